@@ -2,86 +2,415 @@ package main
 
 import (
 	"context"
-	"log"
+	"flag"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/auth"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/codehost"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/codehost/github"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/codehost/gitlab"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/config"
 	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/db"
 	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/handlers"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/health"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/logger"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/metrics"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/outbox"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/ratelimit"
 	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/repositories"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/reviewsync"
 	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/services"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/tracing"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/webhooks"
 	"github.com/gin-gonic/gin"
 )
 
+// bootstrapTokenID — ID admin-токена из AUTH_BOOTSTRAP_TOKEN
+const bootstrapTokenID = "bootstrap"
+
 func main() {
-	database, err := db.Connect()
+	configPath := flag.String("config", os.Getenv("CONFIG_FILE"), "path to YAML config file")
+	printConfig := flag.Bool("print-config", false, "print effective config with secrets redacted and exit")
+	flag.Parse()
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if *printConfig {
+		out, err := cfg.YAML()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Stdout.Write(out)
+		return
+	}
+
+	log := logger.New(os.Stdout, logger.ParseLevel(cfg.Log.Level))
+	slog.SetDefault(log)
+	log.Info("config loaded", "config", cfg)
+
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+		ServiceName: cfg.Tracing.ServiceName,
+	})
 	if err != nil {
-		log.Fatal("Can't connect to database:", err)
+		fatal("can't set up tracing", err)
+	}
+
+	// Повторы подключения прерываются по Ctrl+C, не дожидаясь всех попыток
+	startupCtx, stopStartup := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	dbOptions := db.Options{
+		Pool: db.PoolConfig{
+			MaxOpenConns:    cfg.Database.MaxOpenConns,
+			MaxIdleConns:    cfg.Database.MaxIdleConns,
+			ConnMaxLifetime: cfg.Database.ConnMaxLifetime,
+			ConnMaxIdleTime: cfg.Database.ConnMaxIdleTime,
+		},
+		Retry: db.RetryConfig{
+			Attempts:       cfg.Database.ConnectAttempts,
+			InitialBackoff: cfg.Database.ConnectBackoff,
+			MaxBackoff:     cfg.Database.ConnectMaxBackoff,
+		},
+		StatementTimeout: cfg.Database.StatementTimeout,
+	}
+	database, err := db.Connect(startupCtx, cfg.Database.DSN, dbOptions)
+	if err != nil {
+		fatal("can't connect to database", err)
 	}
 	defer database.Close()
 
 	repo := repositories.NewRepository(database.DB)
-	reviewService := services.NewReviewService(repo)
-	handler := handlers.NewHandler(reviewService)
+	var replica *db.DB
+	if cfg.Database.ReplicaDSN != "" {
+		replicaOptions := dbOptions
+		replicaOptions.ReadOnly = true
+		replica, err = db.Connect(startupCtx, cfg.Database.ReplicaDSN, replicaOptions)
+		if err != nil {
+			fatal("can't connect to read replica", err)
+		}
+		defer replica.Close()
+		repo = repo.WithReadReplica(replica.DB)
+	}
 
-	r := gin.Default()
+	reviewOptions := []services.Option{
+		services.WithMaxReviewers(cfg.Assignment.MaxReviewers),
+		services.WithStrategy(cfg.Assignment.Strategy),
+	}
+	// События из outbox получают все Sink: вебхуки, синхронизация ревьюеров и, если включено, лог
+	var sinks []outbox.Sink
+	var dispatcher *webhooks.Dispatcher
+	if cfg.Features.Webhooks {
+		dispatcher = webhooks.NewDispatcher(repo, nil, webhooks.Config{
+			PollInterval:   cfg.Webhooks.PollInterval,
+			Timeout:        cfg.Webhooks.Timeout,
+			MaxAttempts:    cfg.Webhooks.MaxAttempts,
+			InitialBackoff: cfg.Webhooks.InitialBackoff,
+			MaxBackoff:     cfg.Webhooks.MaxBackoff,
+			AllowPrivate:   cfg.Webhooks.AllowPrivate,
+		})
+		sinks = append(sinks, outbox.Sink{Name: "webhooks", Publisher: dispatcher})
+	}
+	// Журнал для /events/stream пишет тот же Relay, поэтому порядок событий
+	// в потоке совпадает с порядком публикации
+	if cfg.Features.EventStream {
+		sinks = append(sinks, outbox.Sink{Name: "event_log", Publisher: outbox.EventLogSink{Repo: repo.EventLog}})
+	}
+	// Ревьюеры PR из GitHub и GitLab передаются провайдеру, если задан токен его API
+	var syncer *reviewsync.Syncer
+	if clients := codeHostClients(cfg.Integrations); len(clients) > 0 {
+		syncer = reviewsync.NewSyncer(repo, clients, reviewsync.Config{
+			PollInterval:   cfg.Integrations.ReviewerSync.PollInterval,
+			Timeout:        cfg.Integrations.ReviewerSync.Timeout,
+			MaxAttempts:    cfg.Integrations.ReviewerSync.MaxAttempts,
+			InitialBackoff: cfg.Integrations.ReviewerSync.InitialBackoff,
+			MaxBackoff:     cfg.Integrations.ReviewerSync.MaxBackoff,
+		})
+		sinks = append(sinks, outbox.Sink{Name: "reviewer_sync", Publisher: syncer})
+	}
+	if cfg.Outbox.LogEvents {
+		sinks = append(sinks, outbox.Sink{Name: "log", Publisher: outbox.LogSink{Logger: log}})
+	}
+	relay := outbox.NewRelay(repo, outbox.Config{
+		PollInterval: cfg.Outbox.PollInterval,
+		BatchSize:    cfg.Outbox.BatchSize,
+		LeaseTTL:     cfg.Outbox.LeaseTTL,
+		Retention:    cfg.Outbox.Retention,
+		MaxAttempts:  cfg.Outbox.MaxAttempts,
+	}, sinks...)
+	reviewService := services.NewTracingService(services.NewPolicyService(services.NewReviewService(repo, reviewOptions...), repo))
+	handler := handlers.NewHandler(reviewService)
+	authService := services.NewAuthService(repo)
+	authHandler := handlers.NewAuthHandler(authService)
+	var authenticator services.Authenticator = authService
+	var jwtVerifier *auth.JWTVerifier
+	switch {
+	case cfg.Auth.Enabled && cfg.Auth.Mode == auth.ModeJWT:
+		jwtVerifier = newJWTVerifier(cfg.Auth.JWT)
+		authenticator = jwtVerifier
+		// SSO может быть недоступен при старте: ключи догрузятся на первом запросе
+		if err := jwtVerifier.Refresh(startupCtx); err != nil {
+			log.Warn("can't load jwks", "error", err.Error())
+		}
+	case cfg.Auth.BootstrapToken != "":
+		if err := authService.EnsureToken(startupCtx, bootstrapTokenID, "bootstrap", auth.ScopeAdmin, cfg.Auth.BootstrapToken); err != nil {
+			fatal("can't create bootstrap token", err)
+		}
+	}
+	stopStartup()
 
-	r.Use(handlers.ErrorHandler())
+	r := gin.New()
+	// Без списка gin верит X-Forwarded-For от любого клиента, и ключ лимита
+	// запросов по IP подделывается заголовком
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		fatal("invalid trusted proxies", err)
+	}
 
-	r.GET("/health", func(c *gin.Context) {
+	r.Use(handlers.RequestID(log))
+	r.Use(handlers.AccessLog())
+	r.Use(handlers.Recovery())
+	r.Use(tracing.Middleware())
+	if cfg.Features.Metrics {
+		metrics.SetMaxReviewers(cfg.Assignment.MaxReviewers)
 		sqlDB, err := database.DB.DB()
 		if err != nil {
-			c.JSON(500, gin.H{"status": "unhealthy", "error": err.Error()})
-			return
+			fatal("can't get sql.DB", err)
 		}
-
-		if err := sqlDB.Ping(); err != nil {
-			c.JSON(500, gin.H{"status": "unhealthy", "error": err.Error()})
-			return
+		if err := metrics.RegisterDB(sqlDB, "primary"); err != nil {
+			fatal("can't register database metrics", err)
+		}
+		if replica != nil {
+			replicaSQL, err := replica.DB.DB()
+			if err != nil {
+				fatal("can't get replica sql.DB", err)
+			}
+			if err := metrics.RegisterDB(replicaSQL, "replica"); err != nil {
+				fatal("can't register replica metrics", err)
+			}
 		}
+		r.Use(metrics.Middleware())
+	}
 
-		c.JSON(200, gin.H{"status": "healthy"})
-	})
+	// Поток событий открыт, пока клиент не отключится
+	r.Use(handlers.RequestTimeout(cfg.Server.RequestTimeout, handlers.EventStreamPath))
+	r.Use(handlers.ErrorHandler())
 
-	r.POST("/team/add", handler.CreateTeam)
-	r.GET("/team/get", handler.GetTeam)
-	r.POST("/users/setIsActive", handler.SetUserActive)
-	r.POST("/pullRequest/create", handler.CreatePR)
-	r.POST("/pullRequest/merge", handler.MergePR)
-	r.POST("/pullRequest/reassign", handler.ReassignReviewer)
-	r.GET("/users/getReview", handler.GetUserReviews)
+	readiness := health.NewRegistry(cfg.Server.ReadinessTimeout)
+	readiness.Register("database", database.Ping)
+	readiness.Register("migrations", database.CheckMigrations)
+	if replica != nil {
+		readiness.Register("database_replica", replica.Ping)
+	}
+	if jwtVerifier != nil {
+		readiness.Register("jwks", jwtVerifier.Check)
+	}
+
+	r.GET("/livez", gin.WrapH(health.LivenessHandler()))
+	r.GET("/readyz", gin.WrapH(readiness.ReadinessHandler()))
+	if cfg.Features.Metrics {
+		r.GET("/metrics", gin.WrapH(metrics.Handler()))
+	}
 
-	port := os.Getenv("SERVICE_PORT")
-	if port == "" {
-		port = "8080"
+	// Порядок: аутентификация, лимит запросов, идемпотентность — чтобы ответы
+	// 401 и 429 не сохранялись под ключом идемпотентности
+	api := r.Group("")
+	if cfg.Auth.Enabled {
+		api.Use(handlers.Authenticate(authenticator))
+	}
+	// read — чтение, write — изменения; у групп свои лимиты запросов
+	read, write := api.Group(""), api.Group("")
+	if cfg.RateLimit.Enabled {
+		limits := ratelimit.NewMemory()
+		read.Use(handlers.RateLimit(limits, "read", cfg.RateLimit.Read()))
+		write.Use(handlers.RateLimit(limits, "write", cfg.RateLimit.Write()))
+	}
+	if cfg.Features.Idempotency {
+		write.Use(handlers.Idempotency(repo.Idempotency, cfg.Idempotency.TTL))
+	}
+	// admin — создание команд и токены; остальное доступно и user-токенам,
+	// а права по ролям в команде проверяет services.NewPolicyService
+	admin := write.Group("")
+	if cfg.Auth.Enabled {
+		admin.Use(handlers.RequireScope(auth.ScopeAdmin))
+	}
+
+	admin.POST("/team/add", handler.CreateTeam)
+	read.GET("/team/get", handler.GetTeam)
+	write.POST("/team/setRole", handler.SetTeamRole)
+	write.POST("/users/setIsActive", handler.SetUserActive)
+	write.POST("/pullRequest/create", handler.CreatePR)
+	if cfg.Features.BatchCreate {
+		write.POST("/pullRequest/batchCreate", handler.BatchCreatePRs)
+	}
+	read.GET("/pullRequest/get", handler.GetPR)
+	write.POST("/pullRequest/merge", handler.MergePR)
+	write.POST("/pullRequest/reassign", handler.ReassignReviewer)
+	read.GET("/users/getReview", handler.GetUserReviews)
+	if cfg.Features.Stats {
+		read.GET("/stats/team", handler.GetTeamStats)
+		read.GET("/stats/assignments", handler.GetAssignmentStats)
+	}
+	// В режиме jwt токены выпускает SSO
+	if cfg.Auth.Enabled && cfg.Auth.Mode == auth.ModeToken {
+		admin.POST("/auth/tokens", authHandler.IssueToken)
+		admin.POST("/auth/tokens/revoke", authHandler.RevokeToken)
+	}
+	if cfg.Features.Webhooks {
+		webhookHandler := handlers.NewWebhookHandler(services.NewWebhookPolicyService(
+			services.NewWebhookService(repo, cfg.Webhooks.AllowHTTP, cfg.Webhooks.AllowPrivate), repo))
+		write.POST("/webhooks/add", webhookHandler.CreateWebhook)
+		read.GET("/webhooks/list", webhookHandler.ListWebhooks)
+		write.POST("/webhooks/delete", webhookHandler.DeleteWebhook)
+		read.GET("/webhooks/deliveries", webhookHandler.ListDeliveries)
+	}
+	// Вебхуки провайдеров подтверждаются подписью, а не токеном API
+	codeHostService := services.NewCodeHostPolicyService(services.NewCodeHostService(reviewService, repo))
+	integrationHandler := handlers.NewIntegrationHandler(codeHostService)
+	if cfg.Integrations.GitHubWebhookSecret != "" {
+		r.POST(handlers.WebhookPath(codehost.ProviderGitHub),
+			integrationHandler.Webhook(github.Webhook{Secret: cfg.Integrations.GitHubWebhookSecret}))
+	}
+	if cfg.Integrations.GitLabWebhookToken != "" {
+		r.POST(handlers.WebhookPath(codehost.ProviderGitLab),
+			integrationHandler.Webhook(gitlab.Webhook{Secret: cfg.Integrations.GitLabWebhookToken}))
+	}
+	admin.POST("/integrations/accounts/set", integrationHandler.SetAccount)
+	read.GET("/integrations/accounts/list", integrationHandler.ListAccounts)
+	admin.POST("/integrations/accounts/delete", integrationHandler.DeleteAccount)
+	read.GET("/integrations/syncs/list", integrationHandler.ListSyncs)
+	admin.POST("/integrations/syncs/retry", integrationHandler.RetrySync)
+	var streamHandler *handlers.EventStreamHandler
+	if cfg.Features.EventStream {
+		streamHandler = handlers.NewEventStreamHandler(services.NewEventStreamPolicyService(
+			services.NewEventStreamService(repo), repo), handlers.StreamConfig{
+			PollInterval:      cfg.EventStream.PollInterval,
+			HeartbeatInterval: cfg.EventStream.HeartbeatInterval,
+		})
+		read.GET(handlers.EventStreamPath, streamHandler.Stream)
 	}
 
 	// Graceful shutdown
 	srv := &http.Server{
-		Addr:    ":" + port,
+		Addr:    ":" + strconv.Itoa(cfg.Server.Port),
 		Handler: r,
 	}
+	if streamHandler != nil {
+		// Shutdown ждёт завершения запросов, а потоки сами не завершаются
+		srv.RegisterOnShutdown(streamHandler.Close)
+	}
+
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	purgeHeartbeat := health.NewHeartbeat(2 * cfg.Idempotency.PurgeInterval)
+	readiness.Register("idempotency_purge", purgeHeartbeat.Check)
+	go purgeIdempotencyKeys(workerCtx, repo.Idempotency, cfg.Idempotency.PurgeInterval, purgeHeartbeat)
+
+	outboxHeartbeat := health.NewHeartbeat(2*cfg.Outbox.PollInterval + cfg.Outbox.LeaseTTL)
+	readiness.Register("outbox_relay", outboxHeartbeat.Check)
+	go relay.Run(workerCtx, outboxHeartbeat)
+
+	if dispatcher != nil {
+		// Проход по очереди может занять таймаут запроса к получателю
+		webhookHeartbeat := health.NewHeartbeat(2*cfg.Webhooks.PollInterval + cfg.Webhooks.Timeout)
+		readiness.Register("webhook_delivery", webhookHeartbeat.Check)
+		go dispatcher.Run(workerCtx, webhookHeartbeat)
+	}
+	if syncer != nil {
+		syncHeartbeat := health.NewHeartbeat(2*cfg.Integrations.ReviewerSync.PollInterval + cfg.Integrations.ReviewerSync.Timeout)
+		readiness.Register("reviewer_sync", syncHeartbeat.Check)
+		go syncer.Run(workerCtx, syncHeartbeat)
+	}
 
 	go func() {
-		log.Printf("Server starting on port %s", port)
+		log.Info("server starting", "port", cfg.Server.Port)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Server failed: %s", err)
+			fatal("server failed", err)
 		}
 	}()
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
-	log.Println("Shutting down server...")
+	log.Info("shutting down server")
+
+	// Сначала перестаём быть ready, чтобы балансировщик успел снять под с трафика
+	readiness.SetShuttingDown()
+	time.Sleep(cfg.Server.ShutdownDelay)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatal("Server forced to shutdown:", err)
+		fatal("server forced to shutdown", err)
+	}
+	if err := shutdownTracing(ctx); err != nil {
+		log.Error("failed to flush traces", "error", err.Error())
+	}
+
+	log.Info("server exited gracefully")
+}
+
+// purgeIdempotencyKeys периодически удаляет истёкшие ключи идемпотентности
+func purgeIdempotencyKeys(ctx context.Context, store repositories.IdempotencyRepository, interval time.Duration, heartbeat *health.Heartbeat) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := store.DeleteExpired(ctx, time.Now())
+			if err != nil {
+				slog.Error("failed to purge idempotency keys", "error", err.Error())
+				heartbeat.Fail(err)
+				continue
+			}
+			if n > 0 {
+				slog.Info("purged expired idempotency keys", "count", n)
+			}
+			heartbeat.Beat()
+		}
+	}
+}
+
+// codeHostClients возвращает клиенты API провайдеров, для которых задан токен
+func codeHostClients(cfg config.IntegrationsConfig) []codehost.Client {
+	var clients []codehost.Client
+	if cfg.GitHubToken != "" {
+		clients = append(clients, github.NewClient(cfg.GitHubAPIURL, cfg.GitHubToken, nil))
+	}
+	if cfg.GitLabToken != "" {
+		clients = append(clients, gitlab.NewClient(cfg.GitLabURL, cfg.GitLabToken, nil))
 	}
+	return clients
+}
+
+func newJWTVerifier(cfg config.JWTConfig) *auth.JWTVerifier {
+	source := auth.JWKSFromFile(cfg.JWKSFile)
+	if cfg.JWKSURL != "" {
+		source = auth.JWKSFromURL(cfg.JWKSURL, &http.Client{Timeout: 10 * time.Second})
+	}
+	return auth.NewJWTVerifier(auth.JWTConfig{
+		Issuer:     cfg.Issuer,
+		Audience:   cfg.Audience,
+		UserClaim:  cfg.UserClaim,
+		RolesClaim: cfg.RolesClaim,
+		AdminRole:  cfg.AdminRole,
+		Refresh:    cfg.JWKSRefresh,
+	}, source)
+}
 
-	log.Println("Server exited gracefully")
+// fatal логирует ошибку и завершает процесс
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err.Error())
+	os.Exit(1)
 }
@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// Scopes токенов
+const (
+	// ScopeAdmin — изменение команд, пользователей и PR, управление токенами
+	ScopeAdmin = "admin"
+	// ScopeUser — чтение и запросы о собственных ревью
+	ScopeUser = "user"
+)
+
+// Роли пользователя в команде
+const (
+	// RoleLead управляет составом команды и переназначает ревьюеров
+	RoleLead = "lead"
+	// RoleMember видит команду и может снять себя с ревью
+	RoleMember = "member"
+	// RoleBot создаёт и мержит PR от имени команды (CI, интеграции)
+	RoleBot = "bot"
+)
+
+// tokenPrefix помогает узнать токен сервиса в логах и сканерах секретов
+const tokenPrefix = "prs_"
+
+// Principal — тот, от чьего имени выполняется запрос
+type Principal struct {
+	TokenID string
+	Scope   string
+	// UserID — пользователь, к которому привязан токен; пусто для сервисных токенов
+	UserID string
+	// Roles — роли по имени команды из claims JWT; важнее ролей из базы
+	Roles map[string]string
+}
+
+func (p *Principal) IsAdmin() bool {
+	return p.Scope == ScopeAdmin
+}
+
+type ctxKey struct{}
+
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, ctxKey{}, p)
+}
+
+// FromContext возвращает principal запроса или nil, если аутентификация выключена
+func FromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(ctxKey{}).(*Principal)
+	return p
+}
+
+func ValidScope(scope string) bool {
+	return scope == ScopeAdmin || scope == ScopeUser
+}
+
+func ValidRole(role string) bool {
+	return role == RoleLead || role == RoleMember || role == RoleBot
+}
+
+// GenerateToken создаёт новый случайный токен
+func GenerateToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return tokenPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// GenerateID создаёт идентификатор токена, который можно безопасно показывать и логировать
+func GenerateID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "tok_" + hex.EncodeToString(b), nil
+}
+
+// HashToken возвращает хэш токена для хранения и поиска.
+// Токены случайные и длинные, поэтому медленный KDF не нужен.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,370 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/errors"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/logger"
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+)
+
+// Режимы аутентификации
+const (
+	// ModeToken — токены, выпущенные сервисом и хранящиеся в базе
+	ModeToken = "token"
+	// ModeJWT — JWT от внешнего SSO, подпись проверяется по JWKS
+	ModeJWT = "jwt"
+)
+
+// Значения по умолчанию для JWTConfig
+const (
+	DefaultUserClaim   = "sub"
+	DefaultRolesClaim  = "roles"
+	DefaultAdminRole   = "admin"
+	DefaultJWKSRefresh = 15 * time.Minute
+)
+
+// minRefetchInterval ограничивает внеплановые загрузки JWKS, когда приходит токен
+// с неизвестным kid: иначе поток поддельных токенов превратится в поток запросов к SSO.
+const minRefetchInterval = time.Minute
+
+var signatureAlgorithms = []jose.SignatureAlgorithm{
+	jose.RS256, jose.RS384, jose.RS512,
+	jose.PS256, jose.PS384, jose.PS512,
+	jose.ES256, jose.ES384, jose.ES512,
+	jose.EdDSA,
+}
+
+type JWTConfig struct {
+	// Issuer и Audience проверяются, если заданы
+	Issuer   string
+	Audience string
+	// UserClaim — claim с ID пользователя; вложенные claims через точку
+	UserClaim string
+	// RolesClaim — claim со списком ролей, например realm_access.roles.
+	// Роль AdminRole даёт scope admin, роли вида "<команда>:<роль>" — роль в команде.
+	RolesClaim string
+	AdminRole  string
+	// Refresh — как часто перечитывать JWKS
+	Refresh time.Duration
+}
+
+// KeySource загружает набор ключей JWKS
+type KeySource func(ctx context.Context) (*jose.JSONWebKeySet, error)
+
+// JWKSFromFile читает JWKS из файла
+func JWKSFromFile(path string) KeySource {
+	return func(ctx context.Context) (*jose.JSONWebKeySet, error) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return parseJWKS(data)
+	}
+}
+
+// JWKSFromURL загружает JWKS по HTTP(S), например с jwks_uri провайдера OIDC
+func JWKSFromURL(url string, client *http.Client) KeySource {
+	return func(ctx context.Context) (*jose.JSONWebKeySet, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("fetch jwks: unexpected status %d", resp.StatusCode)
+		}
+		data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		if err != nil {
+			return nil, err
+		}
+		return parseJWKS(data)
+	}
+}
+
+func parseJWKS(data []byte) (*jose.JSONWebKeySet, error) {
+	var keys jose.JSONWebKeySet
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("parse jwks: %w", err)
+	}
+	if len(keys.Keys) == 0 {
+		return nil, stderrors.New("parse jwks: no keys")
+	}
+	return &keys, nil
+}
+
+// JWTVerifier проверяет JWT и превращает claims в Principal.
+// Ключи кэшируются и перечитываются раз в Refresh или при неизвестном kid.
+// JWKS загружается без блокировки: запросы тем временем проверяются по
+// прежним ключам, а одновременные загрузки объединяются в одну.
+type JWTVerifier struct {
+	cfg    JWTConfig
+	source KeySource
+	now    func() time.Time
+
+	mu        sync.Mutex
+	keys      *jose.JSONWebKeySet
+	fetchedAt time.Time
+	fetchErr  error
+	// inflight — идущая загрузка JWKS; её ждут все, кому нужны свежие ключи
+	inflight *fetchCall
+}
+
+// fetchCall — одна загрузка JWKS; done закрывается, когда err заполнен
+type fetchCall struct {
+	done chan struct{}
+	err  error
+}
+
+func NewJWTVerifier(cfg JWTConfig, source KeySource) *JWTVerifier {
+	if cfg.UserClaim == "" {
+		cfg.UserClaim = DefaultUserClaim
+	}
+	if cfg.RolesClaim == "" {
+		cfg.RolesClaim = DefaultRolesClaim
+	}
+	if cfg.AdminRole == "" {
+		cfg.AdminRole = DefaultAdminRole
+	}
+	if cfg.Refresh <= 0 {
+		cfg.Refresh = DefaultJWKSRefresh
+	}
+	return &JWTVerifier{cfg: cfg, source: source, now: time.Now}
+}
+
+// Refresh загружает JWKS. При ошибке остаются прежние ключи.
+func (v *JWTVerifier) Refresh(ctx context.Context) error {
+	v.mu.Lock()
+	call := v.fetchLocked(ctx)
+	v.mu.Unlock()
+	return wait(ctx, call)
+}
+
+// fetchLocked запускает загрузку JWKS или возвращает уже идущую. Загрузка не
+// зависит от отмены ctx: её результат нужен и другим запросам.
+func (v *JWTVerifier) fetchLocked(ctx context.Context) *fetchCall {
+	if v.inflight != nil {
+		return v.inflight
+	}
+	call := &fetchCall{done: make(chan struct{})}
+	v.inflight = call
+	go func() {
+		keys, err := v.source(context.WithoutCancel(ctx))
+		v.mu.Lock()
+		v.fetchedAt = v.now()
+		v.fetchErr = err
+		if err == nil {
+			v.keys = keys
+		}
+		v.inflight = nil
+		v.mu.Unlock()
+		if err != nil {
+			logger.FromContext(ctx).Warn("jwks refresh failed", "error", err.Error())
+		}
+		call.err = err
+		close(call.done)
+	}()
+	return call
+}
+
+func wait(ctx context.Context, call *fetchCall) error {
+	select {
+	case <-call.done:
+		return call.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Check для /readyz: ключи загружены хотя бы раз
+func (v *JWTVerifier) Check(ctx context.Context) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.keys != nil {
+		return nil
+	}
+	if v.fetchErr != nil {
+		return fmt.Errorf("jwks not loaded: %w", v.fetchErr)
+	}
+	return stderrors.New("jwks not loaded")
+}
+
+// key возвращает ключ по kid, при необходимости перечитывая JWKS
+func (v *JWTVerifier) key(ctx context.Context, kid string) (*jose.JSONWebKey, error) {
+	v.mu.Lock()
+	keys := v.keys
+	var call *fetchCall
+	if keys == nil || v.now().Sub(v.fetchedAt) >= v.cfg.Refresh {
+		call = v.fetchLocked(ctx)
+	}
+	v.mu.Unlock()
+	// Плановое обновление идёт в фоне; ждём его, только если ключей ещё нет
+	if keys == nil {
+		if err := wait(ctx, call); err != nil {
+			return nil, err
+		}
+		keys = v.currentKeys()
+	}
+	if k := findKey(keys, kid); k != nil {
+		return k, nil
+	}
+
+	// Провайдер мог сменить ключи раньше планового обновления
+	v.mu.Lock()
+	call = v.inflight
+	if call == nil && v.now().Sub(v.fetchedAt) >= minRefetchInterval {
+		call = v.fetchLocked(ctx)
+	}
+	v.mu.Unlock()
+	if call != nil {
+		if err := wait(ctx, call); err != nil {
+			return nil, err
+		}
+		if k := findKey(v.currentKeys(), kid); k != nil {
+			return k, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown key id %q", kid)
+}
+
+func (v *JWTVerifier) currentKeys() *jose.JSONWebKeySet {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.keys
+}
+
+// findKey ищет ключ подписи по kid; без kid подходит единственный ключ в наборе
+func findKey(keys *jose.JSONWebKeySet, kid string) *jose.JSONWebKey {
+	if keys == nil {
+		return nil
+	}
+	if kid == "" {
+		if len(keys.Keys) == 1 {
+			return &keys.Keys[0]
+		}
+		return nil
+	}
+	for _, k := range keys.Key(kid) {
+		if k.Use == "" || k.Use == "sig" {
+			return &k
+		}
+	}
+	return nil
+}
+
+// Verify проверяет подпись и стандартные claims и возвращает Principal
+func (v *JWTVerifier) Verify(ctx context.Context, token string) (*Principal, error) {
+	parsed, err := jwt.ParseSigned(token, signatureAlgorithms)
+	if err != nil {
+		return nil, err
+	}
+	key, err := v.key(ctx, parsed.Headers[0].KeyID)
+	if err != nil {
+		return nil, err
+	}
+	var std jwt.Claims
+	var custom map[string]any
+	if err := parsed.Claims(key.Key, &std, &custom); err != nil {
+		return nil, err
+	}
+	if std.Expiry == nil {
+		return nil, stderrors.New("token has no exp claim")
+	}
+	expected := jwt.Expected{Issuer: v.cfg.Issuer, Time: v.now()}
+	if v.cfg.Audience != "" {
+		expected.AnyAudience = jwt.Audience{v.cfg.Audience}
+	}
+	if err := std.ValidateWithLeeway(expected, jwt.DefaultLeeway); err != nil {
+		return nil, err
+	}
+	return v.principal(std, custom)
+}
+
+func (v *JWTVerifier) principal(std jwt.Claims, claims map[string]any) (*Principal, error) {
+	principal := &Principal{TokenID: "jwt", Scope: ScopeUser}
+	if std.ID != "" {
+		principal.TokenID = "jwt:" + std.ID
+	}
+	if userID, ok := lookupClaim(claims, v.cfg.UserClaim).(string); ok {
+		principal.UserID = userID
+	}
+	for _, role := range stringList(lookupClaim(claims, v.cfg.RolesClaim)) {
+		if role == v.cfg.AdminRole {
+			principal.Scope = ScopeAdmin
+			continue
+		}
+		// Имя команды может содержать двоеточие, роль — нет
+		i := strings.LastIndex(role, ":")
+		if i <= 0 {
+			continue
+		}
+		if team, teamRole := role[:i], role[i+1:]; ValidRole(teamRole) {
+			if principal.Roles == nil {
+				principal.Roles = make(map[string]string)
+			}
+			principal.Roles[team] = teamRole
+		}
+	}
+	if principal.Scope == ScopeUser && principal.UserID == "" {
+		return nil, fmt.Errorf("claim %q is required", v.cfg.UserClaim)
+	}
+	return principal, nil
+}
+
+// Authenticate — Verify для middleware: любая причина отказа превращается в UNAUTHORIZED,
+// а сама причина пишется в лог
+func (v *JWTVerifier) Authenticate(ctx context.Context, token string) (*Principal, error) {
+	if token == "" {
+		return nil, errors.NewUnauthorized()
+	}
+	principal, err := v.Verify(ctx, token)
+	if err != nil {
+		logger.FromContext(ctx).Info("jwt rejected", "error", err.Error())
+		return nil, errors.NewUnauthorized()
+	}
+	return principal, nil
+}
+
+// lookupClaim достаёт claim по пути через точку (realm_access.roles)
+func lookupClaim(claims map[string]any, path string) any {
+	var current any = claims
+	for _, part := range strings.Split(path, ".") {
+		m, ok := current.(map[string]any)
+		if !ok {
+			return nil
+		}
+		current = m[part]
+	}
+	return current
+}
+
+// stringList принимает массив строк или строку с ролями через пробел (как scope в OAuth)
+func stringList(value any) []string {
+	switch v := value.(type) {
+	case string:
+		return strings.Fields(v)
+	case []any:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/errors"
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testIssuer   = "https://sso.example.com"
+	testAudience = "pr-service"
+)
+
+type testKey struct {
+	kid    string
+	alg    jose.SignatureAlgorithm
+	signer any
+	public any
+}
+
+func newRSAKey(t *testing.T, kid string) testKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return testKey{kid: kid, alg: jose.RS256, signer: key, public: &key.PublicKey}
+}
+
+func newECKey(t *testing.T, kid string) testKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return testKey{kid: kid, alg: jose.ES256, signer: key, public: &key.PublicKey}
+}
+
+func jwks(t *testing.T, keys ...testKey) []byte {
+	t.Helper()
+	set := jose.JSONWebKeySet{}
+	for _, k := range keys {
+		set.Keys = append(set.Keys, jose.JSONWebKey{Key: k.public, KeyID: k.kid, Algorithm: string(k.alg), Use: "sig"})
+	}
+	data, err := json.Marshal(set)
+	require.NoError(t, err)
+	return data
+}
+
+func sign(t *testing.T, key testKey, claims map[string]any) string {
+	t.Helper()
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: key.alg, Key: key.signer},
+		(&jose.SignerOptions{}).WithType("JWT").WithHeader(jose.HeaderKey("kid"), key.kid))
+	require.NoError(t, err)
+	token, err := jwt.Signed(signer).Claims(claims).Serialize()
+	require.NoError(t, err)
+	return token
+}
+
+func claims(overrides map[string]any) map[string]any {
+	c := map[string]any{
+		"iss": testIssuer,
+		"aud": testAudience,
+		"sub": "u1",
+		"exp": time.Now().Add(time.Hour).Unix(),
+		"iat": time.Now().Unix(),
+	}
+	for k, v := range overrides {
+		if v == nil {
+			delete(c, k)
+			continue
+		}
+		c[k] = v
+	}
+	return c
+}
+
+func fileVerifier(t *testing.T, cfg JWTConfig, keys ...testKey) *JWTVerifier {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, jwks(t, keys...), 0o600))
+	cfg.Issuer, cfg.Audience = testIssuer, testAudience
+	return NewJWTVerifier(cfg, JWKSFromFile(path))
+}
+
+func TestJWTVerifier_MapsClaims(t *testing.T) {
+	ctx := context.Background()
+	rsaKey, ecKey := newRSAKey(t, "rsa-1"), newECKey(t, "ec-1")
+	v := fileVerifier(t, JWTConfig{RolesClaim: "realm_access.roles"}, rsaKey, ecKey)
+
+	principal, err := v.Verify(ctx, sign(t, rsaKey, claims(map[string]any{
+		"jti":          "abc",
+		"realm_access": map[string]any{"roles": []string{"offline_access", "backend:lead", "mobile:bot", "web:owner"}},
+	})))
+	require.NoError(t, err)
+	assert.Equal(t, &Principal{
+		TokenID: "jwt:abc",
+		Scope:   ScopeUser,
+		UserID:  "u1",
+		Roles:   map[string]string{"backend": RoleLead, "mobile": RoleBot},
+	}, principal)
+
+	principal, err = v.Verify(ctx, sign(t, ecKey, claims(map[string]any{
+		"sub":          "sso-admin",
+		"realm_access": map[string]any{"roles": []string{"admin"}},
+	})))
+	require.NoError(t, err)
+	assert.True(t, principal.IsAdmin())
+	assert.Equal(t, "sso-admin", principal.UserID)
+}
+
+func TestJWTVerifier_Rejects(t *testing.T) {
+	ctx := context.Background()
+	key, other := newRSAKey(t, "rsa-1"), newRSAKey(t, "rsa-1")
+	v := fileVerifier(t, JWTConfig{}, key)
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"garbage", "not-a-jwt"},
+		{"expired", sign(t, key, claims(map[string]any{"exp": time.Now().Add(-time.Hour).Unix()}))},
+		{"no exp", sign(t, key, claims(map[string]any{"exp": nil}))},
+		{"wrong issuer", sign(t, key, claims(map[string]any{"iss": "https://evil.example.com"}))},
+		{"wrong audience", sign(t, key, claims(map[string]any{"aud": "other-service"}))},
+		{"no user", sign(t, key, claims(map[string]any{"sub": nil}))},
+		{"foreign key", sign(t, other, claims(nil))},
+		{"unknown kid", sign(t, newRSAKey(t, "rsa-2"), claims(nil))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := v.Verify(ctx, tt.token)
+			assert.Error(t, err)
+
+			_, err = v.Authenticate(ctx, tt.token)
+			assert.True(t, errors.IsUnauthorized(err))
+		})
+	}
+}
+
+func TestJWTVerifier_URLRotation(t *testing.T) {
+	ctx := context.Background()
+	oldKey, newKey := newRSAKey(t, "old"), newRSAKey(t, "new")
+	var body atomic.Value
+	body.Store(jwks(t, oldKey))
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		_, _ = w.Write(body.Load().([]byte))
+	}))
+	defer server.Close()
+
+	v := NewJWTVerifier(JWTConfig{Issuer: testIssuer}, JWKSFromURL(server.URL, server.Client()))
+	now := time.Now()
+	v.now = func() time.Time { return now }
+
+	require.Error(t, v.Check(ctx))
+	_, err := v.Verify(ctx, sign(t, oldKey, claims(nil)))
+	require.NoError(t, err)
+	require.NoError(t, v.Check(ctx))
+	assert.Equal(t, int32(1), fetches.Load())
+
+	// Провайдер сменил ключ: сразу после загрузки JWKS повторно не перечитывается
+	body.Store(jwks(t, newKey))
+	token := sign(t, newKey, claims(nil))
+	_, err = v.Verify(ctx, token)
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), fetches.Load())
+
+	now = now.Add(minRefetchInterval)
+	_, err = v.Verify(ctx, token)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), fetches.Load())
+}
+
+func TestJWTVerifier_RefreshDoesNotBlockRequests(t *testing.T) {
+	ctx := context.Background()
+	key := newRSAKey(t, "rsa-1")
+	set := jwks(t, key)
+	release := make(chan struct{})
+	var fetches atomic.Int32
+	v := NewJWTVerifier(JWTConfig{Issuer: testIssuer, Refresh: time.Minute}, func(ctx context.Context) (*jose.JSONWebKeySet, error) {
+		// Первая загрузка сразу, плановое обновление ждёт release
+		if fetches.Add(1) > 1 {
+			<-release
+		}
+		return parseJWKS(set)
+	})
+	var now atomic.Int64
+	now.Store(time.Now().UnixNano())
+	v.now = func() time.Time { return time.Unix(0, now.Load()) }
+	token := sign(t, key, claims(nil))
+	_, err := v.Verify(ctx, token)
+	require.NoError(t, err)
+
+	// Пока SSO не отвечает, запросы проверяются по прежним ключам
+	now.Add(int64(time.Minute))
+	for i := 0; i < 3; i++ {
+		_, err = v.Verify(ctx, token)
+		require.NoError(t, err)
+	}
+	// Обновление запущено в фоне и идёт одно
+	require.Eventually(t, func() bool { return fetches.Load() == 2 }, time.Second, time.Millisecond)
+
+	close(release)
+	require.NoError(t, v.Refresh(ctx))
+}
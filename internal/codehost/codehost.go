@@ -0,0 +1,82 @@
+// Package codehost описывает события о PR из внешних систем хранения кода
+// (GitHub, GitLab) в общем для всех провайдеров виде. Пакеты провайдеров
+// проверяют подлинность запроса и переводят свой формат в Event.
+package codehost
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// Провайдеры
+const (
+	ProviderGitHub = "github"
+	ProviderGitLab = "gitlab"
+)
+
+// Providers — все поддерживаемые провайдеры
+var Providers = []string{ProviderGitHub, ProviderGitLab}
+
+// Действия с PR, на которые реагирует сервис
+const (
+	ActionOpened   = "opened"
+	ActionMerged   = "merged"
+	ActionClosed   = "closed"
+	ActionReopened = "reopened"
+	// ActionDraft — PR стал черновиком, ActionReady — черновик готов к ревью
+	ActionDraft = "draft"
+	ActionReady = "ready"
+)
+
+// Event — событие о PR на стороне провайдера
+type Event struct {
+	Provider string
+	Action   string
+	// Repository — полное имя репозитория (owner/repo, group/project)
+	Repository string
+	// Number — номер PR внутри репозитория
+	Number int
+	Title  string
+	URL    string
+	// AuthorLogin — логин автора у провайдера; пользователь сервиса ищется по нему
+	AuthorLogin string
+	// Draft — PR черновик; учитывается для ActionOpened и ActionReopened
+	Draft bool
+}
+
+// PullRequestID — ID PR в сервисе для PR провайдера, например github:owner/repo#12
+func (e Event) PullRequestID() string {
+	return fmt.Sprintf("%s:%s#%d", e.Provider, e.Repository, e.Number)
+}
+
+// Webhook проверяет и разбирает вебхук провайдера. Новый провайдер
+// подключается реализацией Webhook, обработка событий у всех общая.
+type Webhook interface {
+	// Provider — имя провайдера, например ProviderGitHub
+	Provider() string
+	// Verify проверяет подлинность запроса по заголовкам и телу
+	Verify(header http.Header, body []byte) bool
+	// Parse переводит запрос в Event. Для событий, на которые сервис не
+	// реагирует, возвращает nil без ошибки.
+	Parse(header http.Header, body []byte) (*Event, error)
+}
+
+// ReviewRequest — ревьюеры PR, которых нужно передать провайдеру
+type ReviewRequest struct {
+	Repository string
+	Number     int
+	// Reviewers — логины, с которых запрашивается ревью
+	Reviewers []string
+	// Removed — логины, с которых запрос ревью нужно снять
+	Removed []string
+}
+
+// Client вызывает API провайдера. Ревьюеры, которых на PR добавили вручную
+// у провайдера, остаются: клиент только добавляет Reviewers и снимает Removed.
+type Client interface {
+	// Provider — имя провайдера, например ProviderGitHub
+	Provider() string
+	// RequestReviewers запрашивает ревью у Reviewers и снимает запрос с Removed
+	RequestReviewers(ctx context.Context, req ReviewRequest) error
+}
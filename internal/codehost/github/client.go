@@ -0,0 +1,89 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/codehost"
+)
+
+// DefaultAPIURL — адрес API github.com; для GitHub Enterprise это https://<host>/api/v3
+const DefaultAPIURL = "https://api.github.com"
+
+// apiVersion — версия REST API, под которую написан клиент
+const apiVersion = "2022-11-28"
+
+// Client запрашивает ревью у пользователей GitHub
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+// NewClient создаёт клиент API по адресу baseURL с токеном token.
+// Если httpClient nil, используется http.DefaultClient.
+func NewClient(baseURL, token string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		http:    httpClient,
+	}
+}
+
+func (*Client) Provider() string {
+	return codehost.ProviderGitHub
+}
+
+// RequestReviewers добавляет запросы ревью (POST requested_reviewers) и снимает
+// их с Removed (DELETE requested_reviewers). Повторный запрос у того же
+// ревьюера GitHub принимает без ошибки.
+func (c *Client) RequestReviewers(ctx context.Context, req codehost.ReviewRequest) error {
+	path := fmt.Sprintf("/repos/%s/pulls/%d/requested_reviewers", req.Repository, req.Number)
+	if len(req.Removed) > 0 {
+		if err := c.do(ctx, http.MethodDelete, path, req.Removed); err != nil {
+			return err
+		}
+	}
+	if len(req.Reviewers) > 0 {
+		if err := c.do(ctx, http.MethodPost, path, req.Reviewers); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, reviewers []string) error {
+	body, err := json.Marshal(map[string][]string{"reviewers": reviewers})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("X-GitHub-Api-Version", apiVersion)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// Текст ответа попадает в ошибку: по нему видно, почему GitHub отказал
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("github: %s %s: unexpected status %d: %s",
+			method, path, resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
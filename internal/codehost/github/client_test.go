@@ -0,0 +1,85 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/codehost"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordedRequest struct {
+	method    string
+	path      string
+	reviewers []string
+}
+
+// fakeAPI — локальный сервер вместо api.github.com; отвечает status на все запросы
+func fakeAPI(t *testing.T, status int) (*httptest.Server, func() []recordedRequest) {
+	t.Helper()
+	var (
+		mu       sync.Mutex
+		requests []recordedRequest
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer test-token", r.Header.Get("Authorization"))
+		assert.Equal(t, apiVersion, r.Header.Get("X-GitHub-Api-Version"))
+		var body struct {
+			Reviewers []string `json:"reviewers"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		mu.Lock()
+		requests = append(requests, recordedRequest{method: r.Method, path: r.URL.Path, reviewers: body.Reviewers})
+		mu.Unlock()
+		w.WriteHeader(status)
+		if status >= 400 {
+			_, _ = w.Write([]byte(`{"message":"Reviews may only be requested from collaborators."}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []recordedRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return requests
+	}
+}
+
+func TestClient_RequestReviewers(t *testing.T) {
+	server, requests := fakeAPI(t, http.StatusCreated)
+	client := NewClient(server.URL+"/", "test-token", server.Client())
+
+	err := client.RequestReviewers(context.Background(), codehost.ReviewRequest{
+		Repository: "acme/payments",
+		Number:     42,
+		Reviewers:  []string{"alice", "bob"},
+		Removed:    []string{"carol"},
+	})
+
+	require.NoError(t, err)
+	const path = "/repos/acme/payments/pulls/42/requested_reviewers"
+	assert.Equal(t, []recordedRequest{
+		{method: http.MethodDelete, path: path, reviewers: []string{"carol"}},
+		{method: http.MethodPost, path: path, reviewers: []string{"alice", "bob"}},
+	}, requests())
+}
+
+func TestClient_RequestReviewersError(t *testing.T) {
+	server, requests := fakeAPI(t, http.StatusUnprocessableEntity)
+	client := NewClient(server.URL, "test-token", server.Client())
+
+	err := client.RequestReviewers(context.Background(), codehost.ReviewRequest{
+		Repository: "acme/payments",
+		Number:     42,
+		Reviewers:  []string{"alice"},
+	})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unexpected status 422")
+	assert.Contains(t, err.Error(), "collaborators")
+	assert.Len(t, requests(), 1)
+}
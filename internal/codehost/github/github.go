@@ -0,0 +1,122 @@
+// Package github принимает вебхуки GitHub о pull request и запрашивает ревью
+// через REST API GitHub.
+package github
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/codehost"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/errors"
+)
+
+// Заголовки вебхука GitHub
+const (
+	HeaderEvent     = "X-GitHub-Event"
+	HeaderDelivery  = "X-GitHub-Delivery"
+	HeaderSignature = "X-Hub-Signature-256"
+)
+
+// Типы событий GitHub
+const (
+	EventPullRequest = "pull_request"
+	EventPing        = "ping"
+)
+
+// Sign возвращает подпись тела body в формате X-Hub-Signature-256
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify проверяет подпись X-Hub-Signature-256
+func Verify(secret string, body []byte, signature string) bool {
+	if secret == "" || !strings.HasPrefix(signature, "sha256=") {
+		return false
+	}
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
+
+type pullRequestPayload struct {
+	Action      string `json:"action"`
+	PullRequest struct {
+		Number  int    `json:"number"`
+		Title   string `json:"title"`
+		HTMLURL string `json:"html_url"`
+		Merged  bool   `json:"merged"`
+		Draft   bool   `json:"draft"`
+		User    struct {
+			Login string `json:"login"`
+		} `json:"user"`
+	} `json:"pull_request"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+}
+
+// Parse переводит событие GitHub в codehost.Event. Для событий и действий,
+// на которые сервис не реагирует (ping, edited, synchronize и т.п.), возвращает nil.
+// Черновики: converted_to_draft и ready_for_review, а opened и reopened несут флаг draft.
+func Parse(eventName string, body []byte) (*codehost.Event, error) {
+	if eventName != EventPullRequest {
+		return nil, nil
+	}
+	var payload pullRequestPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, errors.NewInvalidInput("invalid pull_request payload")
+	}
+	var action string
+	switch payload.Action {
+	case "opened":
+		action = codehost.ActionOpened
+	case "reopened":
+		action = codehost.ActionReopened
+	case "converted_to_draft":
+		action = codehost.ActionDraft
+	case "ready_for_review":
+		action = codehost.ActionReady
+	case "closed":
+		// GitHub присылает merge как closed с merged: true
+		action = codehost.ActionClosed
+		if payload.PullRequest.Merged {
+			action = codehost.ActionMerged
+		}
+	default:
+		return nil, nil
+	}
+	if payload.Repository.FullName == "" || payload.PullRequest.Number == 0 {
+		return nil, errors.NewInvalidInput("pull_request payload has no repository or number")
+	}
+	return &codehost.Event{
+		Provider:    codehost.ProviderGitHub,
+		Action:      action,
+		Repository:  payload.Repository.FullName,
+		Number:      payload.PullRequest.Number,
+		Title:       payload.PullRequest.Title,
+		URL:         payload.PullRequest.HTMLURL,
+		AuthorLogin: payload.PullRequest.User.Login,
+		Draft:       payload.PullRequest.Draft,
+	}, nil
+}
+
+// Webhook — вебхук GitHub с секретом Secret для codehost.Webhook
+type Webhook struct {
+	Secret string
+}
+
+func (Webhook) Provider() string {
+	return codehost.ProviderGitHub
+}
+
+func (w Webhook) Verify(header http.Header, body []byte) bool {
+	return Verify(w.Secret, body, header.Get(HeaderSignature))
+}
+
+func (Webhook) Parse(header http.Header, body []byte) (*codehost.Event, error) {
+	return Parse(header.Get(HeaderEvent), body)
+}
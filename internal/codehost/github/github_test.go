@@ -0,0 +1,87 @@
+package github
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/codehost"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fixture(t *testing.T, name string) []byte {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+	return body
+}
+
+func TestVerify(t *testing.T) {
+	body := fixture(t, "pull_request_opened.json")
+	signature := Sign("secret", body)
+	assert.Regexp(t, `^sha256=[0-9a-f]{64}$`, signature)
+	assert.True(t, Verify("secret", body, signature))
+	assert.False(t, Verify("other", body, signature))
+	assert.False(t, Verify("secret", append(body, ' '), signature))
+	assert.False(t, Verify("secret", body, ""))
+	// Без секрета подпись не проверить — запрос отклоняется
+	assert.False(t, Verify("", body, Sign("", body)))
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		fixture string
+		action  string
+	}{
+		{"pull_request_opened.json", codehost.ActionOpened},
+		{"pull_request_closed.json", codehost.ActionClosed},
+		{"pull_request_merged.json", codehost.ActionMerged},
+		{"pull_request_reopened.json", codehost.ActionReopened},
+		{"pull_request_ready_for_review.json", codehost.ActionReady},
+	}
+	for _, tt := range tests {
+		ev, err := Parse(EventPullRequest, fixture(t, tt.fixture))
+		require.NoError(t, err, tt.fixture)
+		require.NotNil(t, ev, tt.fixture)
+		assert.Equal(t, codehost.Event{
+			Provider:    codehost.ProviderGitHub,
+			Action:      tt.action,
+			Repository:  "acme/payments",
+			Number:      42,
+			Title:       "Add refund endpoint",
+			URL:         "https://github.com/acme/payments/pull/42",
+			AuthorLogin: "octocat",
+		}, *ev, tt.fixture)
+		assert.Equal(t, "github:acme/payments#42", ev.PullRequestID())
+	}
+}
+
+func TestParse_Draft(t *testing.T) {
+	ev, err := Parse(EventPullRequest, fixture(t, "pull_request_converted_to_draft.json"))
+	require.NoError(t, err)
+	require.NotNil(t, ev)
+	assert.Equal(t, codehost.ActionDraft, ev.Action)
+	assert.True(t, ev.Draft)
+
+	ev, err = Parse(EventPullRequest, []byte(`{"action":"opened","pull_request":{"number":7,"draft":true,"user":{"login":"octocat"}},"repository":{"full_name":"acme/payments"}}`))
+	require.NoError(t, err)
+	require.NotNil(t, ev)
+	assert.Equal(t, codehost.ActionOpened, ev.Action)
+	assert.True(t, ev.Draft)
+}
+
+func TestParse_Ignored(t *testing.T) {
+	ev, err := Parse(EventPullRequest, fixture(t, "pull_request_synchronize.json"))
+	require.NoError(t, err)
+	assert.Nil(t, ev)
+	ev, err = Parse(EventPing, fixture(t, "ping.json"))
+	require.NoError(t, err)
+	assert.Nil(t, ev)
+
+	_, err = Parse(EventPullRequest, []byte(`{"action":`))
+	assert.True(t, errors.IsInvalidInput(err))
+	_, err = Parse(EventPullRequest, []byte(`{"action":"opened","pull_request":{}}`))
+	assert.True(t, errors.IsInvalidInput(err))
+}
@@ -0,0 +1,151 @@
+package gitlab
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/codehost"
+)
+
+// DefaultURL — адрес gitlab.com; для своей инсталляции — её корневой адрес
+const DefaultURL = "https://gitlab.com"
+
+// Client назначает ревьюеров merge request в GitLab
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+
+	// userIDs кеширует ID пользователей по username: API MR принимает только ID
+	mu      sync.Mutex
+	userIDs map[string]int
+}
+
+// NewClient создаёт клиент GitLab по адресу baseURL с токеном token.
+// Если httpClient nil, используется http.DefaultClient.
+func NewClient(baseURL, token string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/") + "/api/v4",
+		token:   token,
+		http:    httpClient,
+		userIDs: make(map[string]int),
+	}
+}
+
+func (*Client) Provider() string {
+	return codehost.ProviderGitLab
+}
+
+type glUser struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+}
+
+// RequestReviewers читает текущих ревьюеров MR, добавляет к ним Reviewers, убирает
+// Removed и сохраняет результат. PUT заменяет список целиком, поэтому без чтения
+// текущего списка пропали бы ревьюеры, добавленные в GitLab вручную.
+func (c *Client) RequestReviewers(ctx context.Context, req codehost.ReviewRequest) error {
+	path := fmt.Sprintf("/projects/%s/merge_requests/%d", url.PathEscape(req.Repository), req.Number)
+	var mr struct {
+		Reviewers []glUser `json:"reviewers"`
+	}
+	if err := c.do(ctx, http.MethodGet, path, nil, &mr); err != nil {
+		return err
+	}
+
+	var ids []int
+	usernames := make(map[string]bool, len(mr.Reviewers))
+	for _, user := range mr.Reviewers {
+		usernames[user.Username] = true
+		if !slices.Contains(req.Removed, user.Username) {
+			ids = append(ids, user.ID)
+		}
+	}
+	changed := len(ids) != len(mr.Reviewers)
+	for _, username := range req.Reviewers {
+		if usernames[username] {
+			continue
+		}
+		id, err := c.userID(ctx, username)
+		if err != nil {
+			return err
+		}
+		ids = append(ids, id)
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	// Пустой список снимает всех ревьюеров; nil ушёл бы как null
+	if ids == nil {
+		ids = []int{}
+	}
+	return c.do(ctx, http.MethodPut, path, map[string][]int{"reviewer_ids": ids}, nil)
+}
+
+func (c *Client) userID(ctx context.Context, username string) (int, error) {
+	c.mu.Lock()
+	id, ok := c.userIDs[username]
+	c.mu.Unlock()
+	if ok {
+		return id, nil
+	}
+	var users []glUser
+	if err := c.do(ctx, http.MethodGet, "/users?username="+url.QueryEscape(username), nil, &users); err != nil {
+		return 0, err
+	}
+	if len(users) == 0 {
+		return 0, fmt.Errorf("gitlab: user %q not found", username)
+	}
+	c.mu.Lock()
+	c.userIDs[username] = users[0].ID
+	c.mu.Unlock()
+	return users[0].ID, nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("PRIVATE-TOKEN", c.token)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		// Текст ответа попадает в ошибку: по нему видно, почему GitLab отказал
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+		return fmt.Errorf("gitlab: %s %s: unexpected status %d: %s",
+			method, path, resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	if out == nil {
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
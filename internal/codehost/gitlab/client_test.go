@@ -0,0 +1,145 @@
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/codehost"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeGitLab — локальный сервер вместо gitlab.com с одним MR acme/payments!7
+type fakeGitLab struct {
+	mu        sync.Mutex
+	users     map[string]int
+	reviewers []glUser
+	// userLookups и updates считают запросы к /users и PUT на MR
+	userLookups int
+	updates     int
+	status      int
+}
+
+func newFakeGitLab(t *testing.T, reviewers ...glUser) (*fakeGitLab, *httptest.Server) {
+	t.Helper()
+	fake := &fakeGitLab{
+		users:     map[string]int{"alice": 11, "bob": 12, "carol": 13, "dave": 14},
+		reviewers: reviewers,
+	}
+	mux := http.NewServeMux()
+	const mrPath = "/api/v4/projects/acme%2Fpayments/merge_requests/7"
+	mux.HandleFunc("/api/v4/users", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "test-token", r.Header.Get("PRIVATE-TOKEN"))
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+		fake.userLookups++
+		username := r.URL.Query().Get("username")
+		users := []glUser{}
+		if id, ok := fake.users[username]; ok {
+			users = append(users, glUser{ID: id, Username: username})
+		}
+		_ = json.NewEncoder(w).Encode(users)
+	})
+	mux.HandleFunc("/api/v4/projects/", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "test-token", r.Header.Get("PRIVATE-TOKEN"))
+		// Путь проекта передаётся одним сегментом
+		if r.URL.EscapedPath() != mrPath {
+			http.NotFound(w, r)
+			return
+		}
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+		switch r.Method {
+		case http.MethodGet:
+			_ = json.NewEncoder(w).Encode(map[string]any{"iid": 7, "reviewers": fake.reviewers})
+		case http.MethodPut:
+			fake.updates++
+			if fake.status != 0 {
+				w.WriteHeader(fake.status)
+				return
+			}
+			var body struct {
+				ReviewerIDs []int `json:"reviewer_ids"`
+			}
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			fake.reviewers = nil
+			for _, id := range body.ReviewerIDs {
+				for username, userID := range fake.users {
+					if userID == id {
+						fake.reviewers = append(fake.reviewers, glUser{ID: id, Username: username})
+					}
+				}
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"iid": 7, "reviewers": fake.reviewers})
+		}
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return fake, server
+}
+
+func (f *fakeGitLab) reviewerNames() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var names []string
+	for _, user := range f.reviewers {
+		names = append(names, user.Username)
+	}
+	return names
+}
+
+func TestClient_RequestReviewers(t *testing.T) {
+	// dave добавлен в GitLab вручную и должен остаться
+	fake, server := newFakeGitLab(t, glUser{ID: 13, Username: "carol"}, glUser{ID: 14, Username: "dave"})
+	client := NewClient(server.URL, "test-token", server.Client())
+	ctx := context.Background()
+
+	err := client.RequestReviewers(ctx, codehost.ReviewRequest{
+		Repository: "acme/payments",
+		Number:     7,
+		Reviewers:  []string{"alice", "bob"},
+		Removed:    []string{"carol"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dave", "alice", "bob"}, fake.reviewerNames())
+	assert.Equal(t, 1, fake.updates)
+
+	// Ревьюеры уже назначены — MR не меняется, ID берутся из кеша
+	err = client.RequestReviewers(ctx, codehost.ReviewRequest{
+		Repository: "acme/payments",
+		Number:     7,
+		Reviewers:  []string{"alice", "bob"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, fake.updates)
+	assert.Equal(t, 2, fake.userLookups)
+}
+
+func TestClient_RequestReviewersErrors(t *testing.T) {
+	fake, server := newFakeGitLab(t)
+	client := NewClient(server.URL, "test-token", server.Client())
+	ctx := context.Background()
+
+	err := client.RequestReviewers(ctx, codehost.ReviewRequest{
+		Repository: "acme/payments", Number: 7, Reviewers: []string{"mallory"},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `user "mallory" not found`)
+
+	err = client.RequestReviewers(ctx, codehost.ReviewRequest{
+		Repository: "acme/other", Number: 7, Reviewers: []string{"alice"},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unexpected status 404")
+
+	fake.status = http.StatusForbidden
+	err = client.RequestReviewers(ctx, codehost.ReviewRequest{
+		Repository: "acme/payments", Number: 7, Reviewers: []string{"alice"},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unexpected status 403")
+}
@@ -0,0 +1,120 @@
+// Package gitlab принимает вебхуки GitLab о merge request и назначает
+// ревьюеров через REST API GitLab.
+package gitlab
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/codehost"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/errors"
+)
+
+// Заголовки вебхука GitLab
+const (
+	HeaderEvent     = "X-Gitlab-Event"
+	HeaderToken     = "X-Gitlab-Token"
+	HeaderEventUUID = "X-Gitlab-Event-UUID"
+)
+
+// EventMergeRequest — значение X-Gitlab-Event для событий merge request
+const EventMergeRequest = "Merge Request Hook"
+
+// Verify сравнивает X-Gitlab-Token с секретом: GitLab не подписывает тело,
+// а передаёт секретный токен как есть
+func Verify(secret, token string) bool {
+	if secret == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(secret), []byte(token)) == 1
+}
+
+type mergeRequestPayload struct {
+	ObjectKind string `json:"object_kind"`
+	// User — кто выполнил действие; для open это автор MR
+	User struct {
+		Username string `json:"username"`
+	} `json:"user"`
+	Project struct {
+		PathWithNamespace string `json:"path_with_namespace"`
+	} `json:"project"`
+	ObjectAttributes struct {
+		IID    int    `json:"iid"`
+		Title  string `json:"title"`
+		URL    string `json:"url"`
+		Action string `json:"action"`
+		Draft  bool   `json:"draft"`
+	} `json:"object_attributes"`
+	Changes struct {
+		Draft *struct {
+			Current bool `json:"current"`
+		} `json:"draft"`
+	} `json:"changes"`
+}
+
+// Parse переводит Merge Request Hook в codehost.Event. Действия open, close,
+// reopen и merge переходят в одноимённые, update — в ActionDraft или ActionReady,
+// только если у MR поменялся флаг draft. Остальные события и действия
+// (approved, push и т.п.) возвращают nil.
+func Parse(eventName string, body []byte) (*codehost.Event, error) {
+	if eventName != EventMergeRequest {
+		return nil, nil
+	}
+	var payload mergeRequestPayload
+	if err := json.Unmarshal(body, &payload); err != nil || payload.ObjectKind != "merge_request" {
+		return nil, errors.NewInvalidInput("invalid merge_request payload")
+	}
+	attrs := payload.ObjectAttributes
+	var action string
+	switch attrs.Action {
+	case "open":
+		action = codehost.ActionOpened
+	case "reopen":
+		action = codehost.ActionReopened
+	case "close":
+		action = codehost.ActionClosed
+	case "merge":
+		action = codehost.ActionMerged
+	case "update":
+		if payload.Changes.Draft == nil {
+			return nil, nil
+		}
+		action = codehost.ActionReady
+		if payload.Changes.Draft.Current {
+			action = codehost.ActionDraft
+		}
+	default:
+		return nil, nil
+	}
+	if payload.Project.PathWithNamespace == "" || attrs.IID == 0 {
+		return nil, errors.NewInvalidInput("merge_request payload has no project or iid")
+	}
+	return &codehost.Event{
+		Provider:    codehost.ProviderGitLab,
+		Action:      action,
+		Repository:  payload.Project.PathWithNamespace,
+		Number:      attrs.IID,
+		Title:       attrs.Title,
+		URL:         attrs.URL,
+		AuthorLogin: payload.User.Username,
+		Draft:       attrs.Draft,
+	}, nil
+}
+
+// Webhook — вебхук GitLab с секретным токеном Secret для codehost.Webhook
+type Webhook struct {
+	Secret string
+}
+
+func (Webhook) Provider() string {
+	return codehost.ProviderGitLab
+}
+
+func (w Webhook) Verify(header http.Header, _ []byte) bool {
+	return Verify(w.Secret, header.Get(HeaderToken))
+}
+
+func (Webhook) Parse(header http.Header, body []byte) (*codehost.Event, error) {
+	return Parse(header.Get(HeaderEvent), body)
+}
@@ -0,0 +1,72 @@
+package gitlab
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/codehost"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fixture(t *testing.T, name string) []byte {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+	return body
+}
+
+func TestVerify(t *testing.T) {
+	assert.True(t, Verify("secret", "secret"))
+	assert.False(t, Verify("secret", "other"))
+	assert.False(t, Verify("secret", ""))
+	// Без секрета токен не проверить — запрос отклоняется
+	assert.False(t, Verify("", ""))
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		fixture string
+		action  string
+		draft   bool
+	}{
+		{"merge_request_open.json", codehost.ActionOpened, false},
+		{"merge_request_open_draft.json", codehost.ActionOpened, true},
+		{"merge_request_close.json", codehost.ActionClosed, false},
+		{"merge_request_reopen.json", codehost.ActionReopened, false},
+		{"merge_request_merge.json", codehost.ActionMerged, false},
+		{"merge_request_update_draft.json", codehost.ActionDraft, true},
+		{"merge_request_update_ready.json", codehost.ActionReady, false},
+	}
+	for _, tt := range tests {
+		ev, err := Parse(EventMergeRequest, fixture(t, tt.fixture))
+		require.NoError(t, err, tt.fixture)
+		require.NotNil(t, ev, tt.fixture)
+		assert.Equal(t, codehost.ProviderGitLab, ev.Provider, tt.fixture)
+		assert.Equal(t, tt.action, ev.Action, tt.fixture)
+		assert.Equal(t, tt.draft, ev.Draft, tt.fixture)
+		assert.Equal(t, "acme/payments", ev.Repository, tt.fixture)
+		assert.Equal(t, 7, ev.Number, tt.fixture)
+		assert.Equal(t, "https://gitlab.com/acme/payments/-/merge_requests/7", ev.URL, tt.fixture)
+		assert.Equal(t, "octocat", ev.AuthorLogin, tt.fixture)
+		assert.Equal(t, "gitlab:acme/payments#7", ev.PullRequestID())
+	}
+}
+
+func TestParse_Ignored(t *testing.T) {
+	for _, name := range []string{"merge_request_update_title.json", "merge_request_approved.json"} {
+		ev, err := Parse(EventMergeRequest, fixture(t, name))
+		require.NoError(t, err, name)
+		assert.Nil(t, ev, name)
+	}
+	ev, err := Parse("Push Hook", []byte(`{"object_kind":"push"}`))
+	require.NoError(t, err)
+	assert.Nil(t, ev)
+
+	_, err = Parse(EventMergeRequest, []byte(`{"object_kind":`))
+	assert.True(t, errors.IsInvalidInput(err))
+	_, err = Parse(EventMergeRequest, []byte(`{"object_kind":"merge_request","object_attributes":{"action":"open"}}`))
+	assert.True(t, errors.IsInvalidInput(err))
+}
@@ -0,0 +1,468 @@
+package config
+
+import (
+	"bytes"
+	stderrors "errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/auth"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/codehost/github"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/codehost/gitlab"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/ratelimit"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/services"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/tracing"
+	"gopkg.in/yaml.v3"
+)
+
+// Config — конфигурация сервиса. Значения берутся из Default, затем из YAML-файла,
+// затем из переменных окружения (тег env), так что окружение имеет приоритет.
+// Поля с тегом secret не выводятся как есть: secret:"dsn" скрывает пароль в DSN,
+// любое другое непустое значение тега — всё значение целиком.
+type Config struct {
+	Server       ServerConfig       `yaml:"server"`
+	Database     DatabaseConfig     `yaml:"database"`
+	Log          LogConfig          `yaml:"log"`
+	Tracing      TracingConfig      `yaml:"tracing"`
+	Assignment   AssignmentConfig   `yaml:"assignment"`
+	Idempotency  IdempotencyConfig  `yaml:"idempotency"`
+	Auth         AuthConfig         `yaml:"auth"`
+	RateLimit    RateLimitConfig    `yaml:"rate_limit"`
+	Webhooks     WebhooksConfig     `yaml:"webhooks"`
+	Outbox       OutboxConfig       `yaml:"outbox"`
+	EventStream  EventStreamConfig  `yaml:"event_stream"`
+	Integrations IntegrationsConfig `yaml:"integrations"`
+	Features     FeaturesConfig     `yaml:"features"`
+}
+
+type ServerConfig struct {
+	Port             int           `yaml:"port" env:"SERVICE_PORT"`
+	RequestTimeout   time.Duration `yaml:"request_timeout" env:"REQUEST_TIMEOUT"`
+	ShutdownTimeout  time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT"`
+	ShutdownDelay    time.Duration `yaml:"shutdown_delay" env:"SHUTDOWN_DELAY"`
+	ReadinessTimeout time.Duration `yaml:"readiness_timeout" env:"READINESS_TIMEOUT"`
+	// TrustedProxies — адреса и подсети прокси, которым верим в X-Forwarded-For
+	// (через запятую). Пусто — адрес клиента берётся из соединения, заголовок
+	// не учитывается и не подделывается ради обхода лимита запросов.
+	TrustedProxies []string `yaml:"trusted_proxies" env:"TRUSTED_PROXIES"`
+}
+
+type DatabaseConfig struct {
+	DSN string `yaml:"dsn" env:"CONN_STR" secret:"dsn"`
+	// ReplicaDSN — реплика для чтения (/team/get, /users/getReview, статистика); пусто — читаем из основной базы
+	ReplicaDSN       string        `yaml:"replica_dsn" env:"CONN_STR_REPLICA" secret:"dsn"`
+	MaxOpenConns     int           `yaml:"max_open_conns" env:"DB_MAX_OPEN_CONNS"`
+	MaxIdleConns     int           `yaml:"max_idle_conns" env:"DB_MAX_IDLE_CONNS"`
+	ConnMaxLifetime  time.Duration `yaml:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME"`
+	ConnMaxIdleTime  time.Duration `yaml:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME"`
+	StatementTimeout time.Duration `yaml:"statement_timeout" env:"DB_STATEMENT_TIMEOUT"`
+	// Повторы подключения при старте, пока база поднимается
+	ConnectAttempts   int           `yaml:"connect_attempts" env:"DB_CONNECT_ATTEMPTS"`
+	ConnectBackoff    time.Duration `yaml:"connect_backoff" env:"DB_CONNECT_BACKOFF"`
+	ConnectMaxBackoff time.Duration `yaml:"connect_max_backoff" env:"DB_CONNECT_MAX_BACKOFF"`
+}
+
+type LogConfig struct {
+	Level string `yaml:"level" env:"LOG_LEVEL"`
+}
+
+type TracingConfig struct {
+	Exporter    string `yaml:"exporter" env:"OTEL_TRACES_EXPORTER"`
+	Endpoint    string `yaml:"endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT"`
+	ServiceName string `yaml:"service_name" env:"OTEL_SERVICE_NAME"`
+}
+
+type AssignmentConfig struct {
+	MaxReviewers int    `yaml:"max_reviewers" env:"ASSIGNMENT_MAX_REVIEWERS"`
+	Strategy     string `yaml:"strategy" env:"ASSIGNMENT_STRATEGY"`
+}
+
+type IdempotencyConfig struct {
+	TTL           time.Duration `yaml:"ttl" env:"IDEMPOTENCY_TTL"`
+	PurgeInterval time.Duration `yaml:"purge_interval" env:"IDEMPOTENCY_PURGE_INTERVAL"`
+}
+
+type AuthConfig struct {
+	// Enabled — требовать bearer-токен на всех маршрутах API
+	Enabled bool `yaml:"enabled" env:"AUTH_ENABLED"`
+	// Mode — token (токены из базы) или jwt (JWT от SSO, подпись проверяется по JWKS)
+	Mode string `yaml:"mode" env:"AUTH_MODE"`
+	// BootstrapToken — admin-токен, который создаётся при старте, чтобы выпустить остальные
+	BootstrapToken string    `yaml:"bootstrap_token" env:"AUTH_BOOTSTRAP_TOKEN" secret:"true"`
+	JWT            JWTConfig `yaml:"jwt"`
+}
+
+// JWTConfig — проверка JWT в режиме auth.mode=jwt
+type JWTConfig struct {
+	// JWKS берётся по URL (jwks_uri провайдера) или из файла; задаётся одно из двух
+	JWKSURL     string        `yaml:"jwks_url" env:"AUTH_JWKS_URL"`
+	JWKSFile    string        `yaml:"jwks_file" env:"AUTH_JWKS_FILE"`
+	JWKSRefresh time.Duration `yaml:"jwks_refresh" env:"AUTH_JWKS_REFRESH"`
+	Issuer      string        `yaml:"issuer" env:"AUTH_JWT_ISSUER"`
+	Audience    string        `yaml:"audience" env:"AUTH_JWT_AUDIENCE"`
+	UserClaim   string        `yaml:"user_claim" env:"AUTH_JWT_USER_CLAIM"`
+	RolesClaim  string        `yaml:"roles_claim" env:"AUTH_JWT_ROLES_CLAIM"`
+	AdminRole   string        `yaml:"admin_role" env:"AUTH_JWT_ADMIN_ROLE"`
+}
+
+// RateLimitConfig — лимиты запросов на клиента (token bucket) по группам маршрутов:
+// read — GET, write — изменения. Rate — запросов в секунду, burst — сколько можно
+// сделать подряд; rate 0 снимает лимит с группы.
+type RateLimitConfig struct {
+	Enabled    bool    `yaml:"enabled" env:"RATE_LIMIT_ENABLED"`
+	ReadRate   float64 `yaml:"read_rate" env:"RATE_LIMIT_READ_RATE"`
+	ReadBurst  int     `yaml:"read_burst" env:"RATE_LIMIT_READ_BURST"`
+	WriteRate  float64 `yaml:"write_rate" env:"RATE_LIMIT_WRITE_RATE"`
+	WriteBurst int     `yaml:"write_burst" env:"RATE_LIMIT_WRITE_BURST"`
+}
+
+func (c RateLimitConfig) Read() ratelimit.Limit {
+	return ratelimit.Limit{Rate: c.ReadRate, Burst: c.ReadBurst}
+}
+
+func (c RateLimitConfig) Write() ratelimit.Limit {
+	return ratelimit.Limit{Rate: c.WriteRate, Burst: c.WriteBurst}
+}
+
+// WebhooksConfig — доставка вебхуков: опрос очереди, таймаут запроса и повторы
+type WebhooksConfig struct {
+	PollInterval   time.Duration `yaml:"poll_interval" env:"WEBHOOKS_POLL_INTERVAL"`
+	Timeout        time.Duration `yaml:"timeout" env:"WEBHOOKS_TIMEOUT"`
+	MaxAttempts    int           `yaml:"max_attempts" env:"WEBHOOKS_MAX_ATTEMPTS"`
+	InitialBackoff time.Duration `yaml:"initial_backoff" env:"WEBHOOKS_INITIAL_BACKOFF"`
+	MaxBackoff     time.Duration `yaml:"max_backoff" env:"WEBHOOKS_MAX_BACKOFF"`
+	// AllowHTTP разрешает регистрировать адреса http:// — только для локальной разработки
+	AllowHTTP bool `yaml:"allow_http" env:"WEBHOOKS_ALLOW_HTTP"`
+	// AllowPrivate разрешает localhost и внутренние сети — только для локальной разработки
+	AllowPrivate bool `yaml:"allow_private" env:"WEBHOOKS_ALLOW_PRIVATE"`
+}
+
+// OutboxConfig — публикация доменных событий из outbox
+type OutboxConfig struct {
+	PollInterval time.Duration `yaml:"poll_interval" env:"OUTBOX_POLL_INTERVAL"`
+	BatchSize    int           `yaml:"batch_size" env:"OUTBOX_BATCH_SIZE"`
+	// LeaseTTL — через сколько другой экземпляр подхватит публикацию, если текущий упал
+	LeaseTTL time.Duration `yaml:"lease_ttl" env:"OUTBOX_LEASE_TTL"`
+	// Retention — сколько хранить уже опубликованные события
+	Retention time.Duration `yaml:"retention" env:"OUTBOX_RETENTION"`
+	// MaxAttempts — после стольких неудачных попыток событие помечается failed
+	// и перестаёт задерживать следующие события своего PR
+	MaxAttempts int `yaml:"max_attempts" env:"OUTBOX_MAX_ATTEMPTS"`
+	// LogEvents пишет каждое опубликованное событие в лог
+	LogEvents bool `yaml:"log_events" env:"OUTBOX_LOG_EVENTS"`
+}
+
+// EventStreamConfig — поток событий /events/stream
+type EventStreamConfig struct {
+	PollInterval time.Duration `yaml:"poll_interval" env:"EVENT_STREAM_POLL_INTERVAL"`
+	// HeartbeatInterval должен быть меньше таймаута простоя у прокси перед сервисом
+	HeartbeatInterval time.Duration `yaml:"heartbeat_interval" env:"EVENT_STREAM_HEARTBEAT_INTERVAL"`
+}
+
+// IntegrationsConfig — приём событий о PR от GitHub и GitLab и передача им ревьюеров
+type IntegrationsConfig struct {
+	// GitHubWebhookSecret — секрет вебхука GitHub; пока он не задан,
+	// /integrations/github/webhook не подключается
+	GitHubWebhookSecret string `yaml:"github_webhook_secret" env:"GITHUB_WEBHOOK_SECRET" secret:"true"`
+	// GitLabWebhookToken — секретный токен вебхука GitLab (X-Gitlab-Token);
+	// пока он не задан, /integrations/gitlab/webhook не подключается
+	GitLabWebhookToken string `yaml:"gitlab_webhook_token" env:"GITLAB_WEBHOOK_TOKEN" secret:"true"`
+	// GitHubToken — токен API GitHub для запроса ревью; пока он не задан,
+	// ревьюеры PR из GitHub туда не передаются
+	GitHubToken  string `yaml:"github_token" env:"GITHUB_TOKEN" secret:"true"`
+	GitHubAPIURL string `yaml:"github_api_url" env:"GITHUB_API_URL"`
+	// GitLabToken — токен API GitLab для назначения ревьюеров MR
+	GitLabToken  string             `yaml:"gitlab_token" env:"GITLAB_TOKEN" secret:"true"`
+	GitLabURL    string             `yaml:"gitlab_url" env:"GITLAB_URL"`
+	ReviewerSync ReviewerSyncConfig `yaml:"reviewer_sync"`
+}
+
+// ReviewerSyncConfig — передача ревьюеров провайдеру: опрос очереди, таймаут и повторы
+type ReviewerSyncConfig struct {
+	PollInterval   time.Duration `yaml:"poll_interval" env:"REVIEWER_SYNC_POLL_INTERVAL"`
+	Timeout        time.Duration `yaml:"timeout" env:"REVIEWER_SYNC_TIMEOUT"`
+	MaxAttempts    int           `yaml:"max_attempts" env:"REVIEWER_SYNC_MAX_ATTEMPTS"`
+	InitialBackoff time.Duration `yaml:"initial_backoff" env:"REVIEWER_SYNC_INITIAL_BACKOFF"`
+	MaxBackoff     time.Duration `yaml:"max_backoff" env:"REVIEWER_SYNC_MAX_BACKOFF"`
+}
+
+// FeaturesConfig — флаги, которыми можно отключить части API без пересборки
+type FeaturesConfig struct {
+	Idempotency bool `yaml:"idempotency" env:"FEATURE_IDEMPOTENCY"`
+	Metrics     bool `yaml:"metrics" env:"FEATURE_METRICS"`
+	BatchCreate bool `yaml:"batch_create" env:"FEATURE_BATCH_CREATE"`
+	Stats       bool `yaml:"stats" env:"FEATURE_STATS"`
+	Webhooks    bool `yaml:"webhooks" env:"FEATURE_WEBHOOKS"`
+	EventStream bool `yaml:"event_stream" env:"FEATURE_EVENT_STREAM"`
+}
+
+const minBootstrapTokenLength = 32
+
+// Default возвращает конфигурацию по умолчанию. DSN не задан: пароль к базе
+// должен прийти из окружения или файла.
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Port:             8080,
+			RequestTimeout:   5 * time.Second,
+			ShutdownTimeout:  30 * time.Second,
+			ReadinessTimeout: 2 * time.Second,
+		},
+		Database: DatabaseConfig{
+			MaxOpenConns:      25,
+			MaxIdleConns:      5,
+			ConnMaxLifetime:   30 * time.Minute,
+			ConnMaxIdleTime:   5 * time.Minute,
+			StatementTimeout:  30 * time.Second,
+			ConnectAttempts:   10,
+			ConnectBackoff:    500 * time.Millisecond,
+			ConnectMaxBackoff: 10 * time.Second,
+		},
+		Log: LogConfig{Level: "info"},
+		Tracing: TracingConfig{
+			Exporter:    tracing.ExporterNone,
+			ServiceName: "pr-review-service",
+		},
+		Assignment: AssignmentConfig{
+			MaxReviewers: services.DefaultMaxReviewers,
+			Strategy:     services.StrategyRandom,
+		},
+		Idempotency: IdempotencyConfig{
+			TTL:           24 * time.Hour,
+			PurgeInterval: time.Hour,
+		},
+		Auth: AuthConfig{
+			Enabled: true,
+			Mode:    auth.ModeToken,
+			JWT: JWTConfig{
+				JWKSRefresh: auth.DefaultJWKSRefresh,
+				UserClaim:   auth.DefaultUserClaim,
+				RolesClaim:  auth.DefaultRolesClaim,
+				AdminRole:   auth.DefaultAdminRole,
+			},
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:    true,
+			ReadRate:   50,
+			ReadBurst:  100,
+			WriteRate:  10,
+			WriteBurst: 20,
+		},
+		Webhooks: WebhooksConfig{
+			PollInterval:   5 * time.Second,
+			Timeout:        10 * time.Second,
+			MaxAttempts:    8,
+			InitialBackoff: 10 * time.Second,
+			MaxBackoff:     time.Hour,
+		},
+		Outbox: OutboxConfig{
+			PollInterval: time.Second,
+			BatchSize:    100,
+			LeaseTTL:     30 * time.Second,
+			Retention:    7 * 24 * time.Hour,
+			MaxAttempts:  100,
+		},
+		EventStream: EventStreamConfig{
+			PollInterval:      time.Second,
+			HeartbeatInterval: 15 * time.Second,
+		},
+		Integrations: IntegrationsConfig{
+			GitHubAPIURL: github.DefaultAPIURL,
+			GitLabURL:    gitlab.DefaultURL,
+			ReviewerSync: ReviewerSyncConfig{
+				PollInterval:   5 * time.Second,
+				Timeout:        10 * time.Second,
+				MaxAttempts:    8,
+				InitialBackoff: 10 * time.Second,
+				MaxBackoff:     time.Hour,
+			},
+		},
+		Features: FeaturesConfig{
+			Idempotency: true,
+			Metrics:     true,
+			BatchCreate: true,
+			Stats:       true,
+			Webhooks:    true,
+			EventStream: true,
+		},
+	}
+}
+
+// Load читает конфигурацию из файла path (если задан) и окружения и проверяет её
+func Load(path string) (*Config, error) {
+	return load(path, os.LookupEnv)
+}
+
+func load(path string, lookupEnv func(string) (string, bool)) (*Config, error) {
+	cfg := Default()
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		// Опечатка в имени ключа не должна молча игнорироваться
+		decoder.KnownFields(true)
+		if err := decoder.Decode(cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	}
+	// Ошибки окружения и валидации выводим вместе, чтобы исправить всё за один запуск
+	if err := stderrors.Join(applyEnv(cfg, lookupEnv), cfg.Validate()); err != nil {
+		return nil, fmt.Errorf("invalid config:\n%w", err)
+	}
+	return cfg, nil
+}
+
+// Validate проверяет значения и возвращает все найденные ошибки разом
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Server.Port > 0 && c.Server.Port <= 65535, "server.port (SERVICE_PORT) must be in 1..65535, got %d", c.Server.Port)
+	check(c.Server.RequestTimeout > 0, "server.request_timeout (REQUEST_TIMEOUT) must be positive")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout (SHUTDOWN_TIMEOUT) must be positive")
+	check(c.Server.ShutdownDelay >= 0, "server.shutdown_delay (SHUTDOWN_DELAY) must not be negative")
+	check(c.Server.ReadinessTimeout > 0, "server.readiness_timeout (READINESS_TIMEOUT) must be positive")
+	for _, proxy := range c.Server.TrustedProxies {
+		_, _, cidrErr := net.ParseCIDR(proxy)
+		check(cidrErr == nil || net.ParseIP(proxy) != nil,
+			"server.trusted_proxies (TRUSTED_PROXIES) must contain IP addresses or CIDRs, got %q", proxy)
+	}
+
+	check(c.Database.DSN != "", "database.dsn (CONN_STR) is required")
+	check(c.Database.MaxOpenConns >= 0, "database.max_open_conns (DB_MAX_OPEN_CONNS) must not be negative")
+	check(c.Database.MaxIdleConns >= 0, "database.max_idle_conns (DB_MAX_IDLE_CONNS) must not be negative")
+	check(c.Database.MaxOpenConns == 0 || c.Database.MaxIdleConns <= c.Database.MaxOpenConns,
+		"database.max_idle_conns (%d) must not exceed database.max_open_conns (%d)", c.Database.MaxIdleConns, c.Database.MaxOpenConns)
+	check(c.Database.ConnMaxLifetime >= 0, "database.conn_max_lifetime (DB_CONN_MAX_LIFETIME) must not be negative")
+	check(c.Database.ConnMaxIdleTime >= 0, "database.conn_max_idle_time (DB_CONN_MAX_IDLE_TIME) must not be negative")
+	check(c.Database.StatementTimeout >= 0, "database.statement_timeout (DB_STATEMENT_TIMEOUT) must not be negative")
+	check(c.Database.ConnectAttempts > 0, "database.connect_attempts (DB_CONNECT_ATTEMPTS) must be positive")
+	check(c.Database.ConnectBackoff >= 0, "database.connect_backoff (DB_CONNECT_BACKOFF) must not be negative")
+	check(c.Database.ConnectMaxBackoff >= c.Database.ConnectBackoff,
+		"database.connect_max_backoff (DB_CONNECT_MAX_BACKOFF) must not be less than database.connect_backoff")
+
+	var level slog.Level
+	check(level.UnmarshalText([]byte(c.Log.Level)) == nil, "log.level (LOG_LEVEL) must be debug, info, warn or error, got %q", c.Log.Level)
+
+	switch c.Tracing.Exporter {
+	case tracing.ExporterNone, tracing.ExporterOTLP, tracing.ExporterStdout:
+	default:
+		errs = append(errs, fmt.Errorf("tracing.exporter (OTEL_TRACES_EXPORTER) must be none, otlp or stdout, got %q", c.Tracing.Exporter))
+	}
+	check(c.Tracing.ServiceName != "", "tracing.service_name (OTEL_SERVICE_NAME) is required")
+
+	check(c.Assignment.MaxReviewers > 0, "assignment.max_reviewers (ASSIGNMENT_MAX_REVIEWERS) must be positive")
+	switch c.Assignment.Strategy {
+	case services.StrategyRandom, services.StrategyLeastLoaded:
+	default:
+		errs = append(errs, fmt.Errorf("assignment.strategy (ASSIGNMENT_STRATEGY) must be %s or %s, got %q",
+			services.StrategyRandom, services.StrategyLeastLoaded, c.Assignment.Strategy))
+	}
+
+	check(c.Idempotency.TTL > 0, "idempotency.ttl (IDEMPOTENCY_TTL) must be positive")
+	check(c.Idempotency.PurgeInterval > 0, "idempotency.purge_interval (IDEMPOTENCY_PURGE_INTERVAL) must be positive")
+
+	check(c.Auth.BootstrapToken == "" || len(c.Auth.BootstrapToken) >= minBootstrapTokenLength,
+		"auth.bootstrap_token (AUTH_BOOTSTRAP_TOKEN) must be at least %d characters", minBootstrapTokenLength)
+	check(!strings.Contains(strings.ToLower(c.Auth.BootstrapToken), "change-me"),
+		"auth.bootstrap_token (AUTH_BOOTSTRAP_TOKEN) is a placeholder, generate a random one (openssl rand -hex 32)")
+	switch c.Auth.Mode {
+	case auth.ModeToken:
+		// Без bootstrap-токена не выпустить ни одного токена: все ручки отвечали бы 401
+		check(!c.Auth.Enabled || c.Auth.BootstrapToken != "",
+			"auth.bootstrap_token (AUTH_BOOTSTRAP_TOKEN) is required when auth is enabled in token mode")
+	case auth.ModeJWT:
+		check((c.Auth.JWT.JWKSURL == "") != (c.Auth.JWT.JWKSFile == ""),
+			"exactly one of auth.jwt.jwks_url (AUTH_JWKS_URL) and auth.jwt.jwks_file (AUTH_JWKS_FILE) is required in jwt mode")
+		check(c.Auth.JWT.JWKSRefresh > 0, "auth.jwt.jwks_refresh (AUTH_JWKS_REFRESH) must be positive")
+		check(c.Auth.JWT.UserClaim != "", "auth.jwt.user_claim (AUTH_JWT_USER_CLAIM) is required")
+		check(c.Auth.JWT.RolesClaim != "", "auth.jwt.roles_claim (AUTH_JWT_ROLES_CLAIM) is required")
+	default:
+		errs = append(errs, fmt.Errorf("auth.mode (AUTH_MODE) must be %s or %s, got %q", auth.ModeToken, auth.ModeJWT, c.Auth.Mode))
+	}
+
+	check(c.RateLimit.ReadRate >= 0, "rate_limit.read_rate (RATE_LIMIT_READ_RATE) must not be negative")
+	check(c.RateLimit.ReadRate == 0 || c.RateLimit.ReadBurst > 0,
+		"rate_limit.read_burst (RATE_LIMIT_READ_BURST) must be positive when read_rate is set")
+	check(c.RateLimit.WriteRate >= 0, "rate_limit.write_rate (RATE_LIMIT_WRITE_RATE) must not be negative")
+	check(c.RateLimit.WriteRate == 0 || c.RateLimit.WriteBurst > 0,
+		"rate_limit.write_burst (RATE_LIMIT_WRITE_BURST) must be positive when write_rate is set")
+
+	check(c.Webhooks.PollInterval > 0, "webhooks.poll_interval (WEBHOOKS_POLL_INTERVAL) must be positive")
+	check(c.Webhooks.Timeout > 0, "webhooks.timeout (WEBHOOKS_TIMEOUT) must be positive")
+	check(c.Webhooks.MaxAttempts > 0, "webhooks.max_attempts (WEBHOOKS_MAX_ATTEMPTS) must be positive")
+	check(c.Webhooks.InitialBackoff > 0, "webhooks.initial_backoff (WEBHOOKS_INITIAL_BACKOFF) must be positive")
+	check(c.Webhooks.MaxBackoff >= c.Webhooks.InitialBackoff,
+		"webhooks.max_backoff (WEBHOOKS_MAX_BACKOFF) must not be less than webhooks.initial_backoff")
+
+	check(c.Outbox.PollInterval > 0, "outbox.poll_interval (OUTBOX_POLL_INTERVAL) must be positive")
+	check(c.Outbox.BatchSize > 0, "outbox.batch_size (OUTBOX_BATCH_SIZE) must be positive")
+	check(c.Outbox.LeaseTTL > c.Outbox.PollInterval,
+		"outbox.lease_ttl (OUTBOX_LEASE_TTL) must be greater than outbox.poll_interval")
+	check(c.Outbox.Retention > 0, "outbox.retention (OUTBOX_RETENTION) must be positive")
+	check(c.Outbox.MaxAttempts > 0, "outbox.max_attempts (OUTBOX_MAX_ATTEMPTS) must be positive")
+
+	check(c.EventStream.PollInterval > 0, "event_stream.poll_interval (EVENT_STREAM_POLL_INTERVAL) must be positive")
+	check(c.EventStream.HeartbeatInterval > 0,
+		"event_stream.heartbeat_interval (EVENT_STREAM_HEARTBEAT_INTERVAL) must be positive")
+
+	sync := c.Integrations.ReviewerSync
+	check(sync.PollInterval > 0, "integrations.reviewer_sync.poll_interval (REVIEWER_SYNC_POLL_INTERVAL) must be positive")
+	check(sync.Timeout > 0, "integrations.reviewer_sync.timeout (REVIEWER_SYNC_TIMEOUT) must be positive")
+	check(sync.MaxAttempts > 0, "integrations.reviewer_sync.max_attempts (REVIEWER_SYNC_MAX_ATTEMPTS) must be positive")
+	check(sync.InitialBackoff > 0, "integrations.reviewer_sync.initial_backoff (REVIEWER_SYNC_INITIAL_BACKOFF) must be positive")
+	check(sync.MaxBackoff >= sync.InitialBackoff,
+		"integrations.reviewer_sync.max_backoff (REVIEWER_SYNC_MAX_BACKOFF) must not be less than integrations.reviewer_sync.initial_backoff")
+	check(c.Integrations.GitHubToken == "" || c.Integrations.GitHubAPIURL != "",
+		"integrations.github_api_url (GITHUB_API_URL) is required when github_token is set")
+	check(c.Integrations.GitLabToken == "" || c.Integrations.GitLabURL != "",
+		"integrations.gitlab_url (GITLAB_URL) is required when gitlab_token is set")
+
+	return stderrors.Join(errs...)
+}
+
+// Redacted возвращает копию конфигурации со скрытыми секретами
+func (c *Config) Redacted() *Config {
+	copied := *c
+	_ = walk(&copied, func(f field) error {
+		if f.secret != "" && f.value.Kind() == reflect.String {
+			f.value.SetString(redact(f.secret, f.value.String()))
+		}
+		return nil
+	})
+	return &copied
+}
+
+// YAML возвращает действующую конфигурацию со скрытыми секретами
+func (c *Config) YAML() ([]byte, error) {
+	return yaml.Marshal(c.Redacted())
+}
+
+// LogValue выводит конфигурацию в лог плоским списком ключей без секретов
+func (c *Config) LogValue() slog.Value {
+	var attrs []slog.Attr
+	_ = walk(c.Redacted(), func(f field) error {
+		switch v := f.value.Interface().(type) {
+		case time.Duration:
+			attrs = append(attrs, slog.String(f.path, v.String()))
+		case int:
+			attrs = append(attrs, slog.String(f.path, strconv.Itoa(v)))
+		default:
+			attrs = append(attrs, slog.Any(f.path, v))
+		}
+		return nil
+	})
+	return slog.GroupValue(attrs...)
+}
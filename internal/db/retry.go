@@ -0,0 +1,34 @@
+package db
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// retry вызывает fn, пока она не завершится успешно или не кончатся попытки.
+// Задержка между попытками удваивается, но не превышает MaxBackoff.
+func retry(ctx context.Context, cfg RetryConfig, fn func() error) error {
+	backoff := cfg.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= cfg.Attempts {
+			return err
+		}
+		slog.Warn("database is unavailable, retrying",
+			"attempt", attempt, "max_attempts", cfg.Attempts, "backoff", backoff.String(), "error", err.Error())
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+
+		backoff *= 2
+		if cfg.MaxBackoff > 0 && backoff > cfg.MaxBackoff {
+			backoff = cfg.MaxBackoff
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+const sqliteScheme = "sqlite://"
+
+// Включаем внешние ключи и ждём снятия блокировки вместо SQLITE_BUSY
+var sqlitePragmas = []string{
+	"_pragma=foreign_keys(1)",
+	"_pragma=busy_timeout(5000)",
+}
+
+func openSQLite(path string) (*gorm.DB, error) {
+	if path == "" {
+		return nil, fmt.Errorf("empty sqlite path")
+	}
+	dsn := path
+	sep := "?"
+	if strings.Contains(dsn, "?") {
+		sep = "&"
+	}
+	dsn += sep + strings.Join(sqlitePragmas, "&")
+
+	gormDB, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: newGormLogger(),
+		// SQLite хранит время строкой, поэтому пишем всё в UTC,
+		// чтобы сравнения и сортировка по времени были корректными
+		NowFunc: func() time.Time { return time.Now().UTC() },
+	})
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := gormDB.DB()
+	if err != nil {
+		return nil, err
+	}
+	// SQLite допускает только одного писателя
+	sqlDB.SetMaxOpenConns(1)
+	return gormDB, nil
+}
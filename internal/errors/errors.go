@@ -8,10 +8,19 @@ const (
 	CodeTeamExists   ErrCode = "TEAM_EXISTS"
 	CodePRExists     ErrCode = "PR_EXISTS"
 	CodePRMerged     ErrCode = "PR_MERGED"
+	CodePRClosed     ErrCode = "PR_CLOSED"
 	CodeNotAssigned  ErrCode = "NOT_ASSIGNED"
 	CodeNoCandidate  ErrCode = "NO_CANDIDATE"
 	CodeNotFound     ErrCode = "NOT_FOUND"
 	CodeInvalidInput ErrCode = "INVALID_INPUT"
+	CodeConflict     ErrCode = "CONFLICT"
+	CodeTimeout      ErrCode = "TIMEOUT"
+	CodeUnauthorized ErrCode = "UNAUTHORIZED"
+	CodeForbidden    ErrCode = "FORBIDDEN"
+	CodeRateLimited  ErrCode = "RATE_LIMITED"
+	CodeInternal     ErrCode = "INTERNAL_ERROR"
+
+	CodeIdempotencyKeyReused ErrCode = "IDEMPOTENCY_KEY_REUSED"
 )
 
 type AppError struct {
@@ -44,6 +53,13 @@ func NewPRMerged() *AppError {
 	}
 }
 
+func NewPRClosed() *AppError {
+	return &AppError{
+		Code:    CodePRClosed,
+		Message: "PR is closed",
+	}
+}
+
 func NewNotAssigned() *AppError {
 	return &AppError{
 		Code:    CodeNotAssigned,
@@ -72,6 +88,71 @@ func NewInvalidInput(message string) *AppError {
 	}
 }
 
+func NewConflict() *AppError {
+	return &AppError{
+		Code:    CodeConflict,
+		Message: "resource was modified concurrently, reload and retry",
+	}
+}
+
+func NewTimeout() *AppError {
+	return &AppError{
+		Code:    CodeTimeout,
+		Message: "request timed out",
+	}
+}
+
+func NewUnauthorized() *AppError {
+	return &AppError{
+		Code:    CodeUnauthorized,
+		Message: "missing or invalid bearer token",
+	}
+}
+
+// NewInvalidSignature — подпись вебхука провайдера не сошлась
+func NewInvalidSignature() *AppError {
+	return &AppError{
+		Code:    CodeUnauthorized,
+		Message: "invalid webhook signature",
+	}
+}
+
+func NewForbidden() *AppError {
+	return &AppError{
+		Code:    CodeForbidden,
+		Message: "token is not allowed to perform this action",
+	}
+}
+
+func NewRateLimited() *AppError {
+	return &AppError{
+		Code:    CodeRateLimited,
+		Message: "too many requests, retry later",
+	}
+}
+
+// NewInternal скрывает от клиента подробности ошибки инфраструктуры
+func NewInternal() *AppError {
+	return &AppError{
+		Code:    CodeInternal,
+		Message: "internal error",
+	}
+}
+
+func NewIdempotencyKeyReused() *AppError {
+	return &AppError{
+		Code:    CodeIdempotencyKeyReused,
+		Message: "Idempotency-Key was already used with a different request",
+	}
+}
+
+func NewIdempotencyInProgress() *AppError {
+	return &AppError{
+		Code:    CodeConflict,
+		Message: "request with this Idempotency-Key is still in progress",
+	}
+}
+
 func IsTeamExists(err error) bool {
 	return isErrCode(err, CodeTeamExists)
 }
@@ -84,6 +165,10 @@ func IsPRMerged(err error) bool {
 	return isErrCode(err, CodePRMerged)
 }
 
+func IsPRClosed(err error) bool {
+	return isErrCode(err, CodePRClosed)
+}
+
 func IsNotFound(err error) bool {
 	return isErrCode(err, CodeNotFound)
 }
@@ -96,6 +181,30 @@ func IsNoCandidate(err error) bool {
 	return isErrCode(err, CodeNoCandidate)
 }
 
+func IsInvalidInput(err error) bool {
+	return isErrCode(err, CodeInvalidInput)
+}
+
+func IsConflict(err error) bool {
+	return isErrCode(err, CodeConflict)
+}
+
+func IsUnauthorized(err error) bool {
+	return isErrCode(err, CodeUnauthorized)
+}
+
+func IsForbidden(err error) bool {
+	return isErrCode(err, CodeForbidden)
+}
+
+func IsRateLimited(err error) bool {
+	return isErrCode(err, CodeRateLimited)
+}
+
+func IsIdempotencyKeyReused(err error) bool {
+	return isErrCode(err, CodeIdempotencyKeyReused)
+}
+
 func isErrCode(err error, code ErrCode) bool {
 	if appErr, ok := err.(*AppError); ok {
 		return appErr.Code == code
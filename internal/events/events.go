@@ -0,0 +1,106 @@
+// Package events описывает доменные события сервиса, на которые можно подписаться.
+package events
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"slices"
+	"time"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
+)
+
+// Типы событий
+const (
+	TypePRCreated          = "pr.created"
+	TypeReviewerAssigned   = "reviewer.assigned"
+	TypeReviewerReassigned = "reviewer.reassigned"
+	TypePRMerged           = "pr.merged"
+	TypePRClosed           = "pr.closed"
+	TypePRReopened         = "pr.reopened"
+	TypePRDrafted          = "pr.drafted"
+	TypePRReadyForReview   = "pr.ready_for_review"
+	TypeUserDeactivated    = "user.deactivated"
+)
+
+// Types — все типы событий в порядке, в котором их показывает документация
+var Types = []string{
+	TypePRCreated,
+	TypeReviewerAssigned,
+	TypeReviewerReassigned,
+	TypePRMerged,
+	TypePRClosed,
+	TypePRReopened,
+	TypePRDrafted,
+	TypePRReadyForReview,
+	TypeUserDeactivated,
+}
+
+// ValidType сообщает, известен ли тип события
+func ValidType(eventType string) bool {
+	return slices.Contains(Types, eventType)
+}
+
+// Event — событие в команде TeamName. Data зависит от типа: PullRequestData,
+// ReviewerData, ReassignmentData или UserData.
+type Event struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	TeamName string `json:"team_name"`
+	// Key — ID PR или пользователя, которого касается событие. События с одним
+	// ключом публикуются в порядке записи; в тело события ключ не входит.
+	Key        string    `json:"-"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Data       any       `json:"data"`
+}
+
+// New создаёт событие с новым ID
+func New(eventType, teamName, key string, data any) Event {
+	return Event{
+		ID:         newID(),
+		Type:       eventType,
+		TeamName:   teamName,
+		Key:        key,
+		OccurredAt: time.Now().UTC(),
+		Data:       data,
+	}
+}
+
+// PullRequestData — данные событий о статусе PR: pr.created, pr.merged, pr.closed,
+// pr.reopened, pr.drafted и pr.ready_for_review
+type PullRequestData struct {
+	PullRequest *models.PullRequestResponse `json:"pull_request"`
+}
+
+// ReviewerData — данные reviewer.assigned
+type ReviewerData struct {
+	PullRequestID   string `json:"pull_request_id"`
+	PullRequestName string `json:"pull_request_name"`
+	AuthorID        string `json:"author_id"`
+	ReviewerID      string `json:"reviewer_id"`
+}
+
+// ReassignmentData — данные reviewer.reassigned
+type ReassignmentData struct {
+	PullRequestID string `json:"pull_request_id"`
+	OldReviewerID string `json:"old_reviewer_id"`
+	NewReviewerID string `json:"new_reviewer_id"`
+}
+
+// UserData — данные user.deactivated
+type UserData struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+}
+
+// Publisher доставляет события подписчикам
+type Publisher interface {
+	Publish(ctx context.Context, evs ...Event) error
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return "evt_" + hex.EncodeToString(b)
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/auth"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/errors"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// Authenticate проверяет токен из заголовка Authorization: Bearer <token>
+// и кладёт principal в контекст запроса.
+func Authenticate(authenticator services.Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, err := authenticator.Authenticate(c.Request.Context(), bearerToken(c))
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+		c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), principal))
+		logAttrs(c, "token_id", principal.TokenID, "scope", principal.Scope)
+		c.Next()
+	}
+}
+
+// RequireScope пропускает запрос, только если scope токена входит в scopes
+func RequireScope(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal := auth.FromContext(c.Request.Context())
+		if principal == nil {
+			abortWithError(c, errors.NewUnauthorized())
+			return
+		}
+		if !slices.Contains(scopes, principal.Scope) {
+			abortWithError(c, errors.NewForbidden())
+			return
+		}
+		c.Next()
+	}
+}
+
+func bearerToken(c *gin.Context) string {
+	scheme, token, ok := strings.Cut(c.GetHeader("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+type AuthHandler struct {
+	service services.AuthService
+}
+
+func NewAuthHandler(service services.AuthService) *AuthHandler {
+	return &AuthHandler{service: service}
+}
+
+// POST /auth/tokens
+func (h *AuthHandler) IssueToken(c *gin.Context) {
+	var req models.IssueTokenRequest
+	if err := c.BindJSON(&req); err != nil {
+		_ = c.Error(errors.NewInvalidInput("Invalid request body"))
+		return
+	}
+	var ttl time.Duration
+	if req.ExpiresIn != "" {
+		var err error
+		if ttl, err = time.ParseDuration(req.ExpiresIn); err != nil {
+			_ = c.Error(errors.NewInvalidInput("expires_in must be a duration like 720h"))
+			return
+		}
+	}
+	logAttrs(c, "user_id", req.UserID)
+	result, err := h.service.IssueToken(c.Request.Context(), req.Name, req.Scope, req.UserID, ttl)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, result)
+}
+
+// POST /auth/tokens/revoke
+func (h *AuthHandler) RevokeToken(c *gin.Context) {
+	var req models.RevokeTokenRequest
+	if err := c.BindJSON(&req); err != nil {
+		_ = c.Error(errors.NewInvalidInput("Invalid request body"))
+		return
+	}
+	result, err := h.service.RevokeToken(c.Request.Context(), req.TokenID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"token": result,
+	})
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/auth"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/errors"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock AuthService
+type MockAuthService struct {
+	mock.Mock
+}
+
+func (m *MockAuthService) Authenticate(ctx context.Context, token string) (*auth.Principal, error) {
+	args := m.Called(ctx, token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*auth.Principal), args.Error(1)
+}
+
+func (m *MockAuthService) IssueToken(ctx context.Context, name, scope, userID string, ttl time.Duration) (*models.IssueTokenResponse, error) {
+	args := m.Called(ctx, name, scope, userID, ttl)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.IssueTokenResponse), args.Error(1)
+}
+
+func (m *MockAuthService) RevokeToken(ctx context.Context, tokenID string) (*models.TokenResponse, error) {
+	args := m.Called(ctx, tokenID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TokenResponse), args.Error(1)
+}
+
+func (m *MockAuthService) EnsureToken(ctx context.Context, tokenID, name, scope, secret string) error {
+	args := m.Called(ctx, tokenID, name, scope, secret)
+	return args.Error(0)
+}
+
+func newAuthRouter(reviewService *MockReviewService, authService *MockAuthService) *gin.Engine {
+	handler := NewHandler(reviewService)
+	authHandler := NewAuthHandler(authService)
+	router := gin.New()
+	router.Use(ErrorHandler())
+	api := router.Group("", Authenticate(authService))
+	admin := api.Group("", RequireScope(auth.ScopeAdmin))
+	scoped := api.Group("", RequireScope(auth.ScopeAdmin, auth.ScopeUser))
+	admin.POST("/team/add", handler.CreateTeam)
+	admin.POST("/auth/tokens", authHandler.IssueToken)
+	scoped.GET("/users/getReview", handler.GetUserReviews)
+	return router
+}
+
+func TestAuth_MissingToken(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	authService := new(MockAuthService)
+	router := newAuthRouter(new(MockReviewService), authService)
+
+	authService.On("Authenticate", mock.Anything, "").Return(nil, errors.NewUnauthorized())
+
+	// Execute
+	req, _ := http.NewRequest(http.MethodGet, "/users/getReview?user_id=u1", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Header().Get("WWW-Authenticate"), "Bearer")
+	assert.Contains(t, w.Body.String(), "UNAUTHORIZED")
+}
+
+func TestAuth_UserTokenCannotMutate(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	reviewService := new(MockReviewService)
+	authService := new(MockAuthService)
+	router := newAuthRouter(reviewService, authService)
+
+	authService.On("Authenticate", mock.Anything, "user-token").
+		Return(&auth.Principal{TokenID: "tok-1", Scope: auth.ScopeUser, UserID: "u1"}, nil)
+
+	// Execute
+	body, _ := json.Marshal(models.CreateTeamRequest{TeamName: "backend"})
+	req, _ := http.NewRequest(http.MethodPost, "/team/add", bytes.NewBuffer(body))
+	req.Header.Set("Authorization", "Bearer user-token")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "FORBIDDEN")
+	reviewService.AssertNotCalled(t, "CreateTeam")
+}
+
+func TestAuth_GetUserReviewsDefaultsToCaller(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	reviewService := new(MockReviewService)
+	authService := new(MockAuthService)
+	router := newAuthRouter(reviewService, authService)
+
+	// Mock expectations
+	authService.On("Authenticate", mock.Anything, "sso-token").
+		Return(&auth.Principal{TokenID: "jwt", Scope: auth.ScopeUser, UserID: "u1"}, nil)
+	reviewService.On("GetUserReviews", mock.Anything, "u1").
+		Return(&models.UserPRsResponse{UserID: "u1", PullRequests: []models.PullRequestReview{}}, nil)
+
+	// Execute
+	req, _ := http.NewRequest(http.MethodGet, "/users/getReview", nil)
+	req.Header.Set("Authorization", "Bearer sso-token")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"user_id":"u1"`)
+	reviewService.AssertExpectations(t)
+}
+
+func TestAuthHandler_IssueToken(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	authService := new(MockAuthService)
+	router := newAuthRouter(new(MockReviewService), authService)
+
+	expected := &models.IssueTokenResponse{
+		Token:  models.TokenResponse{TokenID: "tok-2", Name: "ci", Scope: auth.ScopeUser},
+		Secret: "prs_secret",
+	}
+	authService.On("Authenticate", mock.Anything, "admin-token").
+		Return(&auth.Principal{TokenID: "bootstrap", Scope: auth.ScopeAdmin}, nil)
+	authService.On("IssueToken", mock.Anything, "ci", auth.ScopeUser, "u1", 720*time.Hour).Return(expected, nil)
+
+	// Execute
+	body, _ := json.Marshal(models.IssueTokenRequest{Name: "ci", Scope: auth.ScopeUser, UserID: "u1", ExpiresIn: "720h"})
+	req, _ := http.NewRequest(http.MethodPost, "/auth/tokens", bytes.NewBuffer(body))
+	req.Header.Set("Authorization", "Bearer admin-token")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var response models.IssueTokenResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, *expected, response)
+	authService.AssertExpectations(t)
+}
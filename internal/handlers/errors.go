@@ -1,38 +1,63 @@
 package handlers
 
 import (
+	"context"
+	stderrors "errors"
 	"net/http"
 
 	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/errors"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/logger"
 	"github.com/gin-gonic/gin"
 )
 
 func ErrorHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Next()
-		if len(c.Errors) > 0 {
-			err := c.Errors.Last().Err
-			switch {
-			case errors.IsTeamExists(err):
-				c.JSON(http.StatusBadRequest, toErrorResponse(err))
-			case errors.IsPRMerged(err) || errors.IsNotAssigned(err) || errors.IsNoCandidate(err) || errors.IsPRExists(err):
-				c.JSON(http.StatusConflict, toErrorResponse(err))
-			case errors.IsNotFound(err):
-				c.JSON(http.StatusNotFound, toErrorResponse(err))
-			default:
-				if appErr, ok := err.(*errors.AppError); ok && appErr.Code == errors.CodeInvalidInput {
-					c.JSON(http.StatusBadRequest, toErrorResponse(err))
-				} else {
-					c.JSON(http.StatusInternalServerError, toErrorResponse(
-						errors.NewInvalidInput("Internal server error"),
-					))
-				}
-			}
-			c.Abort()
+		// Тело ошибки могла уже записать вложенная Idempotency
+		if len(c.Errors) > 0 && c.Writer.Size() <= 0 {
+			abortWithError(c, c.Errors.Last().Err)
 		}
 	}
 }
 
+// abortWithError пишет ответ с ошибкой и прерывает цепочку обработчиков.
+// Используется как ErrorHandler, так и middleware, которые отвечают сами, не доходя до него.
+func abortWithError(c *gin.Context, err error) {
+	switch {
+	case errors.IsTeamExists(err):
+		c.JSON(http.StatusBadRequest, toErrorResponse(err))
+	case errors.IsPRMerged(err) || errors.IsPRClosed(err) || errors.IsNotAssigned(err) || errors.IsNoCandidate(err) || errors.IsPRExists(err) || errors.IsConflict(err):
+		c.JSON(http.StatusConflict, toErrorResponse(err))
+	case errors.IsNotFound(err):
+		c.JSON(http.StatusNotFound, toErrorResponse(err))
+	case errors.IsUnauthorized(err):
+		c.Header("WWW-Authenticate", `Bearer realm="pr-service"`)
+		c.JSON(http.StatusUnauthorized, toErrorResponse(err))
+	case errors.IsForbidden(err):
+		c.JSON(http.StatusForbidden, toErrorResponse(err))
+	case errors.IsRateLimited(err):
+		c.JSON(http.StatusTooManyRequests, toErrorResponse(err))
+	case errors.IsIdempotencyKeyReused(err):
+		c.JSON(http.StatusUnprocessableEntity, toErrorResponse(err))
+	case stderrors.Is(err, context.DeadlineExceeded):
+		c.JSON(http.StatusGatewayTimeout, toErrorResponse(errors.NewTimeout()))
+	case stderrors.Is(err, context.Canceled):
+		// Клиент закрыл соединение, ответ уже никто не прочитает
+		c.Status(499)
+	default:
+		if appErr, ok := err.(*errors.AppError); ok && appErr.Code == errors.CodeInvalidInput {
+			c.JSON(http.StatusBadRequest, toErrorResponse(err))
+		} else {
+			logger.FromContext(c.Request.Context()).Error("internal error",
+				"route", c.FullPath(), "error", err.Error())
+			c.JSON(http.StatusInternalServerError, toErrorResponse(
+				errors.NewInvalidInput("Internal server error"),
+			))
+		}
+	}
+	c.Abort()
+}
+
 func toErrorResponse(err error) gin.H {
 	if appErr, ok := err.(*errors.AppError); ok {
 		return gin.H{
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/errors"
+	"github.com/gin-gonic/gin"
+)
+
+// setETag отдаёт версию PR клиенту, чтобы тот мог прислать её обратно в If-Match
+func setETag(c *gin.Context, version int) {
+	c.Header("ETag", strconv.Quote(strconv.Itoa(version)))
+}
+
+// ifMatchVersion возвращает версию PR из If-Match.
+// Пустой заголовок и "*" означают отсутствие условия и дают 0.
+func ifMatchVersion(c *gin.Context) (int, error) {
+	value := strings.TrimSpace(c.GetHeader("If-Match"))
+	if value == "" || value == "*" {
+		return 0, nil
+	}
+	value = strings.Trim(strings.TrimPrefix(value, "W/"), `"`)
+	version, err := strconv.Atoi(value)
+	if err != nil || version <= 0 {
+		return 0, errors.NewInvalidInput("If-Match must contain an ETag returned by the API")
+	}
+	return version, nil
+}
@@ -0,0 +1,88 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/db"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/handlers"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/repositories"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestETag_ReloadAfterConflict(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	database, err := db.Open("sqlite://" + filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(database.Close)
+	service := services.NewReviewService(repositories.NewRepository(database.DB))
+	ctx := context.Background()
+	_, err = service.CreateTeam(ctx, "backend", []models.TeamMember{
+		{UserId: "u1", Username: "Alice", IsActive: true},
+		{UserId: "u2", Username: "Bob", IsActive: true},
+		{UserId: "u3", Username: "Carol", IsActive: true},
+		{UserId: "u4", Username: "Dave", IsActive: true},
+	})
+	require.NoError(t, err)
+	pr, err := service.CreatePR(ctx, "pr-1", "Add search", "u1")
+	require.NoError(t, err)
+
+	handler := handlers.NewHandler(service)
+	router := gin.New()
+	router.Use(handlers.ErrorHandler())
+	router.GET("/pullRequest/get", handler.GetPR)
+	router.GET("/users/getReview", handler.GetUserReviews)
+	router.POST("/pullRequest/merge", handler.MergePR)
+
+	get := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/pullRequest/get?pull_request_id=pr-1", nil))
+		return w
+	}
+	merge := func(etag string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(models.MergePRRequest{PullRequestID: "pr-1"})
+		req := httptest.NewRequest(http.MethodPost, "/pullRequest/merge", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", etag)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Execute: клиент читает PR, а тем временем ревьюера переназначают
+	first := get()
+	require.Equal(t, http.StatusOK, first.Code)
+	staleETag := first.Header().Get("ETag")
+	_, err = service.ReassignReviewer(ctx, "pr-1", pr.AssignedReviewers[0], 0)
+	require.NoError(t, err)
+
+	conflict := merge(staleETag)
+	reloaded := get()
+	merged := merge(reloaded.Header().Get("ETag"))
+
+	// Assert
+	assert.Equal(t, `"1"`, staleETag)
+	assert.Equal(t, http.StatusConflict, conflict.Code)
+	assert.Contains(t, conflict.Body.String(), "CONFLICT")
+	assert.Equal(t, `"2"`, reloaded.Header().Get("ETag"))
+	assert.Equal(t, http.StatusOK, merged.Code)
+	assert.Equal(t, `"3"`, merged.Header().Get("ETag"))
+
+	// Версия есть и в списке ревью
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/getReview?user_id="+pr.AssignedReviewers[1], nil))
+	var reviews models.UserPRsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &reviews))
+	require.Len(t, reviews.PullRequests, 1)
+	assert.Equal(t, 3, reviews.PullRequests[0].Version)
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/errors"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/logger"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/services"
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	EventStreamPath   = "/events/stream"
+	LastEventIDHeader = "Last-Event-ID"
+
+	// streamBatchSize — сколько записей журнала читается за один запрос к базе
+	streamBatchSize = 100
+)
+
+type StreamConfig struct {
+	// PollInterval — как часто поток проверяет журнал на новые события
+	PollInterval time.Duration
+	// HeartbeatInterval — как часто слать комментарий-пинг, чтобы прокси
+	// не закрывали соединение без событий
+	HeartbeatInterval time.Duration
+}
+
+type EventStreamHandler struct {
+	service   services.EventStreamService
+	cfg       StreamConfig
+	closing   chan struct{}
+	closeOnce sync.Once
+}
+
+func NewEventStreamHandler(service services.EventStreamService, cfg StreamConfig) *EventStreamHandler {
+	return &EventStreamHandler{service: service, cfg: cfg, closing: make(chan struct{})}
+}
+
+// Close завершает открытые потоки. http.Server.Shutdown ждёт окончания
+// запросов, а поток сам не заканчивается, поэтому Close вызывается при остановке.
+func (h *EventStreamHandler) Close() {
+	h.closeOnce.Do(func() { close(h.closing) })
+}
+
+// GET /events/stream
+func (h *EventStreamHandler) Stream(c *gin.Context) {
+	ctx := c.Request.Context()
+	teamName, userID := c.Query("team_name"), c.Query("user_id")
+	logAttrs(c, "team_name", teamName, "user_id", userID)
+	filter, err := h.service.OpenStream(ctx, teamName, userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	// Без Last-Event-ID поток начинается с новых событий, с ним — продолжается
+	// после последнего полученного клиентом события
+	var cursor uint
+	if value := c.GetHeader(LastEventIDHeader); value != "" {
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			_ = c.Error(errors.NewInvalidInput("invalid Last-Event-ID header"))
+			return
+		}
+		cursor = uint(id)
+	} else if cursor, err = h.service.LastEventID(ctx); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	// Иначе nginx копит ответ в буфере и события приходят пачками
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	poll := time.NewTicker(h.cfg.PollInterval)
+	defer poll.Stop()
+	heartbeat := time.NewTicker(h.cfg.HeartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		for {
+			entries, next, err := h.service.Events(ctx, filter, cursor, streamBatchSize)
+			if err != nil {
+				// Ответ уже начат: закрываем поток, клиент переподключится с Last-Event-ID
+				if ctx.Err() == nil {
+					logger.FromContext(ctx).Error("failed to read event log", "error", err.Error())
+				}
+				return
+			}
+			for _, entry := range entries {
+				c.Render(-1, sse.Event{
+					Id:    strconv.FormatUint(uint64(entry.ID), 10),
+					Event: entry.EventType,
+					Data:  entry.Payload,
+				})
+			}
+			if next == cursor {
+				break
+			}
+			cursor = next
+		}
+		c.Writer.Flush()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-h.closing:
+			return
+		case <-heartbeat.C:
+			_, _ = c.Writer.WriteString(": heartbeat\n\n")
+			c.Writer.Flush()
+		case <-poll.C:
+		}
+	}
+}
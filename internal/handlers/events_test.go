@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/errors"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock EventStreamService
+type MockEventStreamService struct {
+	mock.Mock
+}
+
+func (m *MockEventStreamService) OpenStream(ctx context.Context, teamName, userID string) (services.EventFilter, error) {
+	args := m.Called(ctx, teamName, userID)
+	return args.Get(0).(services.EventFilter), args.Error(1)
+}
+
+func (m *MockEventStreamService) Events(ctx context.Context, filter services.EventFilter, afterID uint, limit int) ([]models.EventLogEntry, uint, error) {
+	args := m.Called(ctx, filter, afterID, limit)
+	entries, _ := args.Get(0).([]models.EventLogEntry)
+	return entries, args.Get(1).(uint), args.Error(2)
+}
+
+func (m *MockEventStreamService) LastEventID(ctx context.Context) (uint, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(uint), args.Error(1)
+}
+
+func newStreamRouter(service *MockEventStreamService, cfg StreamConfig) *gin.Engine {
+	handler := NewEventStreamHandler(service, cfg)
+	router := gin.New()
+	router.Use(ErrorHandler())
+	router.GET(EventStreamPath, handler.Stream)
+	return router
+}
+
+func TestEventStreamHandler_ResumesAfterLastEventID(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	service := new(MockEventStreamService)
+	router := newStreamRouter(service, StreamConfig{PollInterval: time.Millisecond, HeartbeatInterval: time.Hour})
+	filter := services.EventFilter{TeamName: "backend"}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Mock expectations
+	service.On("OpenStream", mock.Anything, "backend", "").Return(filter, nil)
+	service.On("Events", mock.Anything, filter, uint(41), streamBatchSize).Return([]models.EventLogEntry{
+		{ID: 42, EventType: "pr.created", Payload: `{"id":"evt_1"}`},
+		{ID: 44, EventType: "pr.merged", Payload: `{"id":"evt_2"}`},
+	}, uint(45), nil).Once()
+	// Новых событий нет — клиент отключается
+	service.On("Events", mock.Anything, filter, uint(45), streamBatchSize).Return(nil, uint(45), nil).
+		Run(func(mock.Arguments) { cancel() })
+
+	// Execute
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, EventStreamPath+"?team_name=backend", nil)
+	req.Header.Set(LastEventIDHeader, "41")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/event-stream")
+	assert.Equal(t, "id:42\nevent:pr.created\ndata:{\"id\":\"evt_1\"}\n\n"+
+		"id:44\nevent:pr.merged\ndata:{\"id\":\"evt_2\"}\n\n", w.Body.String())
+	service.AssertNotCalled(t, "LastEventID", mock.Anything)
+	service.AssertExpectations(t)
+}
+
+func TestEventStreamHandler_Heartbeat(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	service := new(MockEventStreamService)
+	router := newStreamRouter(service, StreamConfig{PollInterval: time.Hour, HeartbeatInterval: 5 * time.Millisecond})
+	filter := services.EventFilter{TeamName: "backend", UserID: "u1"}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// Mock expectations
+	service.On("OpenStream", mock.Anything, "", "u1").Return(filter, nil)
+	service.On("LastEventID", mock.Anything).Return(uint(7), nil)
+	service.On("Events", mock.Anything, filter, uint(7), streamBatchSize).Return(nil, uint(7), nil)
+
+	// Execute
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, EventStreamPath+"?user_id=u1", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), ": heartbeat\n\n")
+	service.AssertExpectations(t)
+}
+
+func TestEventStreamHandler_Errors(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	service := new(MockEventStreamService)
+	router := newStreamRouter(service, StreamConfig{PollInterval: time.Second, HeartbeatInterval: time.Second})
+
+	// Mock expectations
+	service.On("OpenStream", mock.Anything, "", "").
+		Return(services.EventFilter{}, errors.NewInvalidInput("team_name or user_id parameter is required"))
+	service.On("OpenStream", mock.Anything, "frontend", "").Return(services.EventFilter{}, errors.NewForbidden())
+	service.On("OpenStream", mock.Anything, "backend", "").Return(services.EventFilter{TeamName: "backend"}, nil)
+
+	// Execute
+	tests := []struct {
+		query       string
+		lastEventID string
+		code        int
+	}{
+		{"", "", http.StatusBadRequest},
+		{"team_name=frontend", "", http.StatusForbidden},
+		{"team_name=backend", "abc", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodGet, EventStreamPath+"?"+tt.query, nil)
+		if tt.lastEventID != "" {
+			req.Header.Set(LastEventIDHeader, tt.lastEventID)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, tt.code, w.Code, tt.query)
+	}
+	service.AssertExpectations(t)
+}
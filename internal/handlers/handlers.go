@@ -3,6 +3,7 @@ package handlers
 import (
 	"net/http"
 
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/auth"
 	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/errors"
 	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
 	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/services"
@@ -24,7 +25,8 @@ func (h *Handler) CreateTeam(c *gin.Context) {
 		_ = c.Error(errors.NewInvalidInput("Invalid request body"))
 		return
 	}
-	result, err := h.service.CreateTeam(req.TeamName, req.Members)
+	logAttrs(c, "team_name", req.TeamName)
+	result, err := h.service.CreateTeam(c.Request.Context(), req.TeamName, req.Members)
 	if err != nil {
 		_ = c.Error(err)
 		return
@@ -41,7 +43,8 @@ func (h *Handler) GetTeam(c *gin.Context) {
 		_ = c.Error(errors.NewInvalidInput("team_name parameter is required"))
 		return
 	}
-	result, err := h.service.GetTeam(teamName)
+	logAttrs(c, "team_name", teamName)
+	result, err := h.service.GetTeam(c.Request.Context(), teamName)
 	if err != nil {
 		_ = c.Error(err)
 		return
@@ -49,6 +52,24 @@ func (h *Handler) GetTeam(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 }
 
+// POST /team/setRole
+func (h *Handler) SetTeamRole(c *gin.Context) {
+	var req models.SetTeamRoleRequest
+	if err := c.BindJSON(&req); err != nil {
+		_ = c.Error(errors.NewInvalidInput("Invalid request body"))
+		return
+	}
+	logAttrs(c, "team_name", req.TeamName, "user_id", req.UserID)
+	result, err := h.service.SetTeamRole(c.Request.Context(), req.TeamName, req.UserID, req.Role)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"role": result,
+	})
+}
+
 // POST /users/setIsActive
 func (h *Handler) SetUserActive(c *gin.Context) {
 	var req models.SetActiveRequest
@@ -56,7 +77,8 @@ func (h *Handler) SetUserActive(c *gin.Context) {
 		_ = c.Error(errors.NewInvalidInput("Invalid request body"))
 		return
 	}
-	result, err := h.service.SetUserActive(req.UserID, req.IsActive)
+	logAttrs(c, "user_id", req.UserID)
+	result, err := h.service.SetUserActive(c.Request.Context(), req.UserID, req.IsActive)
 	if err != nil {
 		_ = c.Error(err)
 		return
@@ -73,16 +95,58 @@ func (h *Handler) CreatePR(c *gin.Context) {
 		_ = c.Error(errors.NewInvalidInput("Invalid request body"))
 		return
 	}
-	result, err := h.service.CreatePR(req.PullRequestID, req.PullRequestName, req.AuthorID)
+	logAttrs(c, "pull_request_id", req.PullRequestID, "author_id", req.AuthorID)
+	result, err := h.service.CreatePR(c.Request.Context(), req.PullRequestID, req.PullRequestName, req.AuthorID)
 	if err != nil {
 		_ = c.Error(err)
 		return
 	}
+	setETag(c, result.Version)
 	c.JSON(http.StatusCreated, gin.H{
 		"pr": result,
 	})
 }
 
+// POST /pullRequest/batchCreate
+func (h *Handler) BatchCreatePRs(c *gin.Context) {
+	var req models.BatchCreatePRRequest
+	if err := c.BindJSON(&req); err != nil {
+		_ = c.Error(errors.NewInvalidInput("Invalid request body"))
+		return
+	}
+	logAttrs(c, "batch_size", len(req.Items), "batch_mode", req.Mode)
+	result, err := h.service.BatchCreatePRs(c.Request.Context(), req.Items, req.Mode)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	status := http.StatusCreated
+	if result.Failed > 0 {
+		status = http.StatusMultiStatus
+	}
+	c.JSON(status, result)
+}
+
+// GET /pullRequest/get?pull_request_id=<pr id>
+// Версия PR приходит в ETag: с ней клиент повторяет изменение после 409
+func (h *Handler) GetPR(c *gin.Context) {
+	prID := c.Query("pull_request_id")
+	if prID == "" {
+		_ = c.Error(errors.NewInvalidInput("pull_request_id parameter is required"))
+		return
+	}
+	logAttrs(c, "pull_request_id", prID)
+	result, err := h.service.GetPR(c.Request.Context(), prID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	setETag(c, result.Version)
+	c.JSON(http.StatusOK, gin.H{
+		"pr": result,
+	})
+}
+
 // POST /pullRequest/merge
 func (h *Handler) MergePR(c *gin.Context) {
 	var req models.MergePRRequest
@@ -90,11 +154,18 @@ func (h *Handler) MergePR(c *gin.Context) {
 		_ = c.Error(errors.NewInvalidInput("Invalid request body"))
 		return
 	}
-	result, err := h.service.MergePR(req.PullRequestID)
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	logAttrs(c, "pull_request_id", req.PullRequestID)
+	result, err := h.service.MergePR(c.Request.Context(), req.PullRequestID, version)
 	if err != nil {
 		_ = c.Error(err)
 		return
 	}
+	setETag(c, result.Version)
 	c.JSON(http.StatusOK, gin.H{
 		"pr": result,
 	})
@@ -107,22 +178,81 @@ func (h *Handler) ReassignReviewer(c *gin.Context) {
 		_ = c.Error(errors.NewInvalidInput("Invalid request body"))
 		return
 	}
-	result, err := h.service.ReassignReviewer(req.PullRequestID, req.OldUserID)
+	version, err := ifMatchVersion(c)
 	if err != nil {
 		_ = c.Error(err)
 		return
 	}
+	logAttrs(c, "pull_request_id", req.PullRequestID, "user_id", req.OldUserID)
+	result, err := h.service.ReassignReviewer(c.Request.Context(), req.PullRequestID, req.OldUserID, version)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	setETag(c, result.PR.Version)
+	c.JSON(http.StatusOK, result)
+}
+
+// GET /stats/team?team_name=<team name>
+func (h *Handler) GetTeamStats(c *gin.Context) {
+	teamName := c.Query("team_name")
+	if teamName == "" {
+		_ = c.Error(errors.NewInvalidInput("team_name parameter is required"))
+		return
+	}
+	logAttrs(c, "team_name", teamName)
+	result, err := h.service.GetTeamStats(c.Request.Context(), teamName)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// GET /stats/assignments?from=<time>&to=<time>&team_name=<team name>&format=json|csv
+func (h *Handler) GetAssignmentStats(c *gin.Context) {
+	from, err := parseTimeQuery(c, "from", false)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	to, err := parseTimeQuery(c, "to", true)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		_ = c.Error(errors.NewInvalidInput("format must be json or csv"))
+		return
+	}
+	result, err := h.service.GetAssignmentStats(c.Request.Context(), from, to, c.Query("team_name"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	if format == "csv" {
+		writeAssignmentStatsCSV(c, result)
+		return
+	}
 	c.JSON(http.StatusOK, result)
 }
 
 // GET /users/getReview?user_id=<user id>
+// Без user_id возвращает ревью пользователя, от имени которого пришёл запрос
 func (h *Handler) GetUserReviews(c *gin.Context) {
 	userID := c.Query("user_id")
+	if userID == "" {
+		if principal := auth.FromContext(c.Request.Context()); principal != nil {
+			userID = principal.UserID
+		}
+	}
 	if userID == "" {
 		_ = c.Error(errors.NewInvalidInput("user_id parameter is required"))
 		return
 	}
-	result, err := h.service.GetUserReviews(userID)
+	logAttrs(c, "user_id", userID)
+	result, err := h.service.GetUserReviews(c.Request.Context(), userID)
 	if err != nil {
 		_ = c.Error(err)
 		return
@@ -2,10 +2,12 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/errors"
 	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
@@ -19,56 +21,128 @@ type MockReviewService struct {
 	mock.Mock
 }
 
-func (m *MockReviewService) CreateTeam(teamName string, members []models.TeamMember) (*models.TeamResponse, error) {
-	args := m.Called(teamName, members)
+func (m *MockReviewService) CreateTeam(ctx context.Context, teamName string, members []models.TeamMember) (*models.TeamResponse, error) {
+	args := m.Called(ctx, teamName, members)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.TeamResponse), args.Error(1)
 }
 
-func (m *MockReviewService) GetTeam(teamName string) (*models.TeamResponse, error) {
-	args := m.Called(teamName)
+func (m *MockReviewService) GetTeam(ctx context.Context, teamName string) (*models.TeamResponse, error) {
+	args := m.Called(ctx, teamName)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.TeamResponse), args.Error(1)
 }
 
-func (m *MockReviewService) SetUserActive(userID string, isActive bool) (*models.UserResponse, error) {
-	args := m.Called(userID, isActive)
+func (m *MockReviewService) SetTeamRole(ctx context.Context, teamName, userID, role string) (*models.TeamRoleResponse, error) {
+	args := m.Called(ctx, teamName, userID, role)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TeamRoleResponse), args.Error(1)
+}
+
+func (m *MockReviewService) SetUserActive(ctx context.Context, userID string, isActive bool) (*models.UserResponse, error) {
+	args := m.Called(ctx, userID, isActive)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.UserResponse), args.Error(1)
 }
 
-func (m *MockReviewService) CreatePR(prID, title, authorID string) (*models.PullRequestResponse, error) {
-	args := m.Called(prID, title, authorID)
+func (m *MockReviewService) CreatePR(ctx context.Context, prID, title, authorID string) (*models.PullRequestShort, error) {
+	args := m.Called(ctx, prID, title, authorID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PullRequestShort), args.Error(1)
+}
+
+func (m *MockReviewService) BatchCreatePRs(ctx context.Context, items []models.CreatePRRequest, mode string) (*models.BatchCreateResponse, error) {
+	args := m.Called(ctx, items, mode)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.BatchCreateResponse), args.Error(1)
+}
+
+func (m *MockReviewService) MergePR(ctx context.Context, prID string, expectedVersion int) (*models.PullRequestResponse, error) {
+	args := m.Called(ctx, prID, expectedVersion)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PullRequestResponse), args.Error(1)
+}
+
+func (m *MockReviewService) ClosePR(ctx context.Context, prID string) (*models.PullRequestResponse, error) {
+	args := m.Called(ctx, prID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PullRequestResponse), args.Error(1)
+}
+
+func (m *MockReviewService) ReopenPR(ctx context.Context, prID string) (*models.PullRequestResponse, error) {
+	args := m.Called(ctx, prID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PullRequestResponse), args.Error(1)
+}
+
+func (m *MockReviewService) MarkDraft(ctx context.Context, prID string) (*models.PullRequestResponse, error) {
+	args := m.Called(ctx, prID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PullRequestResponse), args.Error(1)
+}
+
+func (m *MockReviewService) GetPR(ctx context.Context, prID string) (*models.PullRequestResponse, error) {
+	args := m.Called(ctx, prID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.PullRequestResponse), args.Error(1)
 }
 
-func (m *MockReviewService) MergePR(prID string) (*models.PullRequestResponse, error) {
-	args := m.Called(prID)
+func (m *MockReviewService) MarkReady(ctx context.Context, prID string) (*models.PullRequestResponse, error) {
+	args := m.Called(ctx, prID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.PullRequestResponse), args.Error(1)
 }
 
-func (m *MockReviewService) ReassignReviewer(prID, oldReviewerID string) (*models.ReassignResponse, error) {
-	args := m.Called(prID, oldReviewerID)
+func (m *MockReviewService) ReassignReviewer(ctx context.Context, prID, oldReviewerID string, expectedVersion int) (*models.ReassignResponse, error) {
+	args := m.Called(ctx, prID, oldReviewerID, expectedVersion)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.ReassignResponse), args.Error(1)
 }
 
-func (m *MockReviewService) GetUserReviews(userID string) (*models.UserPRsResponse, error) {
-	args := m.Called(userID)
+func (m *MockReviewService) GetTeamStats(ctx context.Context, teamName string) (*models.TeamStatsResponse, error) {
+	args := m.Called(ctx, teamName)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TeamStatsResponse), args.Error(1)
+}
+
+func (m *MockReviewService) GetAssignmentStats(ctx context.Context, from, to *time.Time, teamName string) (*models.AssignmentStatsResponse, error) {
+	args := m.Called(ctx, from, to, teamName)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AssignmentStatsResponse), args.Error(1)
+}
+
+func (m *MockReviewService) GetUserReviews(ctx context.Context, userID string) (*models.UserPRsResponse, error) {
+	args := m.Called(ctx, userID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -94,7 +168,7 @@ func TestHandler_CreateTeam_Success(t *testing.T) {
 	}
 
 	// Mock expectations
-	mockService.On("CreateTeam", "backend", requestBody.Members).Return(expectedResponse, nil)
+	mockService.On("CreateTeam", mock.Anything, "backend", requestBody.Members).Return(expectedResponse, nil)
 
 	// Create request
 	body, _ := json.Marshal(requestBody)
@@ -164,7 +238,7 @@ func TestHandler_CreateTeam_ServiceError(t *testing.T) {
 	}
 
 	// Mock expectations
-	mockService.On("CreateTeam", "backend", requestBody.Members).Return(
+	mockService.On("CreateTeam", mock.Anything, "backend", requestBody.Members).Return(
 		nil, errors.NewTeamExists("backend"),
 	)
 
@@ -209,7 +283,7 @@ func TestHandler_GetTeam_Success(t *testing.T) {
 	}
 
 	// Mock expectations
-	mockService.On("GetTeam", "backend").Return(expectedResponse, nil)
+	mockService.On("GetTeam", mock.Anything, "backend").Return(expectedResponse, nil)
 
 	// Create request
 	req, _ := http.NewRequest("GET", "/team/get?team_name=backend", nil)
@@ -283,7 +357,7 @@ func TestHandler_SetUserActive_Success(t *testing.T) {
 	}
 
 	// Mock expectations
-	mockService.On("SetUserActive", "u1", false).Return(expectedResponse, nil)
+	mockService.On("SetUserActive", mock.Anything, "u1", false).Return(expectedResponse, nil)
 
 	// Create request
 	body, _ := json.Marshal(requestBody)
@@ -308,3 +382,152 @@ func TestHandler_SetUserActive_Success(t *testing.T) {
 	assert.Contains(t, response, "user")
 	mockService.AssertExpectations(t)
 }
+
+func TestHandler_SetTeamRole_Forbidden(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockReviewService)
+	handler := NewHandler(mockService)
+
+	requestBody := models.SetTeamRoleRequest{
+		TeamName: "backend",
+		UserID:   "u1",
+		Role:     "lead",
+	}
+
+	// Mock expectations
+	mockService.On("SetTeamRole", mock.Anything, "backend", "u1", "lead").Return(nil, errors.NewForbidden())
+
+	// Create request
+	body, _ := json.Marshal(requestBody)
+	req, _ := http.NewRequest("POST", "/team/setRole", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	// Create response recorder
+	w := httptest.NewRecorder()
+	router := gin.Default()
+	router.Use(ErrorHandler())
+	router.POST("/team/setRole", handler.SetTeamRole)
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "FORBIDDEN")
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_MergePR_ETag(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockReviewService)
+	handler := NewHandler(mockService)
+
+	expectedResponse := &models.PullRequestResponse{
+		PullRequestId: "pr-1",
+		Status:        "MERGED",
+		Version:       4,
+	}
+
+	// Mock expectations
+	mockService.On("MergePR", mock.Anything, "pr-1", 3).Return(expectedResponse, nil)
+
+	// Create request
+	body, _ := json.Marshal(models.MergePRRequest{PullRequestID: "pr-1"})
+	req, _ := http.NewRequest("POST", "/pullRequest/merge", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"3"`)
+
+	// Create response recorder
+	w := httptest.NewRecorder()
+	router := gin.Default()
+	router.Use(ErrorHandler())
+	router.POST("/pullRequest/merge", handler.MergePR)
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"4"`, w.Header().Get("ETag"))
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_ReassignReviewer_Conflict(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockReviewService)
+	handler := NewHandler(mockService)
+
+	// Mock expectations
+	mockService.On("ReassignReviewer", mock.Anything, "pr-1", "u2", 1).Return(nil, errors.NewConflict())
+
+	// Create request
+	body, _ := json.Marshal(models.ReassignRequest{PullRequestID: "pr-1", OldUserID: "u2"})
+	req, _ := http.NewRequest("POST", "/pullRequest/reassign", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `W/"1"`)
+
+	// Create response recorder
+	w := httptest.NewRecorder()
+	router := gin.Default()
+	router.Use(ErrorHandler())
+	router.POST("/pullRequest/reassign", handler.ReassignReviewer)
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	errorObj := response["error"].(map[string]interface{})
+	assert.Equal(t, "CONFLICT", errorObj["code"])
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_GetAssignmentStats_CSV(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockReviewService)
+	handler := NewHandler(mockService)
+
+	from := time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC)
+	expectedResponse := &models.AssignmentStatsResponse{
+		ReviewsPerUser:     []models.UserReviewCount{{UserID: "u2", AssignedReviews: 3}},
+		PRsByReviewerCount: []models.ReviewerCountBucket{{Reviewers: 0, PRs: 1}, {Reviewers: 1, PRs: 0}, {Reviewers: 2, PRs: 2}},
+		NoCandidateCount:   1,
+		MergesPerDay:       []models.DailyMerges{{Day: "2025-10-02", Merged: 2}},
+	}
+
+	// Mock expectations: дата в to включается целиком
+	mockService.On("GetAssignmentStats", mock.Anything, &from, &to, "backend").Return(expectedResponse, nil)
+
+	// Create request
+	req, _ := http.NewRequest("GET", "/stats/assignments?from=2025-10-01&to=2025-10-31&team_name=backend&format=csv", nil)
+
+	// Create response recorder
+	w := httptest.NewRecorder()
+	router := gin.Default()
+	router.Use(ErrorHandler())
+	router.GET("/stats/assignments", handler.GetAssignmentStats)
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "metric,key,value\n"+
+		"reviews_assigned,u2,3\n"+
+		"prs_by_reviewer_count,0,1\n"+
+		"prs_by_reviewer_count,1,0\n"+
+		"prs_by_reviewer_count,2,2\n"+
+		"no_candidate,,1\n"+
+		"merged_per_day,2025-10-02,2\n", w.Body.String())
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/auth"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/errors"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/logger"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/repositories"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	idempotencyKeyHeader      = "Idempotency-Key"
+	idempotentReplayedHeader  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+	idempotencyReleaseTimeout = 5 * time.Second
+)
+
+// Заголовки ответа, которые повторяются вместе с телом
+var replayedHeaders = []string{"Content-Type", "ETag"}
+
+// Idempotency обрабатывает заголовок Idempotency-Key у POST-запросов.
+// Первый запрос с ключом выполняется как обычно, а его ответ сохраняется на ttl;
+// повтор с тем же ключом и телом получает сохранённый ответ без повторного выполнения,
+// а повтор с другим телом отклоняется. Ответы 5xx не сохраняются, чтобы клиент мог повторить запрос.
+//
+// Ошибки из c.Errors middleware отрисовывает сам, до сохранения ответа, поэтому
+// ErrorHandler может стоять как раньше, так и позже него.
+func Idempotency(store repositories.IdempotencyRepository, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(idempotencyKeyHeader)
+		if c.Request.Method != http.MethodPost || key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			abortWithError(c, errors.NewInvalidInput("Idempotency-Key is too long"))
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			abortWithError(c, errors.NewInvalidInput("Invalid request body"))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := c.Request.Context()
+		// Ключ принадлежит вызывающему: чужой principal с тем же ключом и телом
+		// не должен получить сохранённый ответ в обход проверки прав
+		storeKey := scopedKey(auth.FromContext(ctx), key)
+		hash := requestHash(c.Request.Method, c.Request.URL.Path, body)
+		record, reserved, err := store.Reserve(ctx, storeKey, hash, time.Now().Add(ttl))
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+		if !reserved {
+			switch {
+			case record.RequestHash != hash:
+				abortWithError(c, errors.NewIdempotencyKeyReused())
+			case record.StatusCode == 0:
+				abortWithError(c, errors.NewIdempotencyInProgress())
+			default:
+				replayResponse(c, record.StatusCode, record.Headers, record.Body)
+			}
+			return
+		}
+
+		recorder := &bodyRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+		// Внутри ErrorHandler ошибка обработчика ещё не записана в ответ
+		if len(c.Errors) > 0 && c.Writer.Size() <= 0 {
+			abortWithError(c, c.Errors.Last().Err)
+		}
+
+		status := c.Writer.Status()
+		if status >= http.StatusInternalServerError || ctx.Err() != nil {
+			// Запрос мог оборваться по таймауту, поэтому освобождаем ключ в отдельном контексте
+			releaseCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), idempotencyReleaseTimeout)
+			defer cancel()
+			if err := store.Release(releaseCtx, storeKey); err != nil {
+				logger.FromContext(ctx).Error("failed to release idempotency key",
+					"idempotency_key", key, "error", err.Error())
+			}
+			return
+		}
+		if err := store.Complete(ctx, storeKey, status, encodeHeaders(c.Writer.Header()), recorder.body.Bytes()); err != nil {
+			logger.FromContext(ctx).Error("failed to store idempotent response",
+				"idempotency_key", key, "error", err.Error())
+		}
+	}
+}
+
+// scopedKey добавляет к ключу отпечаток principal. Без аутентификации ключ
+// остаётся общим, как и сами данные.
+func scopedKey(p *auth.Principal, key string) string {
+	if p == nil {
+		return key
+	}
+	sum := sha256.Sum256([]byte(p.Scope + "\n" + p.TokenID + "\n" + p.UserID))
+	return hex.EncodeToString(sum[:8]) + ":" + key
+}
+
+func requestHash(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + " " + path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func encodeHeaders(header http.Header) string {
+	saved := make(map[string]string, len(replayedHeaders))
+	for _, name := range replayedHeaders {
+		if value := header.Get(name); value != "" {
+			saved[name] = value
+		}
+	}
+	data, _ := json.Marshal(saved)
+	return string(data)
+}
+
+func replayResponse(c *gin.Context, status int, headers string, body []byte) {
+	var saved map[string]string
+	_ = json.Unmarshal([]byte(headers), &saved)
+	for name, value := range saved {
+		c.Header(name, value)
+	}
+	c.Header(idempotentReplayedHeader, "true")
+	c.Status(status)
+	_, _ = c.Writer.Write(body)
+	c.Abort()
+}
+
+// bodyRecorder копирует тело ответа, чтобы его можно было сохранить
+type bodyRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bodyRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *bodyRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/auth"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/errors"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// In-memory хранилище ключей идемпотентности
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]*models.IdempotencyKey
+}
+
+func newMemoryIdempotencyStore() *memoryIdempotencyStore {
+	return &memoryIdempotencyStore{records: make(map[string]*models.IdempotencyKey)}
+}
+
+func (s *memoryIdempotencyStore) Reserve(ctx context.Context, key, requestHash string, expiresAt time.Time) (*models.IdempotencyKey, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if record, ok := s.records[key]; ok && record.ExpiresAt.After(time.Now()) {
+		copied := *record
+		return &copied, false, nil
+	}
+	record := &models.IdempotencyKey{Key: key, RequestHash: requestHash, ExpiresAt: expiresAt}
+	s.records[key] = record
+	copied := *record
+	return &copied, true, nil
+}
+
+func (s *memoryIdempotencyStore) Complete(ctx context.Context, key string, statusCode int, headers string, body []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record := s.records[key]
+	record.StatusCode = statusCode
+	record.Headers = headers
+	record.Body = body
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+	return nil
+}
+
+func (s *memoryIdempotencyStore) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	return 0, nil
+}
+
+// newIdempotentRouter собирает цепочку как cmd/api: ErrorHandler глобально,
+// Idempotency — в группе изменений
+func newIdempotentRouter(handler *Handler) *gin.Engine {
+	return newIdempotentRouterWithStore(handler, newMemoryIdempotencyStore())
+}
+
+func newIdempotentRouterWithStore(handler *Handler, store *memoryIdempotencyStore) *gin.Engine {
+	router := gin.New()
+	router.Use(ErrorHandler())
+	write := router.Group("")
+	write.Use(Idempotency(store, time.Hour))
+	write.POST("/pullRequest/create", handler.CreatePR)
+	return router
+}
+
+func postWithKey(router *gin.Engine, key string, body interface{}) *httptest.ResponseRecorder {
+	data, _ := json.Marshal(body)
+	req, _ := http.NewRequest("POST", "/pullRequest/create", bytes.NewBuffer(data))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", key)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotency_ReplaysResponse(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockReviewService)
+	router := newIdempotentRouter(NewHandler(mockService))
+
+	requestBody := models.CreatePRRequest{PullRequestID: "pr-1", PullRequestName: "Add search", AuthorID: "u1"}
+	expectedResponse := &models.PullRequestShort{PullRequestId: "pr-1", Status: "OPEN", Version: 1}
+
+	// Сервис должен вызваться ровно один раз
+	mockService.On("CreatePR", mock.Anything, "pr-1", "Add search", "u1").Return(expectedResponse, nil).Once()
+
+	// Execute
+	first := postWithKey(router, "key-1", requestBody)
+	second := postWithKey(router, "key-1", requestBody)
+
+	// Assert
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Equal(t, http.StatusCreated, second.Code)
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, `"1"`, second.Header().Get("ETag"))
+	assert.Equal(t, "true", second.Header().Get("Idempotent-Replayed"))
+	mockService.AssertExpectations(t)
+}
+
+func TestIdempotency_ReplaysError(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockReviewService)
+	router := newIdempotentRouter(NewHandler(mockService))
+
+	requestBody := models.CreatePRRequest{PullRequestID: "pr-1", PullRequestName: "Add search", AuthorID: "u1"}
+	mockService.On("CreatePR", mock.Anything, "pr-1", "Add search", "u1").Return(nil, errors.NewPRExists("pr-1")).Once()
+
+	// Execute
+	first := postWithKey(router, "key-1", requestBody)
+	second := postWithKey(router, "key-1", requestBody)
+
+	// Assert
+	assert.Equal(t, http.StatusConflict, first.Code)
+	assert.Equal(t, http.StatusConflict, second.Code)
+	assert.Contains(t, second.Body.String(), "PR_EXISTS")
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, "true", second.Header().Get("Idempotent-Replayed"))
+	mockService.AssertExpectations(t)
+}
+
+func TestIdempotency_ReleasesKeyOnInternalError(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockReviewService)
+	store := newMemoryIdempotencyStore()
+	router := newIdempotentRouterWithStore(NewHandler(mockService), store)
+
+	requestBody := models.CreatePRRequest{PullRequestID: "pr-1", PullRequestName: "Add search", AuthorID: "u1"}
+
+	// Mock expectations: после 500 повтор выполняется заново
+	mockService.On("CreatePR", mock.Anything, "pr-1", "Add search", "u1").Return(nil, stderrors.New("db is down")).Once()
+	mockService.On("CreatePR", mock.Anything, "pr-1", "Add search", "u1").
+		Return(&models.PullRequestShort{PullRequestId: "pr-1", Status: "OPEN", Version: 1}, nil).Once()
+
+	// Execute
+	first := postWithKey(router, "key-1", requestBody)
+	second := postWithKey(router, "key-1", requestBody)
+
+	// Assert
+	assert.Equal(t, http.StatusInternalServerError, first.Code)
+	assert.Equal(t, 1, strings.Count(first.Body.String(), `"error"`))
+	assert.Equal(t, http.StatusCreated, second.Code)
+	assert.Empty(t, second.Header().Get("Idempotent-Replayed"))
+	mockService.AssertExpectations(t)
+}
+
+func TestIdempotency_RejectsDifferentBody(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockReviewService)
+	router := newIdempotentRouter(NewHandler(mockService))
+
+	expectedResponse := &models.PullRequestShort{PullRequestId: "pr-1", Status: "OPEN", Version: 1}
+	mockService.On("CreatePR", mock.Anything, "pr-1", "Add search", "u1").Return(expectedResponse, nil).Once()
+
+	// Execute
+	postWithKey(router, "key-1", models.CreatePRRequest{PullRequestID: "pr-1", PullRequestName: "Add search", AuthorID: "u1"})
+	w := postWithKey(router, "key-1", models.CreatePRRequest{PullRequestID: "pr-2", PullRequestName: "Other", AuthorID: "u1"})
+
+	// Assert
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	errorObj := response["error"].(map[string]interface{})
+	assert.Equal(t, "IDEMPOTENCY_KEY_REUSED", errorObj["code"])
+	mockService.AssertExpectations(t)
+}
+
+func TestIdempotency_KeyIsScopedByPrincipal(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockReviewService)
+	handler := NewHandler(mockService)
+	router := gin.New()
+	// Вместо Authenticate: principal берётся из заголовка теста
+	router.Use(func(c *gin.Context) {
+		p := &auth.Principal{TokenID: c.GetHeader("X-Test-Token"), Scope: auth.ScopeUser, UserID: c.GetHeader("X-Test-Token")}
+		c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), p))
+	})
+	router.Use(ErrorHandler())
+	router.Use(Idempotency(newMemoryIdempotencyStore(), time.Hour))
+	router.POST("/pullRequest/create", handler.CreatePR)
+
+	requestBody := models.CreatePRRequest{PullRequestID: "pr-1", PullRequestName: "Add search", AuthorID: "u1"}
+	data, _ := json.Marshal(requestBody)
+	post := func(token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/pullRequest/create", bytes.NewBuffer(data))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Idempotency-Key", "key-1")
+		req.Header.Set("X-Test-Token", token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Mock expectations: второй principal выполняет запрос сам, а не получает чужой ответ
+	mockService.On("CreatePR", mock.Anything, "pr-1", "Add search", "u1").
+		Return(&models.PullRequestShort{PullRequestId: "pr-1", Status: "OPEN", Version: 1}, nil).Once()
+	mockService.On("CreatePR", mock.Anything, "pr-1", "Add search", "u1").
+		Return(nil, errors.NewForbidden()).Once()
+
+	// Execute
+	first := post("u1")
+	other := post("u2")
+	replay := post("u1")
+
+	// Assert
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Equal(t, http.StatusForbidden, other.Code)
+	assert.Empty(t, other.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, http.StatusCreated, replay.Code)
+	assert.Equal(t, "true", replay.Header().Get("Idempotent-Replayed"))
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/codehost"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/errors"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookPath — адрес вебхука провайдера, например /integrations/github/webhook.
+// Он вне группы с аутентификацией API: запрос подтверждает codehost.Webhook.
+func WebhookPath(provider string) string {
+	return "/integrations/" + provider + "/webhook"
+}
+
+// maxWebhookBody — предел тела вебхука провайдера (GitHub присылает до 25 МБ,
+// но события о PR много меньше)
+const maxWebhookBody = 5 << 20
+
+type IntegrationHandler struct {
+	service services.CodeHostService
+}
+
+func NewIntegrationHandler(service services.CodeHostService) *IntegrationHandler {
+	return &IntegrationHandler{service: service}
+}
+
+// Webhook возвращает обработчик POST /integrations/<провайдер>/webhook
+func (h *IntegrationHandler) Webhook(hook codehost.Webhook) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxWebhookBody))
+		if err != nil {
+			_ = c.Error(errors.NewInvalidInput("Invalid request body"))
+			return
+		}
+		if !hook.Verify(c.Request.Header, body) {
+			_ = c.Error(errors.NewInvalidSignature())
+			return
+		}
+		logAttrs(c, "provider", hook.Provider())
+		ev, err := hook.Parse(c.Request.Header, body)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+		if ev == nil {
+			// ping и события, на которые сервис не реагирует
+			c.JSON(http.StatusOK, models.IngestResult{Result: models.IngestIgnored, Reason: "event is not handled"})
+			return
+		}
+		logAttrs(c, "pull_request_id", ev.PullRequestID(), "action", ev.Action)
+		result, err := h.service.Ingest(c.Request.Context(), *ev)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+// POST /integrations/accounts/set
+func (h *IntegrationHandler) SetAccount(c *gin.Context) {
+	var req models.SetCodeHostAccountRequest
+	if err := c.BindJSON(&req); err != nil {
+		_ = c.Error(errors.NewInvalidInput("Invalid request body"))
+		return
+	}
+	logAttrs(c, "provider", req.Provider, "user_id", req.UserID)
+	result, err := h.service.SetAccount(c.Request.Context(), req.Provider, req.Login, req.UserID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"account": result,
+	})
+}
+
+// GET /integrations/accounts/list
+func (h *IntegrationHandler) ListAccounts(c *gin.Context) {
+	provider := c.Query("provider")
+	result, err := h.service.ListAccounts(c.Request.Context(), provider)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// POST /integrations/accounts/delete
+func (h *IntegrationHandler) DeleteAccount(c *gin.Context) {
+	var req models.DeleteCodeHostAccountRequest
+	if err := c.BindJSON(&req); err != nil {
+		_ = c.Error(errors.NewInvalidInput("Invalid request body"))
+		return
+	}
+	logAttrs(c, "provider", req.Provider)
+	if err := h.service.DeleteAccount(c.Request.Context(), req.Provider, req.Login); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"provider": req.Provider,
+		"login":    req.Login,
+	})
+}
+
+// GET /integrations/syncs/list
+func (h *IntegrationHandler) ListSyncs(c *gin.Context) {
+	status := c.Query("status")
+	var limit int
+	if value := c.Query("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil || limit <= 0 {
+			_ = c.Error(errors.NewInvalidInput("limit must be a positive integer"))
+			return
+		}
+	}
+	result, err := h.service.ListSyncs(c.Request.Context(), status, limit)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// POST /integrations/syncs/retry
+func (h *IntegrationHandler) RetrySync(c *gin.Context) {
+	var req models.RetryReviewerSyncRequest
+	if err := c.BindJSON(&req); err != nil {
+		_ = c.Error(errors.NewInvalidInput("Invalid request body"))
+		return
+	}
+	logAttrs(c, "sync_id", req.SyncID)
+	result, err := h.service.RetrySync(c.Request.Context(), req.SyncID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	logAttrs(c, "pull_request_id", result.PullRequestID)
+	c.JSON(http.StatusOK, gin.H{
+		"sync": result,
+	})
+}
@@ -0,0 +1,272 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/codehost"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/codehost/github"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/codehost/gitlab"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// Mock CodeHostService
+type MockCodeHostService struct {
+	mock.Mock
+}
+
+func (m *MockCodeHostService) Ingest(ctx context.Context, ev codehost.Event) (*models.IngestResult, error) {
+	args := m.Called(ctx, ev)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.IngestResult), args.Error(1)
+}
+
+func (m *MockCodeHostService) SetAccount(ctx context.Context, provider, login, userID string) (*models.CodeHostAccountResponse, error) {
+	args := m.Called(ctx, provider, login, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CodeHostAccountResponse), args.Error(1)
+}
+
+func (m *MockCodeHostService) ListAccounts(ctx context.Context, provider string) (*models.CodeHostAccountListResponse, error) {
+	args := m.Called(ctx, provider)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CodeHostAccountListResponse), args.Error(1)
+}
+
+func (m *MockCodeHostService) DeleteAccount(ctx context.Context, provider, login string) error {
+	args := m.Called(ctx, provider, login)
+	return args.Error(0)
+}
+
+func (m *MockCodeHostService) ListSyncs(ctx context.Context, status string, limit int) (*models.ReviewerSyncListResponse, error) {
+	args := m.Called(ctx, status, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ReviewerSyncListResponse), args.Error(1)
+}
+
+func (m *MockCodeHostService) RetrySync(ctx context.Context, syncID string) (*models.ReviewerSyncResponse, error) {
+	args := m.Called(ctx, syncID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ReviewerSyncResponse), args.Error(1)
+}
+
+const (
+	testGitHubSecret = "github-secret"
+	testGitLabToken  = "gitlab-token"
+)
+
+func newIntegrationRouter(service *MockCodeHostService) *gin.Engine {
+	handler := NewIntegrationHandler(service)
+	router := gin.New()
+	router.Use(ErrorHandler())
+	router.POST(WebhookPath(codehost.ProviderGitHub), handler.Webhook(github.Webhook{Secret: testGitHubSecret}))
+	router.POST(WebhookPath(codehost.ProviderGitLab), handler.Webhook(gitlab.Webhook{Secret: testGitLabToken}))
+	router.POST("/integrations/accounts/set", handler.SetAccount)
+	router.GET("/integrations/syncs/list", handler.ListSyncs)
+	router.POST("/integrations/syncs/retry", handler.RetrySync)
+	return router
+}
+
+func githubRequest(t *testing.T, event, fixture, secret string) *http.Request {
+	t.Helper()
+	body, err := os.ReadFile("../codehost/github/testdata/" + fixture)
+	require.NoError(t, err)
+	req, _ := http.NewRequest(http.MethodPost, WebhookPath(codehost.ProviderGitHub), bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(github.HeaderEvent, event)
+	req.Header.Set(github.HeaderDelivery, "delivery-1")
+	req.Header.Set(github.HeaderSignature, github.Sign(secret, body))
+	return req
+}
+
+func TestIntegrationHandler_GitHubWebhook(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	service := new(MockCodeHostService)
+	router := newIntegrationRouter(service)
+	expected := &models.IngestResult{Result: models.IngestMerged, PullRequestID: "github:acme/payments#42"}
+
+	// Mock expectations
+	service.On("Ingest", mock.Anything, mock.MatchedBy(func(ev codehost.Event) bool {
+		return ev.Action == codehost.ActionMerged && ev.PullRequestID() == expected.PullRequestID
+	})).Return(expected, nil)
+
+	// Execute
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, githubRequest(t, github.EventPullRequest, "pull_request_merged.json", testGitHubSecret))
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response models.IngestResult
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, *expected, response)
+	service.AssertExpectations(t)
+}
+
+func TestIntegrationHandler_GitHubWebhook_Rejected(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	service := new(MockCodeHostService)
+	router := newIntegrationRouter(service)
+
+	// Execute
+	tests := []struct {
+		name    string
+		event   string
+		fixture string
+		secret  string
+		code    int
+	}{
+		{"wrong secret", github.EventPullRequest, "pull_request_opened.json", "other", http.StatusUnauthorized},
+		{"ping", github.EventPing, "ping.json", testGitHubSecret, http.StatusOK},
+		{"ignored action", github.EventPullRequest, "pull_request_synchronize.json", testGitHubSecret, http.StatusOK},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, githubRequest(t, tt.event, tt.fixture, tt.secret))
+
+		// Assert
+		assert.Equal(t, tt.code, w.Code, tt.name)
+	}
+	service.AssertNotCalled(t, "Ingest", mock.Anything, mock.Anything)
+}
+
+func TestIntegrationHandler_GitLabWebhook(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	service := new(MockCodeHostService)
+	router := newIntegrationRouter(service)
+	body, err := os.ReadFile("../codehost/gitlab/testdata/merge_request_open_draft.json")
+	require.NoError(t, err)
+	expected := &models.IngestResult{Result: models.IngestCreated, PullRequestID: "gitlab:acme/payments#7"}
+
+	// Mock expectations
+	service.On("Ingest", mock.Anything, mock.MatchedBy(func(ev codehost.Event) bool {
+		return ev.Action == codehost.ActionOpened && ev.Draft && ev.PullRequestID() == expected.PullRequestID
+	})).Return(expected, nil).Once()
+
+	// Execute
+	tests := []struct {
+		token string
+		code  int
+	}{
+		{testGitLabToken, http.StatusOK},
+		{"wrong", http.StatusUnauthorized},
+		{"", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodPost, WebhookPath(codehost.ProviderGitLab), bytes.NewBuffer(body))
+		req.Header.Set(gitlab.HeaderEvent, gitlab.EventMergeRequest)
+		if tt.token != "" {
+			req.Header.Set(gitlab.HeaderToken, tt.token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, tt.code, w.Code, tt.token)
+	}
+	service.AssertExpectations(t)
+}
+
+func TestIntegrationHandler_SetAccount(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	service := new(MockCodeHostService)
+	router := newIntegrationRouter(service)
+	expected := &models.CodeHostAccountResponse{Provider: "github", Login: "octocat", UserID: "u1"}
+
+	// Mock expectations
+	service.On("SetAccount", mock.Anything, "github", "octocat", "u1").Return(expected, nil)
+
+	// Execute
+	body, _ := json.Marshal(models.SetCodeHostAccountRequest{Provider: "github", Login: "octocat", UserID: "u1"})
+	req, _ := http.NewRequest(http.MethodPost, "/integrations/accounts/set", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Account models.CodeHostAccountResponse `json:"account"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, *expected, response.Account)
+	service.AssertExpectations(t)
+}
+
+func TestIntegrationHandler_ListSyncs(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	service := new(MockCodeHostService)
+	router := newIntegrationRouter(service)
+	expected := &models.ReviewerSyncListResponse{Syncs: []models.ReviewerSyncResponse{
+		{SyncID: "rsync_1", PullRequestID: "github:acme/api#12", Status: "failed", Attempts: 8, LastError: "github: unexpected status 422"},
+	}}
+
+	// Mock expectations
+	service.On("ListSyncs", mock.Anything, "failed", 10).Return(expected, nil)
+
+	// Execute
+	req, _ := http.NewRequest(http.MethodGet, "/integrations/syncs/list?status=failed&limit=10", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	badReq, _ := http.NewRequest(http.MethodGet, "/integrations/syncs/list?limit=abc", nil)
+	badW := httptest.NewRecorder()
+	router.ServeHTTP(badW, badReq)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response models.ReviewerSyncListResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, expected.Syncs[0].SyncID, response.Syncs[0].SyncID)
+	assert.Equal(t, http.StatusBadRequest, badW.Code)
+	service.AssertExpectations(t)
+}
+
+func TestIntegrationHandler_RetrySync(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	service := new(MockCodeHostService)
+	router := newIntegrationRouter(service)
+	expected := &models.ReviewerSyncResponse{SyncID: "rsync_1", PullRequestID: "github:acme/api#12", Status: "pending"}
+
+	// Mock expectations
+	service.On("RetrySync", mock.Anything, "rsync_1").Return(expected, nil)
+
+	// Execute
+	body, _ := json.Marshal(models.RetryReviewerSyncRequest{SyncID: "rsync_1"})
+	req, _ := http.NewRequest(http.MethodPost, "/integrations/syncs/retry", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Sync models.ReviewerSyncResponse `json:"sync"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, *expected, response.Sync)
+	service.AssertExpectations(t)
+}
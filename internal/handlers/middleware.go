@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/logger"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	RequestIDHeader = "X-Request-ID"
+
+	maxRequestIDLength = 128
+)
+
+// RequestTimeout ограничивает время обработки запроса: по истечении timeout
+// контекст запроса отменяется, и вместе с ним прерываются запросы к базе.
+// Маршруты из skip (долгие потоки вроде /events/stream) не ограничиваются.
+func RequestTimeout(timeout time.Duration, skip ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if slices.Contains(skip, c.FullPath()) {
+			c.Next()
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// RequestID берёт X-Request-ID из запроса или генерирует новый, возвращает его
+// в ответе и кладёт в контекст логгер с полем request_id.
+func RequestID(base *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		c.Header(RequestIDHeader, id)
+		ctx := logger.WithContext(c.Request.Context(), base.With("request_id", id))
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// AccessLog пишет по одной записи на запрос: маршрут, статус, время обработки
+// и поля, добавленные обработчиками (id пользователя, PR и т.п.).
+func AccessLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		args := []any{
+			"method", c.Request.Method,
+			"route", route,
+			"path", c.Request.URL.Path,
+			"status", status,
+			"latency_ms", float64(time.Since(start).Microseconds()) / 1000,
+			"client_ip", c.ClientIP(),
+		}
+		ctx := c.Request.Context()
+		args = append(args, logger.Attrs(ctx)...)
+		if len(c.Errors) > 0 {
+			args = append(args, "error", c.Errors.Last().Error())
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+		logger.FromContext(ctx).Log(ctx, level, "request", args...)
+	}
+}
+
+// Recovery перехватывает панику в обработчике, логирует её и отвечает 500
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, recovered any) {
+		abortWithError(c, fmt.Errorf("panic: %v", recovered))
+	})
+}
+
+// logAttrs добавляет поля к записи access-лога текущего запроса
+func logAttrs(c *gin.Context, args ...any) {
+	logger.AddAttrs(c.Request.Context(), args...)
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		if r < 0x21 || r > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
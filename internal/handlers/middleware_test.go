@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	stderrors "errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newLoggedRouter(handler *Handler, buf *bytes.Buffer) *gin.Engine {
+	router := gin.New()
+	router.Use(RequestID(logger.New(buf, slog.LevelDebug)))
+	router.Use(AccessLog())
+	router.Use(Recovery())
+	router.Use(ErrorHandler())
+	router.GET("/team/get", handler.GetTeam)
+	router.GET("/panic", func(c *gin.Context) { panic("boom") })
+	return router
+}
+
+// decodeLogLines разбирает JSON-записи лога построчно
+func decodeLogLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var lines []map[string]any
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		var line map[string]any
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestRequestID_Propagated(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockReviewService)
+	var buf bytes.Buffer
+	router := newLoggedRouter(NewHandler(mockService), &buf)
+
+	mockService.On("GetTeam", mock.Anything, "backend").Return(nil, stderrors.New("connection refused"))
+
+	// Execute
+	req, _ := http.NewRequest(http.MethodGet, "/team/get?team_name=backend", nil)
+	req.Header.Set(RequestIDHeader, "req-42")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "req-42", w.Header().Get(RequestIDHeader))
+	assert.NotContains(t, w.Body.String(), "connection refused")
+
+	lines := decodeLogLines(t, &buf)
+	require.Len(t, lines, 2)
+	// Исходная ошибка попадает в лог, но не в ответ
+	assert.Equal(t, "internal error", lines[0]["msg"])
+	assert.Equal(t, "connection refused", lines[0]["error"])
+	assert.Equal(t, "req-42", lines[0]["request_id"])
+
+	access := lines[1]
+	assert.Equal(t, "request", access["msg"])
+	assert.Equal(t, "ERROR", access["level"])
+	assert.Equal(t, "/team/get", access["route"])
+	assert.Equal(t, float64(http.StatusInternalServerError), access["status"])
+	assert.Equal(t, "backend", access["team_name"])
+	assert.Equal(t, "req-42", access["request_id"])
+	assert.Contains(t, access, "latency_ms")
+}
+
+func TestRequestID_Generated(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	var buf bytes.Buffer
+	router := newLoggedRouter(NewHandler(new(MockReviewService)), &buf)
+
+	// Execute
+	req, _ := http.NewRequest(http.MethodGet, "/panic", nil)
+	req.Header.Set(RequestIDHeader, "bad id with spaces")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	id := w.Header().Get(RequestIDHeader)
+	assert.Len(t, id, 32)
+
+	lines := decodeLogLines(t, &buf)
+	require.Len(t, lines, 2)
+	assert.Equal(t, "panic: boom", lines[0]["error"])
+	assert.Equal(t, id, lines[1]["request_id"])
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/auth"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/errors"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/logger"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/metrics"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit ограничивает частоту запросов клиента к группе маршрутов group.
+// Клиент — пользователь или токен из Authenticate, без аутентификации — IP.
+// Если хранилище лимитов недоступно, запрос пропускается: лимит защищает базу
+// и не должен сам ронять API.
+//
+// Middleware должен стоять после Authenticate и раньше Idempotency,
+// чтобы ответ 429 не сохранялся под ключом идемпотентности.
+func RateLimit(store ratelimit.Store, group string, limit ratelimit.Limit) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		decision, err := store.Allow(ctx, group+":"+clientKey(c), limit)
+		if err != nil {
+			logger.FromContext(ctx).Warn("rate limiter unavailable", "group", group, "error", err.Error())
+			c.Next()
+			return
+		}
+		if !decision.Allowed {
+			metrics.RateLimited(group)
+			logAttrs(c, "rate_limit_group", group)
+			retryAfter := int(math.Ceil(decision.RetryAfter.Seconds()))
+			c.Header("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+			abortWithError(c, errors.NewRateLimited())
+			return
+		}
+		c.Next()
+	}
+}
+
+func clientKey(c *gin.Context) string {
+	if principal := auth.FromContext(c.Request.Context()); principal != nil {
+		if principal.UserID != "" {
+			return "user:" + principal.UserID
+		}
+		return "token:" + principal.TokenID
+	}
+	return "ip:" + c.ClientIP()
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/auth"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/ratelimit"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeLimitStore пропускает по allowed запросов на ключ
+type fakeLimitStore struct {
+	allowed int
+	calls   map[string]int
+	err     error
+}
+
+func (s *fakeLimitStore) Allow(_ context.Context, key string, _ ratelimit.Limit) (ratelimit.Decision, error) {
+	if s.err != nil {
+		return ratelimit.Decision{}, s.err
+	}
+	s.calls[key]++
+	if s.calls[key] > s.allowed {
+		return ratelimit.Decision{RetryAfter: 1500 * time.Millisecond}, nil
+	}
+	return ratelimit.Decision{Allowed: true}, nil
+}
+
+func newRateLimitRouter(store ratelimit.Store) *gin.Engine {
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if user := c.GetHeader("X-Test-User"); user != "" {
+			c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(),
+				&auth.Principal{TokenID: "tok-" + user, Scope: auth.ScopeUser, UserID: user}))
+		}
+	})
+	router.Use(RateLimit(store, "write", ratelimit.Limit{Rate: 1, Burst: 1}))
+	router.POST("/pullRequest/create", func(c *gin.Context) {
+		c.Status(http.StatusCreated)
+	})
+	return router
+}
+
+func TestRateLimit_RejectsOverLimit(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	store := &fakeLimitStore{allowed: 1, calls: map[string]int{}}
+	router := newRateLimitRouter(store)
+
+	post := func(user string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPost, "/pullRequest/create", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		if user != "" {
+			req.Header.Set("X-Test-User", user)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Execute & Assert
+	assert.Equal(t, http.StatusCreated, post("ci").Code)
+	w := post("ci")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), "RATE_LIMITED")
+
+	// Другой пользователь с того же IP и запросы без токена считаются отдельно
+	assert.Equal(t, http.StatusCreated, post("alice").Code)
+	assert.Equal(t, http.StatusCreated, post("").Code)
+	assert.Equal(t, map[string]int{"write:user:ci": 2, "write:user:alice": 1, "write:ip:10.0.0.1": 1}, store.calls)
+}
+
+func TestRateLimit_StoreUnavailable(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	router := newRateLimitRouter(&fakeLimitStore{err: stderrors.New("redis: connection refused")})
+
+	// Execute
+	req, _ := http.NewRequest(http.MethodPost, "/pullRequest/create", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusCreated, w.Code)
+}
+
+func TestRateLimit_IgnoresForwardedForFromUntrustedClient(t *testing.T) {
+	// Setup: как в main, без доверенных прокси
+	gin.SetMode(gin.TestMode)
+	store := &fakeLimitStore{allowed: 1, calls: map[string]int{}}
+	router := newRateLimitRouter(store)
+	assert.NoError(t, router.SetTrustedProxies(nil))
+
+	// Execute: клиент меняет X-Forwarded-For в каждом запросе
+	for _, forwarded := range []string{"1.1.1.1", "2.2.2.2", "3.3.3.3"} {
+		req, _ := http.NewRequest(http.MethodPost, "/pullRequest/create", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set("X-Forwarded-For", forwarded)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// Assert
+	assert.Equal(t, map[string]int{"write:ip:10.0.0.1": 3}, store.calls)
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/errors"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+const dateLayout = "2006-01-02"
+
+// parseTimeQuery разбирает параметр времени в формате RFC3339 или YYYY-MM-DD.
+// Дата в конце интервала (endOfRange) включается целиком, поэтому сдвигается на сутки вперёд.
+func parseTimeQuery(c *gin.Context, name string, endOfRange bool) (*time.Time, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse(dateLayout, value)
+	if err != nil {
+		return nil, errors.NewInvalidInput(fmt.Sprintf("%s must be RFC3339 time or YYYY-MM-DD date", name))
+	}
+	if endOfRange {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
+
+// writeAssignmentStatsCSV выводит статистику одной таблицей metric,key,value
+func writeAssignmentStatsCSV(c *gin.Context, stats *models.AssignmentStatsResponse) {
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="assignment_stats.csv"`)
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	_ = w.Write([]string{"metric", "key", "value"})
+	for _, user := range stats.ReviewsPerUser {
+		_ = w.Write([]string{"reviews_assigned", user.UserID, strconv.Itoa(user.AssignedReviews)})
+	}
+	for _, bucket := range stats.PRsByReviewerCount {
+		_ = w.Write([]string{"prs_by_reviewer_count", strconv.Itoa(bucket.Reviewers), strconv.Itoa(bucket.PRs)})
+	}
+	_ = w.Write([]string{"no_candidate", "", strconv.Itoa(stats.NoCandidateCount)})
+	for _, day := range stats.MergesPerDay {
+		_ = w.Write([]string{"merged_per_day", day.Day, strconv.Itoa(day.Merged)})
+	}
+	w.Flush()
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/errors"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookHandler struct {
+	service services.WebhookService
+}
+
+func NewWebhookHandler(service services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{service: service}
+}
+
+// POST /webhooks/add
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var req models.CreateWebhookRequest
+	if err := c.BindJSON(&req); err != nil {
+		_ = c.Error(errors.NewInvalidInput("Invalid request body"))
+		return
+	}
+	logAttrs(c, "team_name", req.TeamName)
+	result, err := h.service.CreateWebhook(c.Request.Context(), req.TeamName, req.URL, req.EventTypes)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, result)
+}
+
+// GET /webhooks/list
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	teamName := c.Query("team_name")
+	if teamName == "" {
+		_ = c.Error(errors.NewInvalidInput("team_name parameter is required"))
+		return
+	}
+	logAttrs(c, "team_name", teamName)
+	result, err := h.service.ListWebhooks(c.Request.Context(), teamName)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// POST /webhooks/delete
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	var req models.DeleteWebhookRequest
+	if err := c.BindJSON(&req); err != nil {
+		_ = c.Error(errors.NewInvalidInput("Invalid request body"))
+		return
+	}
+	logAttrs(c, "webhook_id", req.WebhookID)
+	if err := h.service.DeleteWebhook(c.Request.Context(), req.WebhookID); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"webhook_id": req.WebhookID,
+	})
+}
+
+// GET /webhooks/deliveries
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	webhookID := c.Query("webhook_id")
+	if webhookID == "" {
+		_ = c.Error(errors.NewInvalidInput("webhook_id parameter is required"))
+		return
+	}
+	var limit int
+	if value := c.Query("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil || limit <= 0 {
+			_ = c.Error(errors.NewInvalidInput("limit must be a positive integer"))
+			return
+		}
+	}
+	logAttrs(c, "webhook_id", webhookID)
+	result, err := h.service.ListDeliveries(c.Request.Context(), webhookID, limit)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/errors"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock WebhookService
+type MockWebhookService struct {
+	mock.Mock
+}
+
+func (m *MockWebhookService) CreateWebhook(ctx context.Context, teamName, url string, eventTypes []string) (*models.CreateWebhookResponse, error) {
+	args := m.Called(ctx, teamName, url, eventTypes)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CreateWebhookResponse), args.Error(1)
+}
+
+func (m *MockWebhookService) ListWebhooks(ctx context.Context, teamName string) (*models.WebhookListResponse, error) {
+	args := m.Called(ctx, teamName)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.WebhookListResponse), args.Error(1)
+}
+
+func (m *MockWebhookService) DeleteWebhook(ctx context.Context, webhookID string) error {
+	return m.Called(ctx, webhookID).Error(0)
+}
+
+func (m *MockWebhookService) ListDeliveries(ctx context.Context, webhookID string, limit int) (*models.WebhookDeliveriesResponse, error) {
+	args := m.Called(ctx, webhookID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.WebhookDeliveriesResponse), args.Error(1)
+}
+
+func newWebhookRouter(service *MockWebhookService) *gin.Engine {
+	handler := NewWebhookHandler(service)
+	router := gin.New()
+	router.Use(ErrorHandler())
+	router.POST("/webhooks/add", handler.CreateWebhook)
+	router.GET("/webhooks/deliveries", handler.ListDeliveries)
+	return router
+}
+
+func TestWebhookHandler_CreateWebhook(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	service := new(MockWebhookService)
+	router := newWebhookRouter(service)
+
+	expected := &models.CreateWebhookResponse{
+		Webhook: models.WebhookResponse{
+			WebhookID:  "wh_1",
+			TeamName:   "backend",
+			URL:        "https://example.com/hook",
+			EventTypes: []string{"reviewer.assigned"},
+		},
+		Secret: "whsec_secret",
+	}
+
+	// Mock expectations
+	service.On("CreateWebhook", mock.Anything, "backend", "https://example.com/hook", []string{"reviewer.assigned"}).
+		Return(expected, nil)
+
+	// Execute
+	body, _ := json.Marshal(models.CreateWebhookRequest{
+		TeamName:   "backend",
+		URL:        "https://example.com/hook",
+		EventTypes: []string{"reviewer.assigned"},
+	})
+	req, _ := http.NewRequest(http.MethodPost, "/webhooks/add", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var response models.CreateWebhookResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, *expected, response)
+	service.AssertExpectations(t)
+}
+
+func TestWebhookHandler_ListDeliveries(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	service := new(MockWebhookService)
+	router := newWebhookRouter(service)
+
+	// Mock expectations
+	service.On("ListDeliveries", mock.Anything, "wh_1", 10).
+		Return(&models.WebhookDeliveriesResponse{WebhookID: "wh_1", Deliveries: []models.WebhookDeliveryResponse{}}, nil)
+	service.On("ListDeliveries", mock.Anything, "wh_2", 0).Return(nil, errors.NewNotFound())
+
+	// Execute
+	tests := []struct {
+		query string
+		code  int
+	}{
+		{"webhook_id=wh_1&limit=10", http.StatusOK},
+		{"webhook_id=wh_2", http.StatusNotFound},
+		{"webhook_id=wh_1&limit=abc", http.StatusBadRequest},
+		{"limit=10", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodGet, "/webhooks/deliveries?"+tt.query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, tt.code, w.Code, tt.query)
+	}
+	service.AssertExpectations(t)
+}
@@ -0,0 +1,82 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getReport(t *testing.T, handler http.Handler) (int, Report) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	var report Report
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	return w.Code, report
+}
+
+func TestReadiness(t *testing.T) {
+	// Setup
+	registry := NewRegistry(50 * time.Millisecond)
+	var dbErr error
+	registry.Register("database", func(ctx context.Context) error { return dbErr })
+	registry.Register("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	// Execute
+	code, report := getReport(t, registry.ReadinessHandler())
+
+	// Assert: зависшая проверка обрывается по таймауту
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, StatusFail, report.Status)
+	require.Len(t, report.Checks, 2)
+	assert.Equal(t, Result{Name: "database", Status: StatusOK, LatencyMs: report.Checks[0].LatencyMs}, report.Checks[0])
+	assert.Equal(t, StatusFail, report.Checks[1].Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks[1].Error)
+	assert.GreaterOrEqual(t, report.Checks[1].LatencyMs, 50.0)
+}
+
+func TestReadiness_ShuttingDown(t *testing.T) {
+	// Setup
+	registry := NewRegistry(time.Second)
+	registry.Register("database", func(ctx context.Context) error { return nil })
+
+	code, report := getReport(t, registry.ReadinessHandler())
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, StatusOK, report.Status)
+
+	// Execute
+	registry.SetShuttingDown()
+	code, report = getReport(t, registry.ReadinessHandler())
+
+	// Assert
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, StatusShuttingDown, report.Status)
+	assert.Equal(t, StatusOK, report.Checks[0].Status)
+}
+
+func TestHeartbeat(t *testing.T) {
+	heartbeat := NewHeartbeat(time.Hour)
+	assert.NoError(t, heartbeat.Check(context.Background()))
+
+	// Ошибка без устаревания не делает воркер нездоровым
+	heartbeat.Fail(errors.New("database is locked"))
+	assert.NoError(t, heartbeat.Check(context.Background()))
+
+	heartbeat.last = time.Now().Add(-2 * time.Hour)
+	err := heartbeat.Check(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "database is locked")
+
+	heartbeat.Beat()
+	assert.NoError(t, heartbeat.Check(context.Background()))
+}
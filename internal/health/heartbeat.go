@@ -0,0 +1,50 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Heartbeat отслеживает фоновый воркер: воркер отмечает каждую успешную итерацию,
+// а проверка падает, если успешной итерации не было дольше maxAge.
+// Единичная ошибка не делает сервис not ready, но попадает в текст ошибки.
+type Heartbeat struct {
+	maxAge  time.Duration
+	mu      sync.Mutex
+	last    time.Time
+	lastErr error
+}
+
+func NewHeartbeat(maxAge time.Duration) *Heartbeat {
+	return &Heartbeat{maxAge: maxAge, last: time.Now()}
+}
+
+// Beat отмечает успешную итерацию
+func (h *Heartbeat) Beat() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.last = time.Now()
+	h.lastErr = nil
+}
+
+// Fail запоминает ошибку итерации
+func (h *Heartbeat) Fail(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastErr = err
+}
+
+func (h *Heartbeat) Check(ctx context.Context) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	age := time.Since(h.last)
+	if age <= h.maxAge {
+		return nil
+	}
+	if h.lastErr != nil {
+		return fmt.Errorf("no successful run for %s: %w", age.Round(time.Second), h.lastErr)
+	}
+	return fmt.Errorf("no successful run for %s", age.Round(time.Second))
+}
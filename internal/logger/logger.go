@@ -0,0 +1,66 @@
+package logger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+type ctxKey struct{}
+
+// New создаёт логгер, пишущий JSON в w
+func New(w io.Writer, level slog.Level) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level}))
+}
+
+// ParseLevel разбирает уровень логирования из строки (debug, info, warn, error).
+// Пустая или неизвестная строка даёт info.
+func ParseLevel(s string) slog.Level {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(s))); err != nil {
+		return slog.LevelInfo
+	}
+	return level
+}
+
+// requestLog — логгер запроса и поля, которые обработчики добавляют по ходу работы
+type requestLog struct {
+	logger *slog.Logger
+	mu     sync.Mutex
+	attrs  []any
+}
+
+// WithContext кладёт логгер в контекст запроса
+func WithContext(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, &requestLog{logger: l})
+}
+
+// FromContext возвращает логгер запроса или slog.Default(), если его нет
+func FromContext(ctx context.Context) *slog.Logger {
+	if rl, ok := ctx.Value(ctxKey{}).(*requestLog); ok {
+		return rl.logger
+	}
+	return slog.Default()
+}
+
+// AddAttrs добавляет поля (пары ключ-значение) к итоговой записи о запросе.
+// Без логгера в контексте ничего не делает.
+func AddAttrs(ctx context.Context, args ...any) {
+	if rl, ok := ctx.Value(ctxKey{}).(*requestLog); ok {
+		rl.mu.Lock()
+		rl.attrs = append(rl.attrs, args...)
+		rl.mu.Unlock()
+	}
+}
+
+// Attrs возвращает поля, добавленные через AddAttrs
+func Attrs(ctx context.Context) []any {
+	if rl, ok := ctx.Value(ctxKey{}).(*requestLog); ok {
+		rl.mu.Lock()
+		defer rl.mu.Unlock()
+		return append([]any(nil), rl.attrs...)
+	}
+	return nil
+}
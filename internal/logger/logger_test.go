@@ -0,0 +1,35 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLevel(t *testing.T) {
+	assert.Equal(t, slog.LevelDebug, ParseLevel("debug"))
+	assert.Equal(t, slog.LevelWarn, ParseLevel("WARN"))
+	assert.Equal(t, slog.LevelInfo, ParseLevel(""))
+	assert.Equal(t, slog.LevelInfo, ParseLevel("verbose"))
+}
+
+func TestContextAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, slog.LevelInfo).With("request_id", "r1")
+
+	// Без логгера в контексте используется slog.Default(), поля не копятся
+	AddAttrs(context.Background(), "user_id", "u1")
+	assert.Nil(t, Attrs(context.Background()))
+	assert.Equal(t, slog.Default(), FromContext(context.Background()))
+
+	ctx := WithContext(context.Background(), l)
+	AddAttrs(ctx, "user_id", "u1")
+	AddAttrs(ctx, "pull_request_id", "pr-1")
+	assert.Equal(t, []any{"user_id", "u1", "pull_request_id", "pr-1"}, Attrs(ctx))
+
+	FromContext(ctx).Info("hello")
+	assert.Contains(t, buf.String(), `"request_id":"r1"`)
+}
@@ -0,0 +1,188 @@
+// Package metrics собирает метрики сервиса в формате Prometheus.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "pr_service"
+
+var registry = prometheus.NewRegistry()
+
+var (
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	prsCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "pull_requests_created_total",
+		Help:      "Pull requests created.",
+	})
+	prsMerged = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "pull_requests_merged_total",
+		Help:      "Pull requests moved to MERGED.",
+	})
+	reassignments = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reviewer_reassignments_total",
+		Help:      "Successful reviewer reassignments.",
+	})
+	noCandidate = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "no_candidate_total",
+		Help:      "Reassignments rejected with NO_CANDIDATE.",
+	})
+	rateLimited = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limited_requests_total",
+		Help:      "Requests rejected with RATE_LIMITED by route group.",
+	}, []string{"group"})
+	webhookAttempts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_delivery_attempts_total",
+		Help:      "Webhook delivery attempts by result: delivered, retry or failed.",
+	}, []string{"result"})
+	outboxPublished = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "outbox_events_published_total",
+		Help:      "Domain events published from the outbox to all sinks.",
+	})
+	outboxDead = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "outbox_events_failed_total",
+		Help:      "Domain events given up on after the maximum number of publish attempts.",
+	})
+	outboxFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "outbox_publish_failures_total",
+		Help:      "Failed attempts to publish an outbox event by sink.",
+	}, []string{"sink"})
+	reviewerSyncAttempts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "code_host_reviewer_sync_attempts_total",
+		Help:      "Attempts to push reviewers to the code host by provider and result: synced, retry or failed.",
+	}, []string{"provider", "result"})
+	// Границы зависят от max_reviewers, их выставляет SetMaxReviewers
+	reviewersPerPR = newReviewersPerPR(2)
+)
+
+// newReviewersPerPR создаёт гистограмму с корзиной на каждое число ревьюеров от 0 до max
+func newReviewersPerPR(max int) prometheus.Histogram {
+	return prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "reviewers_per_pull_request",
+		Help:      "Number of reviewers assigned to a newly created pull request.",
+		Buckets:   prometheus.LinearBuckets(0, 1, max+1),
+	})
+}
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequestDuration,
+		prsCreated,
+		prsMerged,
+		reassignments,
+		noCandidate,
+		rateLimited,
+		webhookAttempts,
+		outboxPublished,
+		outboxDead,
+		outboxFailures,
+		reviewerSyncAttempts,
+		reviewersPerPR,
+	)
+}
+
+// RegisterDB добавляет статистику пула соединений sql.DB
+func RegisterDB(db *sql.DB, name string) error {
+	return registry.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// SetMaxReviewers перестраивает гистограмму ревьюеров под assignment.max_reviewers.
+// Вызывается при старте, до обработки запросов.
+func SetMaxReviewers(max int) {
+	registry.Unregister(reviewersPerPR)
+	reviewersPerPR = newReviewersPerPR(max)
+	registry.MustRegister(reviewersPerPR)
+}
+
+// Handler отдаёт метрики в текстовом формате Prometheus
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{Registry: registry})
+}
+
+// Middleware замеряет время обработки каждого запроса. Маршрут берётся из шаблона gin,
+// поэтому новые ручки попадают в метрики без дополнительных действий.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		httpRequestDuration.
+			WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).
+			Observe(time.Since(start).Seconds())
+	}
+}
+
+func PRCreated(reviewers int) {
+	prsCreated.Inc()
+	reviewersPerPR.Observe(float64(reviewers))
+}
+
+func PRMerged() {
+	prsMerged.Inc()
+}
+
+func ReviewerReassigned() {
+	reassignments.Inc()
+}
+
+func NoCandidate() {
+	noCandidate.Inc()
+}
+
+func RateLimited(group string) {
+	rateLimited.WithLabelValues(group).Inc()
+}
+
+func OutboxPublished() {
+	outboxPublished.Inc()
+}
+
+// OutboxDead учитывает событие, которое Relay перестал публиковать после MaxAttempts
+func OutboxDead() {
+	outboxDead.Inc()
+}
+
+// OutboxFailed учитывает неудачную публикацию события из outbox в sink
+func OutboxFailed(sink string) {
+	outboxFailures.WithLabelValues(sink).Inc()
+}
+
+// WebhookAttempt учитывает попытку доставки вебхука
+func WebhookAttempt(result string) {
+	webhookAttempts.WithLabelValues(result).Inc()
+}
+
+// ReviewerSyncAttempt учитывает попытку передать ревьюеров провайдеру
+func ReviewerSyncAttempt(provider, result string) {
+	reviewerSyncAttempts.WithLabelValues(provider, result).Inc()
+}
@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware_RecordsRouteTemplate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware())
+	router.GET("/team/get", func(c *gin.Context) { c.Status(http.StatusNotFound) })
+	router.GET("/metrics", gin.WrapH(Handler()))
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/team/get?team_name=backend", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/no/such/route", nil))
+	PRCreated(2)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	body := w.Body.String()
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, strings.Contains(body, `pr_service_http_request_duration_seconds_count{method="GET",route="/team/get",status="404"} 1`), body)
+	assert.True(t, strings.Contains(body, `route="unmatched"`), body)
+	assert.True(t, strings.Contains(body, `pr_service_pull_requests_created_total 1`), body)
+	assert.True(t, strings.Contains(body, `pr_service_reviewers_per_pull_request_bucket{le="2"} 1`), body)
+}
+
+func TestSetMaxReviewers(t *testing.T) {
+	SetMaxReviewers(4)
+	t.Cleanup(func() { SetMaxReviewers(2) })
+	PRCreated(3)
+
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	body := w.Body.String()
+
+	for _, le := range []string{"0", "1", "2", "3", "4"} {
+		assert.Contains(t, body, `pr_service_reviewers_per_pull_request_bucket{le="`+le+`"}`)
+	}
+	assert.Contains(t, body, `pr_service_reviewers_per_pull_request_bucket{le="3"} 1`)
+	assert.Contains(t, body, `pr_service_reviewers_per_pull_request_bucket{le="2"} 0`)
+}
@@ -1,10 +1,6 @@
 package models
 
-import (
-	"time"
-
-	"github.com/lib/pq"
-)
+import "time"
 
 type Team struct {
 	ID        string    `gorm:"primaryKey;type:varchar(255)"`
@@ -23,11 +19,179 @@ type User struct {
 }
 
 type PullRequest struct {
-	ID        string         `gorm:"primaryKey;type:varchar(255)"`
-	Title     string         `gorm:"not null"`
-	AuthorID  string         `gorm:"not null;type:varchar(255)"`
-	Author    User           `gorm:"foreignKey:AuthorID"`
-	Status    string         `gorm:"default:'OPEN'"`
-	Reviewers pq.StringArray `gorm:"type:text[]"`
-	MergedAt  *time.Time     ``
+	ID        string      `gorm:"primaryKey;type:varchar(255)"`
+	Title     string      `gorm:"not null"`
+	AuthorID  string      `gorm:"not null;type:varchar(255)"`
+	Author    User        `gorm:"foreignKey:AuthorID"`
+	Status    string      `gorm:"default:'OPEN'"`
+	Reviewers StringArray ``
+	CreatedAt time.Time   ``
+	MergedAt  *time.Time  ``
+	Version   int         `gorm:"not null;default:1"`
+}
+
+type Reassignment struct {
+	ID            uint      `gorm:"primaryKey;autoIncrement"`
+	PullRequestID string    `gorm:"not null;type:varchar(255);index"`
+	OldReviewerID string    `gorm:"not null;type:varchar(255);index"`
+	NewReviewerID string    `gorm:"not null;type:varchar(255);index"`
+	CreatedAt     time.Time ``
+}
+
+// NoCandidateEvent фиксирует неудачное переназначение: в команде не нашлось замены
+type NoCandidateEvent struct {
+	ID            uint      `gorm:"primaryKey;autoIncrement"`
+	PullRequestID string    `gorm:"not null;type:varchar(255)"`
+	ReviewerID    string    `gorm:"not null;type:varchar(255);index"`
+	CreatedAt     time.Time `gorm:"index"`
+}
+
+type IdempotencyKey struct {
+	// Key — Idempotency-Key с префиксом-отпечатком principal (до 17 символов)
+	Key         string    `gorm:"primaryKey;type:varchar(272)"`
+	RequestHash string    `gorm:"not null;type:varchar(64)"`
+	StatusCode  int       `gorm:"not null;default:0"`
+	Headers     string    ``
+	Body        []byte    ``
+	CreatedAt   time.Time ``
+	ExpiresAt   time.Time `gorm:"not null;index"`
+}
+
+// APIToken — токен доступа к API. Сам токен не хранится, только его SHA-256
+type APIToken struct {
+	ID        string     `gorm:"primaryKey;type:varchar(64)"`
+	Name      string     `gorm:"not null;type:varchar(255)"`
+	TokenHash string     `gorm:"not null;type:varchar(64);uniqueIndex"`
+	Scope     string     `gorm:"not null;type:varchar(16)"`
+	UserID    *string    `gorm:"type:varchar(255)"`
+	CreatedAt time.Time  ``
+	ExpiresAt *time.Time ``
+	RevokedAt *time.Time ``
+}
+
+// TeamRole — роль пользователя в команде (lead, member, bot).
+// Участник команды без записи здесь считается member.
+type TeamRole struct {
+	TeamID    string    `gorm:"primaryKey;type:varchar(255)"`
+	UserID    string    `gorm:"primaryKey;type:varchar(255);index"`
+	Role      string    `gorm:"not null;type:varchar(16)"`
+	CreatedAt time.Time ``
+	UpdatedAt time.Time ``
+}
+
+// Статусы доставки вебхука
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+// Webhook — подписка команды на события. Secret нужен, чтобы подписывать
+// доставки, поэтому хранится как есть, а не хэшем.
+type Webhook struct {
+	ID         string      `gorm:"primaryKey;type:varchar(64)"`
+	TeamID     string      `gorm:"not null;type:varchar(255);index"`
+	URL        string      `gorm:"not null"`
+	Secret     string      `gorm:"not null;type:varchar(255)"`
+	EventTypes StringArray ``
+	CreatedAt  time.Time   ``
+}
+
+// WebhookDelivery — отправка одного события на один вебхук и её попытки
+type WebhookDelivery struct {
+	ID string `gorm:"primaryKey;type:varchar(64)"`
+	// Одно событие доставляется на вебхук один раз, даже если outbox опубликовал его повторно
+	WebhookID string `gorm:"not null;type:varchar(64);uniqueIndex:idx_webhook_deliveries_event,priority:1"`
+	EventID   string `gorm:"not null;type:varchar(64);uniqueIndex:idx_webhook_deliveries_event,priority:2"`
+	EventType string `gorm:"not null;type:varchar(64)"`
+	// Payload — тело запроса; при повторах отправляется без изменений
+	Payload        string     `gorm:"not null;type:text"`
+	Status         string     `gorm:"not null;type:varchar(16);index:idx_webhook_deliveries_due,priority:1"`
+	Attempts       int        `gorm:"not null;default:0"`
+	NextAttemptAt  time.Time  `gorm:"index:idx_webhook_deliveries_due,priority:2"`
+	LastStatusCode int        ``
+	LastError      string     ``
+	CreatedAt      time.Time  `gorm:"index"`
+	DeliveredAt    *time.Time ``
+}
+
+// OutboxEvent — доменное событие, записанное в той же транзакции, что и изменение.
+// ID растёт монотонно и задаёт порядок публикации.
+type OutboxEvent struct {
+	ID        uint   `gorm:"primaryKey;autoIncrement"`
+	EventID   string `gorm:"not null;type:varchar(64);uniqueIndex"`
+	EventType string `gorm:"not null;type:varchar(64)"`
+	// Key — ID PR или пользователя; события с одним ключом публикуются по порядку
+	Key string `gorm:"not null;type:varchar(255)"`
+	// Payload — событие в JSON, как его получают подписчики
+	Payload     string     `gorm:"not null;type:text"`
+	Attempts    int        `gorm:"not null;default:0"`
+	LastError   string     ``
+	CreatedAt   time.Time  ``
+	PublishedAt *time.Time `gorm:"index"`
+	// FailedAt — когда Relay сдался после MaxAttempts; такое событие больше не
+	// публикуется и не задерживает следующие события своего ключа
+	FailedAt *time.Time `gorm:"index"`
+}
+
+// WorkerLease — аренда фонового воркера, который должен работать в одном
+// экземпляре сервиса. Владелец продлевает аренду, пока жив.
+type WorkerLease struct {
+	Name      string    `gorm:"primaryKey;type:varchar(64)"`
+	Owner     string    `gorm:"not null;type:varchar(255)"`
+	ExpiresAt time.Time `gorm:"not null"`
+}
+
+// EventLogEntry — опубликованное событие в журнале для /events/stream.
+// Журнал пишет только Relay, поэтому ID растёт в порядке публикации
+// и служит ID события в потоке (Last-Event-ID).
+type EventLogEntry struct {
+	ID        uint   `gorm:"primaryKey;autoIncrement"`
+	EventID   string `gorm:"not null;type:varchar(64);uniqueIndex"`
+	EventType string `gorm:"not null;type:varchar(64)"`
+	TeamName  string `gorm:"not null;type:varchar(255);index"`
+	// UserIDs — участники, которых касается событие: автор, ревьюеры, пользователь
+	UserIDs   StringArray ``
+	Payload   string      `gorm:"not null;type:text"`
+	CreatedAt time.Time   `gorm:"index"`
+}
+
+func (EventLogEntry) TableName() string {
+	return "event_log"
+}
+
+// CodeHostAccount связывает логин у провайдера (GitHub, GitLab) с пользователем сервиса
+type CodeHostAccount struct {
+	Provider  string    `gorm:"primaryKey;type:varchar(32)"`
+	Login     string    `gorm:"primaryKey;type:varchar(255)"`
+	UserID    string    `gorm:"not null;type:varchar(255);index"`
+	CreatedAt time.Time ``
+}
+
+// CodeHostPullRequest связывает PR сервиса с PR у провайдера
+type CodeHostPullRequest struct {
+	PullRequestID string    `gorm:"primaryKey;type:varchar(255)"`
+	Provider      string    `gorm:"not null;type:varchar(32);uniqueIndex:idx_code_host_pull_requests_external"`
+	Repository    string    `gorm:"not null;type:varchar(255);uniqueIndex:idx_code_host_pull_requests_external"`
+	Number        int       `gorm:"not null;uniqueIndex:idx_code_host_pull_requests_external"`
+	URL           string    ``
+	CreatedAt     time.Time ``
+}
+
+// ReviewerSync — задача передать провайдеру ревьюеров PR после назначения или
+// переназначения. Статусы те же, что у доставок вебхуков; failed — очередь
+// задач, которые не удалось выполнить за все попытки.
+type ReviewerSync struct {
+	ID string `gorm:"primaryKey;type:varchar(64)"`
+	// Одно событие даёт одну задачу, даже если outbox опубликовал его повторно
+	EventID       string `gorm:"not null;type:varchar(64);uniqueIndex"`
+	PullRequestID string `gorm:"not null;type:varchar(255);index"`
+	// Removed — с кого снять запрос ревью (старый ревьюер при переназначении)
+	Removed       StringArray ``
+	Status        string      `gorm:"not null;type:varchar(16);index:idx_reviewer_syncs_due,priority:1"`
+	Attempts      int         `gorm:"not null;default:0"`
+	NextAttemptAt time.Time   `gorm:"index:idx_reviewer_syncs_due,priority:2"`
+	LastError     string      ``
+	CreatedAt     time.Time   `gorm:"index"`
+	SyncedAt      *time.Time  ``
 }
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type TeamMember struct {
 	IsActive bool   `json:"is_active"`
@@ -27,6 +30,7 @@ type PullRequestResponse struct {
 	PullRequestId     string     `json:"pull_request_id"`
 	PullRequestName   string     `json:"pull_request_name"`
 	Status            string     `json:"status"`
+	Version           int        `json:"-"`
 }
 
 type PullRequestShort struct {
@@ -35,6 +39,7 @@ type PullRequestShort struct {
 	PullRequestId     string   `json:"pull_request_id"`
 	PullRequestName   string   `json:"pull_request_name"`
 	Status            string   `json:"status"`
+	Version           int      `json:"-"`
 }
 
 type ReassignResponse struct {
@@ -47,6 +52,8 @@ type PullRequestReview struct {
 	PullRequestId   string `json:"pull_request_id"`
 	PullRequestName string `json:"pull_request_name"`
 	Status          string `json:"status"`
+	// Version — то же значение, что в ETag, для If-Match
+	Version int `json:"version"`
 }
 
 type UserPRsResponse struct {
@@ -65,6 +72,29 @@ type CreatePRRequest struct {
 	AuthorID        string `json:"author_id"`
 }
 
+type BatchCreatePRRequest struct {
+	Items []CreatePRRequest `json:"items"`
+	Mode  string            `json:"mode"`
+}
+
+type BatchCreateItemError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type BatchCreateItemResult struct {
+	PullRequestId string                `json:"pull_request_id"`
+	PR            *PullRequestShort     `json:"pr,omitempty"`
+	Error         *BatchCreateItemError `json:"error,omitempty"`
+}
+
+type BatchCreateResponse struct {
+	Mode    string                  `json:"mode"`
+	Created int                     `json:"created"`
+	Failed  int                     `json:"failed"`
+	Results []BatchCreateItemResult `json:"results"`
+}
+
 type MergePRRequest struct {
 	PullRequestID string `json:"pull_request_id"`
 }
@@ -78,3 +108,155 @@ type SetActiveRequest struct {
 	UserID   string `json:"user_id"`
 	IsActive bool   `json:"is_active"`
 }
+
+type SetTeamRoleRequest struct {
+	TeamName string `json:"team_name"`
+	UserID   string `json:"user_id"`
+	Role     string `json:"role"`
+}
+
+type TeamRoleResponse struct {
+	TeamName string `json:"team_name"`
+	UserID   string `json:"user_id"`
+	Role     string `json:"role"`
+}
+
+type IssueTokenRequest struct {
+	Name  string `json:"name"`
+	Scope string `json:"scope"`
+	// UserID — владелец токена со scope user; он видит только свои ревью
+	UserID string `json:"user_id"`
+	// ExpiresIn — срок жизни в формате Go duration (720h); пусто — бессрочный
+	ExpiresIn string `json:"expires_in"`
+}
+
+type RevokeTokenRequest struct {
+	TokenID string `json:"token_id"`
+}
+
+type TokenResponse struct {
+	TokenID   string     `json:"token_id"`
+	Name      string     `json:"name"`
+	Scope     string     `json:"scope"`
+	UserID    *string    `json:"user_id,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+type IssueTokenResponse struct {
+	Token TokenResponse `json:"token"`
+	// Secret показывается только один раз, при выпуске
+	Secret string `json:"secret"`
+}
+
+type CreateWebhookRequest struct {
+	TeamName   string   `json:"team_name"`
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+}
+
+type DeleteWebhookRequest struct {
+	WebhookID string `json:"webhook_id"`
+}
+
+type WebhookResponse struct {
+	WebhookID  string    `json:"webhook_id"`
+	TeamName   string    `json:"team_name"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type CreateWebhookResponse struct {
+	Webhook WebhookResponse `json:"webhook"`
+	// Secret — ключ подписи доставок; показывается только при создании
+	Secret string `json:"secret"`
+}
+
+type WebhookListResponse struct {
+	TeamName string            `json:"team_name"`
+	Webhooks []WebhookResponse `json:"webhooks"`
+}
+
+type WebhookDeliveryResponse struct {
+	DeliveryID     string          `json:"delivery_id"`
+	EventID        string          `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	LastStatusCode int             `json:"last_status_code,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+	Payload        json.RawMessage `json:"payload"`
+}
+
+type WebhookDeliveriesResponse struct {
+	WebhookID  string                    `json:"webhook_id"`
+	Deliveries []WebhookDeliveryResponse `json:"deliveries"`
+}
+
+type SetCodeHostAccountRequest struct {
+	Provider string `json:"provider"`
+	Login    string `json:"login"`
+	UserID   string `json:"user_id"`
+}
+
+type DeleteCodeHostAccountRequest struct {
+	Provider string `json:"provider"`
+	Login    string `json:"login"`
+}
+
+type CodeHostAccountResponse struct {
+	Provider string `json:"provider"`
+	Login    string `json:"login"`
+	UserID   string `json:"user_id"`
+}
+
+type CodeHostAccountListResponse struct {
+	Accounts []CodeHostAccountResponse `json:"accounts"`
+}
+
+type RetryReviewerSyncRequest struct {
+	SyncID string `json:"sync_id"`
+}
+
+type ReviewerSyncResponse struct {
+	SyncID        string `json:"sync_id"`
+	PullRequestID string `json:"pull_request_id"`
+	EventID       string `json:"event_id"`
+	// RemovedUserIDs — с кого снимается запрос ревью
+	RemovedUserIDs []string   `json:"removed_user_ids,omitempty"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	LastError      string     `json:"last_error,omitempty"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	SyncedAt       *time.Time `json:"synced_at,omitempty"`
+}
+
+type ReviewerSyncListResponse struct {
+	Syncs []ReviewerSyncResponse `json:"syncs"`
+}
+
+// Результаты обработки события провайдера
+const (
+	IngestCreated  = "created"
+	IngestExists   = "exists"
+	IngestMerged   = "merged"
+	IngestClosed   = "closed"
+	IngestReopened = "reopened"
+	IngestDraft    = "draft"
+	IngestReady    = "ready"
+	IngestIgnored  = "ignored"
+)
+
+// IngestResult — что сервис сделал по событию провайдера
+type IngestResult struct {
+	Result        string `json:"result"`
+	PullRequestID string `json:"pull_request_id,omitempty"`
+	// Reason — почему событие пропущено
+	Reason string `json:"reason,omitempty"`
+}
@@ -0,0 +1,89 @@
+package models
+
+import "time"
+
+// StatsFilter ограничивает выборку статистики. Пустые поля не фильтруют.
+type StatsFilter struct {
+	From   *time.Time
+	To     *time.Time
+	TeamID string
+}
+
+type UserReviewCount struct {
+	UserID          string `json:"user_id"`
+	Username        string `json:"username"`
+	TeamName        string `json:"team_name"`
+	AssignedReviews int    `json:"assigned_reviews"`
+}
+
+type ReviewerCountBucket struct {
+	Reviewers int `json:"reviewers"`
+	PRs       int `json:"pull_requests" gorm:"column:prs"`
+}
+
+type DailyMerges struct {
+	Day    string `json:"date"`
+	Merged int    `json:"merged"`
+}
+
+type AssignmentStatsResponse struct {
+	From               *time.Time            `json:"from"`
+	To                 *time.Time            `json:"to"`
+	TeamName           string                `json:"team_name,omitempty"`
+	ReviewsPerUser     []UserReviewCount     `json:"reviews_per_user"`
+	PRsByReviewerCount []ReviewerCountBucket `json:"prs_by_reviewer_count"`
+	NoCandidateCount   int                   `json:"no_candidate_count"`
+	MergesPerDay       []DailyMerges         `json:"merges_per_day"`
+}
+
+// MemberReviewStats — агрегаты по ревью одного участника команды
+type MemberReviewStats struct {
+	UserID                string
+	Username              string
+	IsActive              bool
+	AssignedReviews       int
+	OpenReviews           int
+	MergedReviews         int
+	AvgTimeToMergeSeconds *float64
+	ReassignedFrom        int
+	ReassignedTo          int
+}
+
+// TeamPRStats — агрегаты по PR, авторы которых состоят в команде
+type TeamPRStats struct {
+	Authored              int
+	Open                  int
+	Merged                int
+	AvgTimeToMergeSeconds *float64
+}
+
+type MemberStatsResponse struct {
+	UserId                string   `json:"user_id"`
+	Username              string   `json:"username"`
+	IsActive              bool     `json:"is_active"`
+	AssignedReviews       int      `json:"assigned_reviews"`
+	OpenReviews           int      `json:"open_reviews"`
+	MergedReviews         int      `json:"merged_reviews"`
+	AvgTimeToMergeSeconds *float64 `json:"avg_time_to_merge_seconds"`
+	ReassignedFrom        int      `json:"reassigned_from"`
+	ReassignedTo          int      `json:"reassigned_to"`
+	LoadShare             float64  `json:"load_share"`
+}
+
+type LoadDistribution struct {
+	OpenReviews int     `json:"open_reviews"`
+	Min         int     `json:"min"`
+	Max         int     `json:"max"`
+	Mean        float64 `json:"mean"`
+}
+
+type TeamStatsResponse struct {
+	TeamName              string                `json:"team_name"`
+	AuthoredPRs           int                   `json:"authored_prs"`
+	OpenPRs               int                   `json:"open_prs"`
+	MergedPRs             int                   `json:"merged_prs"`
+	AvgTimeToMergeSeconds *float64              `json:"avg_time_to_merge_seconds"`
+	Reassignments         int                   `json:"reassignments"`
+	Load                  LoadDistribution      `json:"load"`
+	Members               []MemberStatsResponse `json:"members"`
+}
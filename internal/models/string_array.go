@@ -0,0 +1,64 @@
+package models
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// StringArray хранится как text[] в Postgres и как JSON-массив в SQLite.
+type StringArray []string
+
+func (StringArray) GormDataType() string {
+	return "text[]"
+}
+
+func (StringArray) GormDBDataType(db *gorm.DB, _ *schema.Field) string {
+	if db.Dialector.Name() == "sqlite" {
+		return "text"
+	}
+	return "text[]"
+}
+
+func (a StringArray) GormValue(_ context.Context, db *gorm.DB) clause.Expr {
+	if db.Dialector.Name() == "sqlite" {
+		if a == nil {
+			return clause.Expr{SQL: "NULL"}
+		}
+		data, err := json.Marshal([]string(a))
+		if err != nil {
+			_ = db.AddError(err)
+		}
+		return clause.Expr{SQL: "?", Vars: []interface{}{string(data)}}
+	}
+	return clause.Expr{SQL: "?", Vars: []interface{}{pq.StringArray(a)}}
+}
+
+func (a StringArray) Value() (driver.Value, error) {
+	return pq.StringArray(a).Value()
+}
+
+func (a *StringArray) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*a = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("cannot convert %T to StringArray", src)
+	}
+	if len(data) > 0 && data[0] == '[' {
+		return json.Unmarshal(data, (*[]string)(a))
+	}
+	return (*pq.StringArray)(a).Scan(data)
+}
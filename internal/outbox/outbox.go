@@ -0,0 +1,385 @@
+// Package outbox публикует доменные события, записанные в таблицу outbox_events
+// в одной транзакции с изменением (transactional outbox).
+//
+// Relay читает события в порядке записи и передаёт их всем Sink. Событие
+// помечается опубликованным, только когда его приняли все Sink, поэтому
+// доставка «хотя бы один раз»: после сбоя событие может прийти повторно,
+// получатели различают повторы по ID события. Если событие не удалось
+// опубликовать, следующие события с тем же ключом (PR или пользователь)
+// ждут его, а события других ключей идут дальше. После MaxAttempts попыток
+// событие помечается failed и больше не задерживает свой ключ.
+package outbox
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/events"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/health"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/metrics"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/repositories"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/retryqueue"
+)
+
+// leaseName — аренда, которая выбирает единственный работающий Relay
+const leaseName = "outbox_relay"
+
+// Record превращает событие в строку outbox
+func Record(ev events.Event) (models.OutboxEvent, error) {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return models.OutboxEvent{}, fmt.Errorf("encode event %s: %w", ev.Type, err)
+	}
+	return models.OutboxEvent{
+		EventID:   ev.ID,
+		EventType: ev.Type,
+		Key:       ev.Key,
+		Payload:   string(payload),
+	}, nil
+}
+
+// Append записывает события в outbox репозитория r. Чтобы событие появилось
+// только вместе с изменением, r должен быть транзакцией этого изменения.
+func Append(ctx context.Context, r *repositories.Repository, evs ...events.Event) error {
+	rows := make([]models.OutboxEvent, len(evs))
+	for i, ev := range evs {
+		row, err := Record(ev)
+		if err != nil {
+			return err
+		}
+		rows[i] = row
+	}
+	return r.Outbox.Append(ctx, rows)
+}
+
+// Decode восстанавливает событие из строки outbox. Data остаётся исходным JSON,
+// так что Sink отправляют событие байт в байт таким, каким оно было записано.
+func Decode(row models.OutboxEvent) (events.Event, error) {
+	var decoded struct {
+		events.Event
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(row.Payload), &decoded); err != nil {
+		return events.Event{}, fmt.Errorf("decode outbox event %d: %w", row.ID, err)
+	}
+	ev := decoded.Event
+	ev.Data = decoded.Data
+	ev.Key = row.Key
+	return ev, nil
+}
+
+// Sink — получатель событий: вебхуки, лог, брокер сообщений
+type Sink struct {
+	// Name — имя для логов и метрик
+	Name      string
+	Publisher events.Publisher
+}
+
+// Purger — Publisher, который сам хранит опубликованные события. Relay удаляет
+// из него события старше Retention вместе с опубликованными событиями outbox.
+type Purger interface {
+	Purge(ctx context.Context, before time.Time) (int64, error)
+}
+
+type Config struct {
+	PollInterval time.Duration
+	BatchSize    int
+	// LeaseTTL — на сколько берётся аренда; если экземпляр с Relay упал,
+	// публикацию через LeaseTTL подхватит другой
+	LeaseTTL time.Duration
+	// Retention — сколько хранить опубликованные события
+	Retention time.Duration
+	// MaxAttempts — после стольких неудачных попыток событие помечается failed
+	MaxAttempts int
+}
+
+// Relay переносит события из outbox в Sink. Запущенных Relay может быть несколько
+// (по одному на экземпляр сервиса), но публикует только владелец аренды, чтобы
+// сохранить порядок событий.
+type Relay struct {
+	repo  *repositories.Repository
+	cfg   Config
+	sinks []Sink
+	owner string
+	now   func() time.Time
+}
+
+func NewRelay(repo *repositories.Repository, cfg Config, sinks ...Sink) *Relay {
+	return &Relay{
+		repo:  repo,
+		cfg:   cfg,
+		sinks: sinks,
+		owner: newOwnerID(),
+		now:   time.Now,
+	}
+}
+
+// Run публикует события, пока не отменён ctx
+func (r *Relay) Run(ctx context.Context, heartbeat *health.Heartbeat) {
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+	defer func() {
+		// Отдаём аренду сразу, чтобы другой экземпляр не ждал LeaseTTL
+		releaseCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		if err := r.repo.Leases.Release(releaseCtx, leaseName, r.owner); err != nil {
+			slog.Warn("failed to release outbox lease", "error", err.Error())
+		}
+	}()
+	for {
+		if err := r.Tick(ctx); err != nil {
+			slog.Error("failed to relay outbox events", "error", err.Error())
+			heartbeat.Fail(err)
+		} else {
+			heartbeat.Beat()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick публикует накопившиеся события, если этот Relay владеет арендой,
+// и удаляет старые опубликованные события
+func (r *Relay) Tick(ctx context.Context) error {
+	for {
+		// Аренда продлевается перед каждой пачкой: длинный разбор очереди не
+		// должен пережить её, иначе публиковать начнёт второй экземпляр
+		leader, err := r.repo.Leases.TryAcquire(ctx, leaseName, r.owner, r.now().UTC(), r.cfg.LeaseTTL)
+		if err != nil || !leader {
+			return err
+		}
+		n, err := r.PublishPending(ctx)
+		if err != nil {
+			return err
+		}
+		if n < r.cfg.BatchSize {
+			break
+		}
+	}
+	before := r.now().UTC().Add(-r.cfg.Retention)
+	deleted, err := r.repo.Outbox.DeletePublished(ctx, before)
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		slog.Info("purged published outbox events", "count", deleted)
+	}
+	for _, sink := range r.sinks {
+		purger, ok := sink.Publisher.(Purger)
+		if !ok {
+			continue
+		}
+		deleted, err := purger.Purge(ctx, before)
+		if err != nil {
+			return fmt.Errorf("%s: %w", sink.Name, err)
+		}
+		if deleted > 0 {
+			slog.Info("purged published events", "sink", sink.Name, "count", deleted)
+		}
+	}
+	return nil
+}
+
+// PublishPending публикует одну пачку событий и возвращает, сколько событий
+// было прочитано
+func (r *Relay) PublishPending(ctx context.Context) (int, error) {
+	rows, err := r.repo.Outbox.Pending(ctx, r.cfg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	var published []uint
+	blocked := make(map[string]bool)
+	for _, row := range rows {
+		// Более раннее событие этого ключа не опубликовано — ждём его
+		if blocked[row.Key] {
+			continue
+		}
+		if err := r.publish(ctx, row); err != nil {
+			errText := retryqueue.Truncate(err.Error())
+			if row.Attempts+1 >= r.cfg.MaxAttempts {
+				// Событие, которое не принимают, не должно держать свой ключ вечно
+				slog.Error("outbox event failed after max attempts",
+					"event_id", row.EventID, "event_type", row.EventType, "key", row.Key, "attempts", row.Attempts+1, "error", err.Error())
+				metrics.OutboxDead()
+				if err := r.repo.Outbox.MarkFailed(ctx, row.ID, errText, r.now().UTC()); err != nil {
+					return 0, err
+				}
+				continue
+			}
+			blocked[row.Key] = true
+			slog.Warn("failed to publish outbox event",
+				"event_id", row.EventID, "event_type", row.EventType, "attempt", row.Attempts+1, "error", err.Error())
+			if err := r.repo.Outbox.RecordFailure(ctx, row.ID, errText); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		published = append(published, row.ID)
+	}
+	if err := r.repo.Outbox.MarkPublished(ctx, published, r.now().UTC()); err != nil {
+		return 0, err
+	}
+	// Если всё прочитанное заблокировано, повторять пачку до следующего тика незачем
+	if len(published) == 0 {
+		return 0, nil
+	}
+	return len(rows), nil
+}
+
+func (r *Relay) publish(ctx context.Context, row models.OutboxEvent) error {
+	ev, err := Decode(row)
+	if err != nil {
+		return err
+	}
+	for _, sink := range r.sinks {
+		if err := sink.Publisher.Publish(ctx, ev); err != nil {
+			metrics.OutboxFailed(sink.Name)
+			return fmt.Errorf("%s: %w", sink.Name, err)
+		}
+	}
+	metrics.OutboxPublished()
+	return nil
+}
+
+// LogSink пишет события в лог — для отладки и как пример Sink
+type LogSink struct {
+	Logger *slog.Logger
+}
+
+func (s LogSink) Publish(_ context.Context, evs ...events.Event) error {
+	for _, ev := range evs {
+		s.Logger.Info("domain event",
+			"event_id", ev.ID, "event_type", ev.Type, "team_name", ev.TeamName, "key", ev.Key)
+	}
+	return nil
+}
+
+// Producer отправляет сообщение в брокер (Kafka, NATS, RabbitMQ). Сообщения с
+// одинаковым key должны попадать в одну партицию, чтобы сохранить их порядок.
+type Producer interface {
+	Send(ctx context.Context, topic, key string, value []byte) error
+}
+
+// BrokerSink публикует события в брокер сообщений через Producer.
+// Ключ сообщения — ключ события, значение — событие в JSON.
+type BrokerSink struct {
+	Producer Producer
+	// Topic — имя топика; пусто — топик совпадает с типом события
+	Topic string
+}
+
+func (s BrokerSink) Publish(ctx context.Context, evs ...events.Event) error {
+	for _, ev := range evs {
+		value, err := json.Marshal(ev)
+		if err != nil {
+			return err
+		}
+		topic := s.Topic
+		if topic == "" {
+			topic = ev.Type
+		}
+		if err := s.Producer.Send(ctx, topic, ev.Key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EventLogSink пишет события в журнал, из которого их читает /events/stream.
+// Relay публикует события по одному в порядке outbox, так что порядок записей
+// журнала совпадает с порядком публикации.
+type EventLogSink struct {
+	Repo repositories.EventLogRepository
+}
+
+func (s EventLogSink) Publish(ctx context.Context, evs ...events.Event) error {
+	entries := make([]models.EventLogEntry, len(evs))
+	for i, ev := range evs {
+		entry, err := LogEntry(ev)
+		if err != nil {
+			return err
+		}
+		entries[i] = entry
+	}
+	return s.Repo.Append(ctx, entries)
+}
+
+func (s EventLogSink) Purge(ctx context.Context, before time.Time) (int64, error) {
+	return s.Repo.DeleteBefore(ctx, before)
+}
+
+// LogEntry превращает событие в запись журнала
+func LogEntry(ev events.Event) (models.EventLogEntry, error) {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return models.EventLogEntry{}, fmt.Errorf("encode event %s: %w", ev.Type, err)
+	}
+	users, err := involvedUsers(ev.Data)
+	if err != nil {
+		return models.EventLogEntry{}, fmt.Errorf("decode event %s: %w", ev.ID, err)
+	}
+	return models.EventLogEntry{
+		EventID:   ev.ID,
+		EventType: ev.Type,
+		TeamName:  ev.TeamName,
+		UserIDs:   users,
+		Payload:   string(payload),
+	}, nil
+}
+
+// involvedUsers собирает ID пользователей из данных события любого типа.
+// После Decode данные — исходный JSON, поэтому разбираем их через JSON.
+func involvedUsers(data any) (models.StringArray, error) {
+	raw, ok := data.(json.RawMessage)
+	if !ok {
+		var err error
+		if raw, err = json.Marshal(data); err != nil {
+			return nil, err
+		}
+	}
+	var fields struct {
+		AuthorID      string `json:"author_id"`
+		ReviewerID    string `json:"reviewer_id"`
+		OldReviewerID string `json:"old_reviewer_id"`
+		NewReviewerID string `json:"new_reviewer_id"`
+		UserID        string `json:"user_id"`
+		PullRequest   *struct {
+			AuthorID  string   `json:"author_id"`
+			Reviewers []string `json:"assigned_reviewers"`
+		} `json:"pull_request"`
+	}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	candidates := []string{fields.AuthorID, fields.ReviewerID, fields.OldReviewerID, fields.NewReviewerID, fields.UserID}
+	if fields.PullRequest != nil {
+		candidates = append(candidates, fields.PullRequest.AuthorID)
+		candidates = append(candidates, fields.PullRequest.Reviewers...)
+	}
+	users := models.StringArray{}
+	for _, id := range candidates {
+		if id != "" && !slices.Contains(users, id) {
+			users = append(users, id)
+		}
+	}
+	return users, nil
+}
+
+// newOwnerID отличает экземпляры сервиса друг от друга в таблице аренды
+func newOwnerID() string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(b))
+}
@@ -0,0 +1,286 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/db"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/events"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/repositories"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingSink запоминает события и отклоняет события из fail
+type recordingSink struct {
+	received []events.Event
+	fail     map[string]bool
+}
+
+func (s *recordingSink) Publish(_ context.Context, evs ...events.Event) error {
+	for _, ev := range evs {
+		if s.fail[ev.ID] {
+			return stderrors.New("sink unavailable")
+		}
+		s.received = append(s.received, ev)
+	}
+	return nil
+}
+
+func (s *recordingSink) ids() []string {
+	ids := make([]string, len(s.received))
+	for i, ev := range s.received {
+		ids[i] = ev.ID
+	}
+	return ids
+}
+
+func newTestRepo(t *testing.T) *repositories.Repository {
+	t.Helper()
+	database, err := db.Open("sqlite://" + filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(database.Close)
+	return repositories.NewRepository(database.DB)
+}
+
+func newTestRelay(repo *repositories.Repository, sinks ...Sink) *Relay {
+	return NewRelay(repo, Config{
+		PollInterval: time.Second,
+		BatchSize:    10,
+		LeaseTTL:     time.Minute,
+		Retention:    time.Hour,
+		MaxAttempts:  3,
+	}, sinks...)
+}
+
+func appendEvent(t *testing.T, repo *repositories.Repository, key string) events.Event {
+	t.Helper()
+	ev := events.New(events.TypeReviewerAssigned, "backend", key, events.ReviewerData{PullRequestID: key, ReviewerID: "u1"})
+	require.NoError(t, Append(context.Background(), repo, ev))
+	return ev
+}
+
+func TestRecordDecode(t *testing.T) {
+	ev := events.New(events.TypePRMerged, "backend", "pr-1", events.ReassignmentData{PullRequestID: "pr-1"})
+	row, err := Record(ev)
+	require.NoError(t, err)
+	assert.Equal(t, "pr-1", row.Key)
+	assert.NotContains(t, row.Payload, `"key"`)
+
+	decoded, err := Decode(row)
+	require.NoError(t, err)
+	assert.Equal(t, ev.ID, decoded.ID)
+	assert.Equal(t, ev.Key, decoded.Key)
+	assert.True(t, ev.OccurredAt.Equal(decoded.OccurredAt))
+	// Повторное кодирование даёт то же тело, что и исходное событие
+	payload, err := json.Marshal(decoded)
+	require.NoError(t, err)
+	assert.JSONEq(t, row.Payload, string(payload))
+}
+
+func TestRelay_PublishesInOrder(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+	first, second := &recordingSink{}, &recordingSink{}
+	relay := newTestRelay(repo, Sink{Name: "first", Publisher: first}, Sink{Name: "second", Publisher: second})
+
+	var ids []string
+	for _, key := range []string{"pr-1", "pr-2", "pr-1"} {
+		ids = append(ids, appendEvent(t, repo, key).ID)
+	}
+	require.NoError(t, relay.Tick(ctx))
+	assert.Equal(t, ids, first.ids())
+	assert.Equal(t, ids, second.ids())
+
+	// Опубликованные события не отправляются повторно
+	require.NoError(t, relay.Tick(ctx))
+	assert.Len(t, first.received, 3)
+}
+
+func TestRelay_FailureBlocksKey(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+	sink := &recordingSink{fail: map[string]bool{}}
+	relay := newTestRelay(repo, Sink{Name: "sink", Publisher: sink})
+
+	a1 := appendEvent(t, repo, "pr-1")
+	b1 := appendEvent(t, repo, "pr-2")
+	a2 := appendEvent(t, repo, "pr-1")
+	sink.fail[a1.ID] = true
+
+	// pr-1 ждёт первое событие, pr-2 публикуется
+	require.NoError(t, relay.Tick(ctx))
+	assert.Equal(t, []string{b1.ID}, sink.ids())
+	pending, err := repo.Outbox.Pending(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	assert.Equal(t, 1, pending[0].Attempts)
+	assert.Contains(t, pending[0].LastError, "sink unavailable")
+	assert.Zero(t, pending[1].Attempts)
+
+	delete(sink.fail, a1.ID)
+	require.NoError(t, relay.Tick(ctx))
+	assert.Equal(t, []string{b1.ID, a1.ID, a2.ID}, sink.ids())
+}
+
+func TestRelay_FailsAfterMaxAttempts(t *testing.T) {
+	ctx := context.Background()
+	database, err := db.Open("sqlite://" + filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(database.Close)
+	repo := repositories.NewRepository(database.DB)
+	sink := &recordingSink{fail: map[string]bool{}}
+	relay := newTestRelay(repo, Sink{Name: "sink", Publisher: sink})
+
+	poison := appendEvent(t, repo, "pr-1")
+	next := appendEvent(t, repo, "pr-1")
+	sink.fail[poison.ID] = true
+
+	for range 2 {
+		require.NoError(t, relay.Tick(ctx))
+		assert.Empty(t, sink.received)
+	}
+	// Третья попытка последняя: событие уходит в failed и больше не держит pr-1
+	require.NoError(t, relay.Tick(ctx))
+	assert.Equal(t, []string{next.ID}, sink.ids())
+	pending, err := repo.Outbox.Pending(ctx, 10)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+
+	var failed models.OutboxEvent
+	require.NoError(t, database.DB.Where("event_id = ?", poison.ID).First(&failed).Error)
+	assert.Equal(t, 3, failed.Attempts)
+	assert.NotNil(t, failed.FailedAt)
+	assert.Nil(t, failed.PublishedAt)
+}
+
+// expiringLeases отдаёт аренду только на первые calls вызовов TryAcquire
+type expiringLeases struct {
+	repositories.LeaseRepository
+	calls int
+}
+
+func (l *expiringLeases) TryAcquire(ctx context.Context, name, owner string, now time.Time, ttl time.Duration) (bool, error) {
+	l.calls--
+	if l.calls < 0 {
+		return false, nil
+	}
+	return l.LeaseRepository.TryAcquire(ctx, name, owner, now, ttl)
+}
+
+func TestRelay_RenewsLeasePerBatch(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+	sink := &recordingSink{}
+	relay := NewRelay(repo, Config{PollInterval: time.Second, BatchSize: 2, LeaseTTL: time.Minute, Retention: time.Hour, MaxAttempts: 3},
+		Sink{Name: "sink", Publisher: sink})
+	leases := &expiringLeases{LeaseRepository: repo.Leases, calls: 2}
+	repo.Leases = leases
+
+	for _, key := range []string{"pr-1", "pr-2", "pr-3", "pr-4", "pr-5"} {
+		appendEvent(t, repo, key)
+	}
+	// Аренда потеряна перед третьей пачкой — Relay останавливается
+	require.NoError(t, relay.Tick(ctx))
+	assert.Len(t, sink.received, 4)
+}
+
+func TestRelay_SingleLeader(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+	leaderSink, followerSink := &recordingSink{}, &recordingSink{}
+	leader := newTestRelay(repo, Sink{Name: "sink", Publisher: leaderSink})
+	follower := newTestRelay(repo, Sink{Name: "sink", Publisher: followerSink})
+
+	appendEvent(t, repo, "pr-1")
+	require.NoError(t, leader.Tick(ctx))
+	appendEvent(t, repo, "pr-1")
+	require.NoError(t, follower.Tick(ctx))
+	assert.Empty(t, followerSink.received)
+
+	// Аренда истекла — публикацию подхватывает другой Relay
+	follower.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	require.NoError(t, follower.Tick(ctx))
+	assert.Len(t, leaderSink.received, 1)
+	assert.Len(t, followerSink.received, 1)
+}
+
+func TestRelay_PurgesPublished(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+	relay := newTestRelay(repo, Sink{Name: "sink", Publisher: &recordingSink{}})
+
+	appendEvent(t, repo, "pr-1")
+	require.NoError(t, relay.Tick(ctx))
+	deleted, err := repo.Outbox.DeletePublished(ctx, time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	assert.Zero(t, deleted)
+
+	relay.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	require.NoError(t, relay.Tick(ctx))
+	deleted, err = repo.Outbox.DeletePublished(ctx, time.Now().Add(3*time.Hour))
+	require.NoError(t, err)
+	assert.Zero(t, deleted, "relay should have purged the event already")
+}
+
+func TestEventLogSink(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+	relay := newTestRelay(repo, Sink{Name: "event_log", Publisher: EventLogSink{Repo: repo.EventLog}})
+
+	require.NoError(t, Append(ctx, repo,
+		events.New(events.TypePRMerged, "backend", "pr-1", events.PullRequestData{
+			PullRequest: &models.PullRequestResponse{PullRequestId: "pr-1", AuthorId: "u1", AssignedReviewers: []string{"u2", "u3"}},
+		}),
+		events.New(events.TypeReviewerReassigned, "backend", "pr-1", events.ReassignmentData{
+			PullRequestID: "pr-1", OldReviewerID: "u2", NewReviewerID: "u4",
+		}),
+		events.New(events.TypeUserDeactivated, "frontend", "u5", events.UserData{UserID: "u5", Username: "Eve"}),
+	))
+	require.NoError(t, relay.Tick(ctx))
+
+	entries, err := repo.EventLog.After(ctx, 0, "", 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, models.StringArray{"u1", "u2", "u3"}, entries[0].UserIDs)
+	assert.Equal(t, models.StringArray{"u2", "u4"}, entries[1].UserIDs)
+	assert.Equal(t, models.StringArray{"u5"}, entries[2].UserIDs)
+	assert.Equal(t, "frontend", entries[2].TeamName)
+	var payload map[string]any
+	require.NoError(t, json.Unmarshal([]byte(entries[1].Payload), &payload))
+	assert.Equal(t, events.TypeReviewerReassigned, payload["type"])
+
+	// Журнал чистится вместе с outbox
+	relay.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	require.NoError(t, relay.Tick(ctx))
+	entries, err = repo.EventLog.After(ctx, 0, "", 10)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+// recordingProducer запоминает отправленные сообщения
+type recordingProducer struct {
+	topics, keys []string
+}
+
+func (p *recordingProducer) Send(_ context.Context, topic, key string, _ []byte) error {
+	p.topics = append(p.topics, topic)
+	p.keys = append(p.keys, key)
+	return nil
+}
+
+func TestBrokerSink(t *testing.T) {
+	ctx := context.Background()
+	producer := &recordingProducer{}
+	ev := events.New(events.TypePRCreated, "backend", "pr-1", nil)
+
+	require.NoError(t, BrokerSink{Producer: producer}.Publish(ctx, ev))
+	require.NoError(t, BrokerSink{Producer: producer, Topic: "review-events"}.Publish(ctx, ev))
+	assert.Equal(t, []string{events.TypePRCreated, "review-events"}, producer.topics)
+	assert.Equal(t, []string{"pr-1", "pr-1"}, producer.keys)
+}
@@ -0,0 +1,113 @@
+// Package ratelimit ограничивает частоту запросов по алгоритму token bucket.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limit — параметры корзины: Rate токенов в секунду, не больше Burst в запасе
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Enabled сообщает, задан ли лимит; нулевой Limit ничего не ограничивает
+func (l Limit) Enabled() bool {
+	return l.Rate > 0 && l.Burst > 0
+}
+
+// Decision — результат проверки одного запроса
+type Decision struct {
+	Allowed bool
+	// Remaining — сколько запросов ещё можно сделать без ожидания
+	Remaining int
+	// RetryAfter — через сколько появится следующий токен, если запрос отклонён
+	RetryAfter time.Duration
+}
+
+// Store хранит корзины. Memory подходит для одного экземпляра сервиса;
+// чтобы лимит был общим для нескольких подов, Store реализуется поверх
+// общего хранилища (например, Redis со скриптом, выполняющим take атомарно).
+type Store interface {
+	// Allow списывает токен из корзины key с параметрами limit
+	Allow(ctx context.Context, key string, limit Limit) (Decision, error)
+}
+
+// bucket — состояние корзины на момент updated
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// take пополняет корзину за прошедшее время и пытается списать токен
+func (b *bucket) take(now time.Time, limit Limit) Decision {
+	if elapsed := now.Sub(b.updated).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(float64(limit.Burst), b.tokens+elapsed*limit.Rate)
+	}
+	b.updated = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return Decision{Allowed: true, Remaining: int(b.tokens)}
+	}
+	wait := (1 - b.tokens) / limit.Rate
+	return Decision{RetryAfter: time.Duration(math.Ceil(wait * float64(time.Second)))}
+}
+
+// full сообщает, что корзина уже пополнилась бы до Burst, — её можно забыть
+func (b *bucket) full(now time.Time, limit Limit) bool {
+	return b.tokens+now.Sub(b.updated).Seconds()*limit.Rate >= float64(limit.Burst)
+}
+
+// sweepInterval — как часто Memory удаляет полные корзины, чтобы не копить ключи
+const sweepInterval = time.Minute
+
+// Memory хранит корзины в памяти процесса
+type Memory struct {
+	now func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	limits    map[string]Limit
+	lastSweep time.Time
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		now:     time.Now,
+		buckets: make(map[string]*bucket),
+		limits:  make(map[string]Limit),
+	}
+}
+
+func (m *Memory) Allow(_ context.Context, key string, limit Limit) (Decision, error) {
+	if !limit.Enabled() {
+		return Decision{Allowed: true}, nil
+	}
+	now := m.now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if now.Sub(m.lastSweep) >= sweepInterval {
+		m.sweep(now)
+	}
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updated: now}
+		m.buckets[key] = b
+	}
+	m.limits[key] = limit
+	return b.take(now, limit), nil
+}
+
+func (m *Memory) sweep(now time.Time) {
+	for key, b := range m.buckets {
+		if b.full(now, m.limits[key]) {
+			delete(m.buckets, key)
+			delete(m.limits, key)
+		}
+	}
+	m.lastSweep = now
+}
@@ -0,0 +1,70 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestMemory() (*Memory, *time.Time) {
+	m := NewMemory()
+	now := time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+	return m, &now
+}
+
+func TestMemory_TokenBucket(t *testing.T) {
+	ctx := context.Background()
+	m, now := newTestMemory()
+	limit := Limit{Rate: 2, Burst: 3}
+
+	// Корзина полная: три запроса подряд проходят, четвёртый ждёт полсекунды
+	for i := 2; i >= 0; i-- {
+		d, err := m.Allow(ctx, "ci", limit)
+		require.NoError(t, err)
+		assert.True(t, d.Allowed)
+		assert.Equal(t, i, d.Remaining)
+	}
+	d, err := m.Allow(ctx, "ci", limit)
+	require.NoError(t, err)
+	assert.False(t, d.Allowed)
+	assert.Equal(t, 500*time.Millisecond, d.RetryAfter)
+
+	// Другой клиент не затронут
+	d, _ = m.Allow(ctx, "alice", limit)
+	assert.True(t, d.Allowed)
+
+	*now = now.Add(500 * time.Millisecond)
+	d, _ = m.Allow(ctx, "ci", limit)
+	assert.True(t, d.Allowed)
+	d, _ = m.Allow(ctx, "ci", limit)
+	assert.False(t, d.Allowed)
+}
+
+func TestMemory_DisabledLimit(t *testing.T) {
+	m, _ := newTestMemory()
+	for range 100 {
+		d, err := m.Allow(context.Background(), "ci", Limit{})
+		require.NoError(t, err)
+		assert.True(t, d.Allowed)
+	}
+	assert.Empty(t, m.buckets)
+}
+
+func TestMemory_SweepsFullBuckets(t *testing.T) {
+	ctx := context.Background()
+	m, now := newTestMemory()
+	limit := Limit{Rate: 1, Burst: 10}
+
+	_, _ = m.Allow(ctx, "idle", limit)
+	*now = now.Add(sweepInterval)
+	for range 10 {
+		_, _ = m.Allow(ctx, "busy", limit)
+	}
+	// idle давно пополнилась и удалена, busy осталась
+	assert.Len(t, m.buckets, 1)
+	assert.Contains(t, m.buckets, "busy")
+}
@@ -0,0 +1,60 @@
+package repositories
+
+import "gorm.io/gorm"
+
+func isSQLite(db *gorm.DB) bool {
+	return db.Dialector.Name() == "sqlite"
+}
+
+// reviewerCondition возвращает условие WHERE "пользователь ? среди ревьюеров"
+// для диалекта текущего подключения.
+func reviewerCondition(db *gorm.DB) string {
+	if isSQLite(db) {
+		return "EXISTS (SELECT 1 FROM json_each(pull_requests.reviewers) WHERE json_each.value = ?)"
+	}
+	return "? = ANY(reviewers)"
+}
+
+// reviewAssignmentsSQL разворачивает массив ревьюеров: одна строка на пару (PR, ревьюер)
+// с колонками pr_id, author_id, status, created_at, merged_at, reviewer_id.
+func reviewAssignmentsSQL(db *gorm.DB) string {
+	if isSQLite(db) {
+		return `SELECT pr.id AS pr_id, pr.author_id, pr.status, pr.created_at, pr.merged_at, j.value AS reviewer_id
+			FROM pull_requests pr, json_each(pr.reviewers) j`
+	}
+	return `SELECT pr.id AS pr_id, pr.author_id, pr.status, pr.created_at, pr.merged_at, r.reviewer_id
+			FROM pull_requests pr CROSS JOIN LATERAL unnest(pr.reviewers) AS r(reviewer_id)`
+}
+
+// secondsBetweenSQL возвращает выражение с числом секунд между двумя метками времени
+func secondsBetweenSQL(db *gorm.DB, from, to string) string {
+	if isSQLite(db) {
+		return "((julianday(" + to + ") - julianday(" + from + ")) * 86400.0)"
+	}
+	return "EXTRACT(EPOCH FROM (" + to + " - " + from + "))"
+}
+
+// reviewerCountSQL возвращает выражение с числом ревьюеров PR
+func reviewerCountSQL(db *gorm.DB, column string) string {
+	if isSQLite(db) {
+		return "COALESCE(json_array_length(" + column + "), 0)"
+	}
+	return "COALESCE(cardinality(" + column + "), 0)"
+}
+
+// dayOfSQL возвращает дату метки времени в UTC в формате YYYY-MM-DD
+func dayOfSQL(db *gorm.DB, column string) string {
+	if isSQLite(db) {
+		return "strftime('%Y-%m-%d', " + column + ")"
+	}
+	return "to_char(" + column + " AT TIME ZONE 'UTC', 'YYYY-MM-DD')"
+}
+
+// timeCompareSQL возвращает условие "column op ?". SQLite хранит время строкой
+// с часовым поясом, поэтому там сравнение идёт через julianday.
+func timeCompareSQL(db *gorm.DB, column, op string) string {
+	if isSQLite(db) {
+		return "julianday(" + column + ") " + op + " julianday(?)"
+	}
+	return column + " " + op + " ?"
+}
@@ -0,0 +1,157 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/errors"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GormCodeHostRepository struct {
+	db *gorm.DB
+}
+
+func NewGormCodeHostRepository(db *gorm.DB) CodeHostRepository {
+	return &GormCodeHostRepository{db: db}
+}
+
+func (g *GormCodeHostRepository) SetAccount(ctx context.Context, account *models.CodeHostAccount) error {
+	return g.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "provider"}, {Name: "login"}},
+		DoUpdates: clause.AssignmentColumns([]string{"user_id"}),
+	}).Create(account).Error
+}
+
+func (g *GormCodeHostRepository) GetAccount(ctx context.Context, provider, login string) (*models.CodeHostAccount, error) {
+	var account models.CodeHostAccount
+	res := g.db.WithContext(ctx).Where("provider = ? AND login = ?", provider, login).First(&account)
+	if res.Error != nil {
+		if res.Error == gorm.ErrRecordNotFound {
+			return nil, errors.NewNotFound()
+		}
+		return nil, res.Error
+	}
+	return &account, nil
+}
+
+func (g *GormCodeHostRepository) ListAccounts(ctx context.Context, provider string) ([]models.CodeHostAccount, error) {
+	query := g.db.WithContext(ctx)
+	if provider != "" {
+		query = query.Where("provider = ?", provider)
+	}
+	var accounts []models.CodeHostAccount
+	err := query.Order("provider, login").Find(&accounts).Error
+	return accounts, err
+}
+
+func (g *GormCodeHostRepository) DeleteAccount(ctx context.Context, provider, login string) error {
+	res := g.db.WithContext(ctx).Where("provider = ? AND login = ?", provider, login).Delete(&models.CodeHostAccount{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errors.NewNotFound()
+	}
+	return nil
+}
+
+func (g *GormCodeHostRepository) LinkPullRequest(ctx context.Context, link *models.CodeHostPullRequest) error {
+	return g.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(link).Error
+}
+
+func (g *GormCodeHostRepository) GetPullRequestLink(ctx context.Context, prID string) (*models.CodeHostPullRequest, error) {
+	var link models.CodeHostPullRequest
+	res := g.db.WithContext(ctx).Where("pull_request_id = ?", prID).First(&link)
+	if res.Error != nil {
+		if res.Error == gorm.ErrRecordNotFound {
+			return nil, errors.NewNotFound()
+		}
+		return nil, res.Error
+	}
+	return &link, nil
+}
+
+func (g *GormCodeHostRepository) UnlinkPullRequest(ctx context.Context, prID string) error {
+	return g.db.WithContext(ctx).Where("pull_request_id = ?", prID).Delete(&models.CodeHostPullRequest{}).Error
+}
+
+func (g *GormCodeHostRepository) GetAccountByUser(ctx context.Context, provider, userID string) (*models.CodeHostAccount, error) {
+	var account models.CodeHostAccount
+	res := g.db.WithContext(ctx).
+		Where("provider = ? AND user_id = ?", provider, userID).
+		Order("login").
+		First(&account)
+	if res.Error != nil {
+		if res.Error == gorm.ErrRecordNotFound {
+			return nil, errors.NewNotFound()
+		}
+		return nil, res.Error
+	}
+	return &account, nil
+}
+
+func (g *GormCodeHostRepository) CreateSyncs(ctx context.Context, syncs []models.ReviewerSync) error {
+	if len(syncs) == 0 {
+		return nil
+	}
+	// Повторная публикация события не создаёт вторую задачу
+	return g.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "event_id"}},
+		DoNothing: true,
+	}).Create(&syncs).Error
+}
+
+func (g *GormCodeHostRepository) ClaimDueSyncs(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.ReviewerSync, error) {
+	var syncs []models.ReviewerSync
+	err := g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ?", models.DeliveryPending).
+			Where(timeCompareSQL(tx, "next_attempt_at", "<="), now).
+			Order("next_attempt_at").
+			Limit(limit).
+			Find(&syncs).Error
+		if err != nil || len(syncs) == 0 {
+			return err
+		}
+		ids := make([]string, len(syncs))
+		for i := range syncs {
+			ids[i] = syncs[i].ID
+		}
+		return tx.Model(&models.ReviewerSync{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(lease)).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return syncs, nil
+}
+
+func (g *GormCodeHostRepository) UpdateSync(ctx context.Context, sync *models.ReviewerSync) error {
+	return g.db.WithContext(ctx).Save(sync).Error
+}
+
+func (g *GormCodeHostRepository) GetSync(ctx context.Context, id string) (*models.ReviewerSync, error) {
+	var sync models.ReviewerSync
+	res := g.db.WithContext(ctx).Where("id = ?", id).First(&sync)
+	if res.Error != nil {
+		if res.Error == gorm.ErrRecordNotFound {
+			return nil, errors.NewNotFound()
+		}
+		return nil, res.Error
+	}
+	return &sync, nil
+}
+
+func (g *GormCodeHostRepository) ListSyncs(ctx context.Context, status string, limit int) ([]models.ReviewerSync, error) {
+	query := g.db.WithContext(ctx)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var syncs []models.ReviewerSync
+	err := query.Order("created_at DESC, id DESC").Limit(limit).Find(&syncs).Error
+	return syncs, err
+}
@@ -0,0 +1,53 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GormEventLogRepository struct {
+	db *gorm.DB
+}
+
+func NewGormEventLogRepository(db *gorm.DB) EventLogRepository {
+	return &GormEventLogRepository{db: db}
+}
+
+func (g *GormEventLogRepository) Append(ctx context.Context, entries []models.EventLogEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	// Relay доставляет «хотя бы один раз», повтор события не дублирует запись
+	return g.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "event_id"}},
+		DoNothing: true,
+	}).Create(&entries).Error
+}
+
+func (g *GormEventLogRepository) After(ctx context.Context, afterID uint, teamName string, limit int) ([]models.EventLogEntry, error) {
+	query := g.db.WithContext(ctx).Where("id > ?", afterID)
+	if teamName != "" {
+		query = query.Where("team_name = ?", teamName)
+	}
+	var entries []models.EventLogEntry
+	err := query.Order("id").Limit(limit).Find(&entries).Error
+	return entries, err
+}
+
+func (g *GormEventLogRepository) LastID(ctx context.Context) (uint, error) {
+	var id uint
+	err := g.db.WithContext(ctx).Model(&models.EventLogEntry{}).
+		Select("COALESCE(MAX(id), 0)").
+		Scan(&id).Error
+	return id, err
+}
+
+func (g *GormEventLogRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	db := g.db.WithContext(ctx)
+	res := db.Where(timeCompareSQL(db, "created_at", "<"), before).Delete(&models.EventLogEntry{})
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,63 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GormIdempotencyRepository struct {
+	db *gorm.DB
+}
+
+func NewGormIdempotencyRepository(db *gorm.DB) IdempotencyRepository {
+	return &GormIdempotencyRepository{db: db}
+}
+
+// Reserve занимает ключ под новый запрос. Если ключ уже занят и не истёк,
+// возвращает существующую запись и reserved=false.
+func (g *GormIdempotencyRepository) Reserve(ctx context.Context, key, requestHash string, expiresAt time.Time) (*models.IdempotencyKey, bool, error) {
+	db := g.db.WithContext(ctx)
+	if err := db.Where("key = ? AND expires_at <= ?", key, time.Now()).Delete(&models.IdempotencyKey{}).Error; err != nil {
+		return nil, false, err
+	}
+	record := &models.IdempotencyKey{
+		Key:         key,
+		RequestHash: requestHash,
+		ExpiresAt:   expiresAt,
+	}
+	res := db.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+	if res.Error != nil {
+		return nil, false, res.Error
+	}
+	if res.RowsAffected == 1 {
+		return record, true, nil
+	}
+	var existing models.IdempotencyKey
+	if err := db.Where("key = ?", key).First(&existing).Error; err != nil {
+		return nil, false, err
+	}
+	return &existing, false, nil
+}
+
+func (g *GormIdempotencyRepository) Complete(ctx context.Context, key string, statusCode int, headers string, body []byte) error {
+	return g.db.WithContext(ctx).Model(&models.IdempotencyKey{}).
+		Where("key = ?", key).
+		Updates(map[string]interface{}{
+			"status_code": statusCode,
+			"headers":     headers,
+			"body":        body,
+		}).Error
+}
+
+func (g *GormIdempotencyRepository) Release(ctx context.Context, key string) error {
+	return g.db.WithContext(ctx).Where("key = ?", key).Delete(&models.IdempotencyKey{}).Error
+}
+
+func (g *GormIdempotencyRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	res := g.db.WithContext(ctx).Where("expires_at <= ?", now).Delete(&models.IdempotencyKey{})
+	return res.RowsAffected, res.Error
+}
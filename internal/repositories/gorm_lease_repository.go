@@ -0,0 +1,41 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GormLeaseRepository struct {
+	db *gorm.DB
+}
+
+func NewGormLeaseRepository(db *gorm.DB) LeaseRepository {
+	return &GormLeaseRepository{db: db}
+}
+
+func (g *GormLeaseRepository) TryAcquire(ctx context.Context, name, owner string, now time.Time, ttl time.Duration) (bool, error) {
+	db := g.db.WithContext(ctx)
+	// Строку аренды создаёт первый претендент; истёкшая аренда свободна
+	err := db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.WorkerLease{Name: name, ExpiresAt: now}).Error
+	if err != nil {
+		return false, err
+	}
+	res := db.Model(&models.WorkerLease{}).
+		Where("name = ? AND (owner = ? OR "+timeCompareSQL(db, "expires_at", "<=")+")", name, owner, now).
+		Updates(map[string]any{"owner": owner, "expires_at": now.Add(ttl)})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+func (g *GormLeaseRepository) Release(ctx context.Context, name, owner string) error {
+	return g.db.WithContext(ctx).Model(&models.WorkerLease{}).
+		Where("name = ? AND owner = ?", name, owner).
+		Update("expires_at", time.Time{}).Error
+}
@@ -0,0 +1,69 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
+	"gorm.io/gorm"
+)
+
+type GormOutboxRepository struct {
+	db *gorm.DB
+}
+
+func NewGormOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &GormOutboxRepository{db: db}
+}
+
+func (g *GormOutboxRepository) Append(ctx context.Context, events []models.OutboxEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	return g.db.WithContext(ctx).Create(&events).Error
+}
+
+func (g *GormOutboxRepository) Pending(ctx context.Context, limit int) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+	err := g.db.WithContext(ctx).
+		Where("published_at IS NULL AND failed_at IS NULL").
+		Order("id").
+		Limit(limit).
+		Find(&events).Error
+	return events, err
+}
+
+func (g *GormOutboxRepository) MarkPublished(ctx context.Context, ids []uint, at time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return g.db.WithContext(ctx).Model(&models.OutboxEvent{}).
+		Where("id IN ?", ids).
+		Update("published_at", at).Error
+}
+
+func (g *GormOutboxRepository) RecordFailure(ctx context.Context, id uint, errText string) error {
+	return g.db.WithContext(ctx).Model(&models.OutboxEvent{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"attempts":   gorm.Expr("attempts + 1"),
+			"last_error": errText,
+		}).Error
+}
+
+func (g *GormOutboxRepository) MarkFailed(ctx context.Context, id uint, errText string, at time.Time) error {
+	return g.db.WithContext(ctx).Model(&models.OutboxEvent{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"attempts":   gorm.Expr("attempts + 1"),
+			"last_error": errText,
+			"failed_at":  at,
+		}).Error
+}
+
+func (g *GormOutboxRepository) DeletePublished(ctx context.Context, before time.Time) (int64, error) {
+	db := g.db.WithContext(ctx)
+	res := db.Where("published_at IS NOT NULL AND "+timeCompareSQL(db, "published_at", "<"), before).
+		Delete(&models.OutboxEvent{})
+	return res.RowsAffected, res.Error
+}
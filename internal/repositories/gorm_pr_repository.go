@@ -1,9 +1,12 @@
 package repositories
 
 import (
+	"context"
+
 	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/errors"
 	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type GormPRRepository struct {
@@ -14,33 +17,36 @@ func NewGormPRRepository(db *gorm.DB) *GormPRRepository {
 	return &GormPRRepository{db: db}
 }
 
-func (g *GormPRRepository) PRExists(id string) (bool, error) {
+func (g *GormPRRepository) PRExists(ctx context.Context, id string) (bool, error) {
 	var count int64
-	res := g.db.Model(&models.PullRequest{}).Where("id = ?", id).Count(&count)
+	res := g.db.WithContext(ctx).Model(&models.PullRequest{}).Where("id = ?", id).Count(&count)
 	if res.Error != nil {
 		return false, res.Error
 	}
 	return count > 0, nil
 }
 
-func (g *GormPRRepository) CreatePR(pr *models.PullRequest) error {
-	exists, err := g.PRExists(pr.ID)
+func (g *GormPRRepository) CreatePR(ctx context.Context, pr *models.PullRequest) error {
+	exists, err := g.PRExists(ctx, pr.ID)
 	if err != nil {
 		return err
 	}
 	if exists {
 		return errors.NewPRExists(pr.ID)
 	}
-	result := g.db.Create(pr)
+	if pr.Version == 0 {
+		pr.Version = 1
+	}
+	result := g.db.WithContext(ctx).Create(pr)
 	if result.Error != nil {
 		return result.Error
 	}
 	return nil
 }
 
-func (g *GormPRRepository) GetPRByID(id string) (*models.PullRequest, error) {
+func (g *GormPRRepository) GetPRByID(ctx context.Context, id string) (*models.PullRequest, error) {
 	var pr models.PullRequest
-	res := g.db.Where("id = ?", id).First(&pr)
+	res := g.db.WithContext(ctx).Where("id = ?", id).First(&pr)
 	if res.Error != nil {
 		if res.Error == gorm.ErrRecordNotFound {
 			return nil, errors.NewNotFound()
@@ -50,17 +56,53 @@ func (g *GormPRRepository) GetPRByID(id string) (*models.PullRequest, error) {
 	return &pr, nil
 }
 
-func (r *GormPRRepository) UpdatePR(pr *models.PullRequest) error {
-	res := r.db.Save(pr)
+// GetPRByIDForUpdate блокирует строку PR до конца транзакции (SELECT ... FOR UPDATE).
+// Имеет смысл только внутри Repository.WithTx.
+func (g *GormPRRepository) GetPRByIDForUpdate(ctx context.Context, id string) (*models.PullRequest, error) {
+	var pr models.PullRequest
+	res := g.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&pr)
+	if res.Error != nil {
+		if res.Error == gorm.ErrRecordNotFound {
+			return nil, errors.NewNotFound()
+		}
+		return nil, res.Error
+	}
+	return &pr, nil
+}
+
+// UpdatePR сохраняет PR, только если его версия в базе не изменилась с момента чтения,
+// иначе возвращает CONFLICT. При успехе pr.Version увеличивается.
+func (r *GormPRRepository) UpdatePR(ctx context.Context, pr *models.PullRequest) error {
+	res := r.db.WithContext(ctx).Model(&models.PullRequest{}).
+		Where("id = ? AND version = ?", pr.ID, pr.Version).
+		Updates(map[string]interface{}{
+			"title":     pr.Title,
+			"status":    pr.Status,
+			"reviewers": pr.Reviewers,
+			"merged_at": pr.MergedAt,
+			"version":   pr.Version + 1,
+		})
 	if res.Error != nil {
 		return res.Error
 	}
+	if res.RowsAffected == 0 {
+		return errors.NewConflict()
+	}
+	pr.Version++
 	return nil
 }
 
-func (g *GormPRRepository) GetPRsByReviewer(userID string) ([]models.PullRequest, error) {
+func (g *GormPRRepository) RecordReassignment(ctx context.Context, reassignment *models.Reassignment) error {
+	return g.db.WithContext(ctx).Create(reassignment).Error
+}
+
+func (g *GormPRRepository) RecordNoCandidate(ctx context.Context, event *models.NoCandidateEvent) error {
+	return g.db.WithContext(ctx).Create(event).Error
+}
+
+func (g *GormPRRepository) GetPRsByReviewer(ctx context.Context, userID string) ([]models.PullRequest, error) {
 	var prs []models.PullRequest
-	res := g.db.Where("? = ANY(reviewers)", userID).Find(&prs)
+	res := g.db.WithContext(ctx).Where(reviewerCondition(g.db), userID).Find(&prs)
 	if res.Error != nil {
 		return nil, res.Error
 	}
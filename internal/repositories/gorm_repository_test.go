@@ -0,0 +1,62 @@
+package repositories_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/db"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/repositories"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/repositories/repotest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func openRepository(t *testing.T, connStr string) *repositories.Repository {
+	t.Helper()
+	database, err := db.Open(connStr)
+	if err != nil {
+		t.Fatalf("open %s: %v", connStr, err)
+	}
+	t.Cleanup(database.Close)
+	return repositories.NewRepository(database.DB)
+}
+
+func TestGormRepository_SQLite(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) *repositories.Repository {
+		return openRepository(t, "sqlite://"+filepath.Join(t.TempDir(), "test.db"))
+	})
+}
+
+func TestGormRepository_Postgres(t *testing.T) {
+	pg := repotest.StartPostgres(t)
+	repotest.Run(t, func(t *testing.T) *repositories.Repository {
+		return openRepository(t, pg.NewDatabase(t))
+	})
+}
+
+func TestRepository_ReadReplica(t *testing.T) {
+	ctx := context.Background()
+	primary := openRepository(t, "sqlite://"+filepath.Join(t.TempDir(), "primary.db"))
+	replicaDB, err := db.Open("sqlite://" + filepath.Join(t.TempDir(), "replica.db"))
+	require.NoError(t, err)
+	t.Cleanup(replicaDB.Close)
+
+	// Без реплики ReadOnly читает из основной базы
+	assert.Same(t, primary, primary.ReadOnly())
+
+	// Разные файлы позволяют увидеть, откуда пришло чтение
+	replica := repositories.NewRepository(replicaDB.DB)
+	require.NoError(t, replica.Team.CreateTeam(ctx, &models.Team{ID: "t1", Name: "backend"}, nil))
+	repo := primary.WithReadReplica(replicaDB.DB)
+
+	team, err := repo.ReadOnly().Team.GetTeamByName(ctx, "backend")
+	require.NoError(t, err)
+	assert.Equal(t, "t1", team.ID)
+
+	_, err = repo.Team.GetTeamByName(ctx, "backend")
+	assert.Error(t, err)
+	// Исходный репозиторий не меняется
+	assert.Same(t, primary, primary.ReadOnly())
+}
@@ -0,0 +1,143 @@
+package repositories
+
+import (
+	"context"
+	"strings"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
+	"gorm.io/gorm"
+)
+
+// GormStatsRepository считает статистику агрегирующими запросами на стороне базы
+type GormStatsRepository struct {
+	db *gorm.DB
+}
+
+func NewGormStatsRepository(db *gorm.DB) StatsRepository {
+	return &GormStatsRepository{db: db}
+}
+
+func (g *GormStatsRepository) TeamMemberStats(ctx context.Context, teamID string) ([]models.MemberReviewStats, error) {
+	query := `
+		SELECT
+			u.id AS user_id,
+			u.username,
+			u.is_active,
+			COUNT(a.pr_id) AS assigned_reviews,
+			COALESCE(SUM(CASE WHEN a.status = 'OPEN' THEN 1 ELSE 0 END), 0) AS open_reviews,
+			COALESCE(SUM(CASE WHEN a.status = 'MERGED' THEN 1 ELSE 0 END), 0) AS merged_reviews,
+			AVG(CASE WHEN a.status = 'MERGED' THEN ` + secondsBetweenSQL(g.db, "a.created_at", "a.merged_at") + ` END) AS avg_time_to_merge_seconds,
+			(SELECT COUNT(*) FROM reassignments ra WHERE ra.old_reviewer_id = u.id) AS reassigned_from,
+			(SELECT COUNT(*) FROM reassignments ra WHERE ra.new_reviewer_id = u.id) AS reassigned_to
+		FROM users u
+		LEFT JOIN (` + reviewAssignmentsSQL(g.db) + `) a ON a.reviewer_id = u.id
+		WHERE u.team_id = ?
+		GROUP BY u.id, u.username, u.is_active
+		ORDER BY u.id`
+	var stats []models.MemberReviewStats
+	if err := g.db.WithContext(ctx).Raw(query, teamID).Scan(&stats).Error; err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+func (g *GormStatsRepository) TeamPRStats(ctx context.Context, teamID string) (*models.TeamPRStats, error) {
+	query := `
+		SELECT
+			COUNT(pr.id) AS authored,
+			COALESCE(SUM(CASE WHEN pr.status = 'OPEN' THEN 1 ELSE 0 END), 0) AS open,
+			COALESCE(SUM(CASE WHEN pr.status = 'MERGED' THEN 1 ELSE 0 END), 0) AS merged,
+			AVG(CASE WHEN pr.status = 'MERGED' THEN ` + secondsBetweenSQL(g.db, "pr.created_at", "pr.merged_at") + ` END) AS avg_time_to_merge_seconds
+		FROM pull_requests pr
+		JOIN users u ON u.id = pr.author_id
+		WHERE u.team_id = ?`
+	var stats models.TeamPRStats
+	if err := g.db.WithContext(ctx).Raw(query, teamID).Scan(&stats).Error; err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// filterSQL собирает условие WHERE по фильтру для колонки времени timeColumn
+// и колонки команды teamColumn
+func (g *GormStatsRepository) filterSQL(filter models.StatsFilter, timeColumn, teamColumn string) (string, []interface{}) {
+	conditions := []string{"1 = 1"}
+	var args []interface{}
+	if filter.From != nil {
+		conditions = append(conditions, timeCompareSQL(g.db, timeColumn, ">="))
+		args = append(args, *filter.From)
+	}
+	if filter.To != nil {
+		conditions = append(conditions, timeCompareSQL(g.db, timeColumn, "<"))
+		args = append(args, *filter.To)
+	}
+	if filter.TeamID != "" {
+		conditions = append(conditions, teamColumn+" = ?")
+		args = append(args, filter.TeamID)
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+func (g *GormStatsRepository) ReviewsPerUser(ctx context.Context, filter models.StatsFilter) ([]models.UserReviewCount, error) {
+	where, args := g.filterSQL(filter, "a.created_at", "author.team_id")
+	query := `
+		SELECT u.id AS user_id, u.username, t.name AS team_name, COUNT(*) AS assigned_reviews
+		FROM (` + reviewAssignmentsSQL(g.db) + `) a
+		JOIN users author ON author.id = a.author_id
+		JOIN users u ON u.id = a.reviewer_id
+		JOIN teams t ON t.id = u.team_id
+		WHERE ` + where + `
+		GROUP BY u.id, u.username, t.name
+		ORDER BY assigned_reviews DESC, u.id`
+	var counts []models.UserReviewCount
+	if err := g.db.WithContext(ctx).Raw(query, args...).Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+func (g *GormStatsRepository) PRsByReviewerCount(ctx context.Context, filter models.StatsFilter) ([]models.ReviewerCountBucket, error) {
+	where, args := g.filterSQL(filter, "pr.created_at", "author.team_id")
+	query := `
+		SELECT ` + reviewerCountSQL(g.db, "pr.reviewers") + ` AS reviewers, COUNT(*) AS prs
+		FROM pull_requests pr
+		JOIN users author ON author.id = pr.author_id
+		WHERE ` + where + `
+		GROUP BY 1
+		ORDER BY 1`
+	var buckets []models.ReviewerCountBucket
+	if err := g.db.WithContext(ctx).Raw(query, args...).Scan(&buckets).Error; err != nil {
+		return nil, err
+	}
+	return buckets, nil
+}
+
+func (g *GormStatsRepository) NoCandidateCount(ctx context.Context, filter models.StatsFilter) (int, error) {
+	where, args := g.filterSQL(filter, "e.created_at", "u.team_id")
+	query := `
+		SELECT COUNT(*)
+		FROM no_candidate_events e
+		JOIN users u ON u.id = e.reviewer_id
+		WHERE ` + where
+	var count int
+	if err := g.db.WithContext(ctx).Raw(query, args...).Scan(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (g *GormStatsRepository) MergesPerDay(ctx context.Context, filter models.StatsFilter) ([]models.DailyMerges, error) {
+	where, args := g.filterSQL(filter, "pr.merged_at", "author.team_id")
+	query := `
+		SELECT ` + dayOfSQL(g.db, "pr.merged_at") + ` AS day, COUNT(*) AS merged
+		FROM pull_requests pr
+		JOIN users author ON author.id = pr.author_id
+		WHERE pr.merged_at IS NOT NULL AND ` + where + `
+		GROUP BY 1
+		ORDER BY 1`
+	var days []models.DailyMerges
+	if err := g.db.WithContext(ctx).Raw(query, args...).Scan(&days).Error; err != nil {
+		return nil, err
+	}
+	return days, nil
+}
@@ -1,6 +1,8 @@
 package repositories
 
 import (
+	"context"
+
 	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/errors"
 	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
 	"gorm.io/gorm"
@@ -14,15 +16,15 @@ func NewGormTeamRepository(db *gorm.DB) TeamRepository {
 	return &GormTeamRepository{db: db}
 }
 
-func (g *GormTeamRepository) CreateTeam(team *models.Team, users []models.User) error {
-	exists, err := g.TeamExists(team.Name)
+func (g *GormTeamRepository) CreateTeam(ctx context.Context, team *models.Team, users []models.User) error {
+	exists, err := g.TeamExists(ctx, team.Name)
 	if err != nil {
 		return err
 	}
 	if exists {
 		return errors.NewTeamExists(team.Name)
 	}
-	return g.db.Transaction(func(tx *gorm.DB) error {
+	return g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		if err := tx.Create(team).Error; err != nil {
 			return err
 		}
@@ -36,9 +38,21 @@ func (g *GormTeamRepository) CreateTeam(team *models.Team, users []models.User)
 	})
 }
 
-func (g *GormTeamRepository) GetTeamByName(name string) (*models.Team, error) {
+func (g *GormTeamRepository) GetTeamByName(ctx context.Context, name string) (*models.Team, error) {
+	var team models.Team
+	res := g.db.WithContext(ctx).Where("name = ?", name).First(&team)
+	if res.Error != nil {
+		if res.Error == gorm.ErrRecordNotFound {
+			return nil, errors.NewNotFound()
+		}
+		return nil, res.Error
+	}
+	return &team, nil
+}
+
+func (g *GormTeamRepository) GetTeamByID(ctx context.Context, id string) (*models.Team, error) {
 	var team models.Team
-	res := g.db.Where("name = ?", name).First(&team)
+	res := g.db.WithContext(ctx).Where("id = ?", id).First(&team)
 	if res.Error != nil {
 		if res.Error == gorm.ErrRecordNotFound {
 			return nil, errors.NewNotFound()
@@ -48,18 +62,18 @@ func (g *GormTeamRepository) GetTeamByName(name string) (*models.Team, error) {
 	return &team, nil
 }
 
-func (g *GormTeamRepository) GetTeamUsers(teamID string) ([]models.User, error) {
+func (g *GormTeamRepository) GetTeamUsers(ctx context.Context, teamID string) ([]models.User, error) {
 	var users []models.User
-	res := g.db.Where("team_id = ?", teamID).Find(&users)
+	res := g.db.WithContext(ctx).Where("team_id = ?", teamID).Find(&users)
 	if res.Error != nil {
 		return nil, res.Error
 	}
 	return users, nil
 }
 
-func (g *GormTeamRepository) TeamExists(name string) (bool, error) {
+func (g *GormTeamRepository) TeamExists(ctx context.Context, name string) (bool, error) {
 	var ans int64
-	res := g.db.Model(&models.Team{}).Where("name = ?", name).Count(&ans)
+	res := g.db.WithContext(ctx).Model(&models.Team{}).Where("name = ?", name).Count(&ans)
 	if res.Error != nil {
 		return false, res.Error
 	}
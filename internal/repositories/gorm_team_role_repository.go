@@ -0,0 +1,37 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/errors"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GormTeamRoleRepository struct {
+	db *gorm.DB
+}
+
+func NewGormTeamRoleRepository(db *gorm.DB) TeamRoleRepository {
+	return &GormTeamRoleRepository{db: db}
+}
+
+func (g *GormTeamRoleRepository) SetRole(ctx context.Context, role *models.TeamRole) error {
+	return g.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "team_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"role", "updated_at"}),
+	}).Create(role).Error
+}
+
+func (g *GormTeamRoleRepository) GetRole(ctx context.Context, teamID, userID string) (*models.TeamRole, error) {
+	var role models.TeamRole
+	res := g.db.WithContext(ctx).Where("team_id = ? AND user_id = ?", teamID, userID).First(&role)
+	if res.Error != nil {
+		if res.Error == gorm.ErrRecordNotFound {
+			return nil, errors.NewNotFound()
+		}
+		return nil, res.Error
+	}
+	return &role, nil
+}
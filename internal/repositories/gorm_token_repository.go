@@ -0,0 +1,61 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/errors"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GormTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewGormTokenRepository(db *gorm.DB) TokenRepository {
+	return &GormTokenRepository{db: db}
+}
+
+func (g *GormTokenRepository) CreateToken(ctx context.Context, token *models.APIToken) error {
+	return g.db.WithContext(ctx).Create(token).Error
+}
+
+func (g *GormTokenRepository) SaveToken(ctx context.Context, token *models.APIToken) error {
+	return g.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"name", "token_hash", "scope"}),
+	}).Create(token).Error
+}
+
+func (g *GormTokenRepository) GetTokenByHash(ctx context.Context, hash string) (*models.APIToken, error) {
+	var token models.APIToken
+	res := g.db.WithContext(ctx).Where("token_hash = ?", hash).First(&token)
+	if res.Error != nil {
+		if res.Error == gorm.ErrRecordNotFound {
+			return nil, errors.NewNotFound()
+		}
+		return nil, res.Error
+	}
+	return &token, nil
+}
+
+func (g *GormTokenRepository) RevokeToken(ctx context.Context, id string, at time.Time) (*models.APIToken, error) {
+	db := g.db.WithContext(ctx)
+	err := db.Model(&models.APIToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", at).Error
+	if err != nil {
+		return nil, err
+	}
+	var token models.APIToken
+	res := db.Where("id = ?", id).First(&token)
+	if res.Error != nil {
+		if res.Error == gorm.ErrRecordNotFound {
+			return nil, errors.NewNotFound()
+		}
+		return nil, res.Error
+	}
+	return &token, nil
+}
@@ -0,0 +1,109 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/errors"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GormWebhookRepository struct {
+	db *gorm.DB
+}
+
+func NewGormWebhookRepository(db *gorm.DB) WebhookRepository {
+	return &GormWebhookRepository{db: db}
+}
+
+func (g *GormWebhookRepository) CreateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	return g.db.WithContext(ctx).Create(webhook).Error
+}
+
+func (g *GormWebhookRepository) GetWebhook(ctx context.Context, id string) (*models.Webhook, error) {
+	var webhook models.Webhook
+	res := g.db.WithContext(ctx).Where("id = ?", id).First(&webhook)
+	if res.Error != nil {
+		if res.Error == gorm.ErrRecordNotFound {
+			return nil, errors.NewNotFound()
+		}
+		return nil, res.Error
+	}
+	return &webhook, nil
+}
+
+func (g *GormWebhookRepository) ListWebhooks(ctx context.Context, teamID string) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	err := g.db.WithContext(ctx).Where("team_id = ?", teamID).Order("created_at, id").Find(&webhooks).Error
+	return webhooks, err
+}
+
+func (g *GormWebhookRepository) DeleteWebhook(ctx context.Context, id string) error {
+	return g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("webhook_id = ?", id).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		res := tx.Where("id = ?", id).Delete(&models.Webhook{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errors.NewNotFound()
+		}
+		return nil
+	})
+}
+
+func (g *GormWebhookRepository) CreateDeliveries(ctx context.Context, deliveries []models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	// Повторная публикация события не создаёт вторую доставку
+	return g.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "webhook_id"}, {Name: "event_id"}},
+		DoNothing: true,
+	}).Create(&deliveries).Error
+}
+
+func (g *GormWebhookRepository) ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// SKIP LOCKED: экземпляры сервиса разбирают очередь, не дожидаясь друг друга
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ?", models.DeliveryPending).
+			Where(timeCompareSQL(tx, "next_attempt_at", "<="), now).
+			Order("next_attempt_at").
+			Limit(limit).
+			Find(&deliveries).Error
+		if err != nil || len(deliveries) == 0 {
+			return err
+		}
+		ids := make([]string, len(deliveries))
+		for i := range deliveries {
+			ids[i] = deliveries[i].ID
+		}
+		return tx.Model(&models.WebhookDelivery{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(lease)).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (g *GormWebhookRepository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return g.db.WithContext(ctx).Save(delivery).Error
+}
+
+func (g *GormWebhookRepository) ListDeliveries(ctx context.Context, webhookID string, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := g.db.WithContext(ctx).
+		Where("webhook_id = ?", webhookID).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Find(&deliveries).Error
+	return deliveries, err
+}
@@ -1,25 +1,139 @@
 package repositories
 
-import "github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
+import (
+	"context"
+	"time"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
+)
 
 type TeamRepository interface {
-	CreateTeam(team *models.Team, users []models.User) error
-	GetTeamByName(name string) (*models.Team, error)
-	GetTeamUsers(teamID string) ([]models.User, error)
-	TeamExists(name string) (bool, error)
+	CreateTeam(ctx context.Context, team *models.Team, users []models.User) error
+	GetTeamByName(ctx context.Context, name string) (*models.Team, error)
+	GetTeamByID(ctx context.Context, id string) (*models.Team, error)
+	GetTeamUsers(ctx context.Context, teamID string) ([]models.User, error)
+	TeamExists(ctx context.Context, name string) (bool, error)
 }
 
 type UserRepository interface {
-	GetUserByID(id string) (*models.User, error)
-	UpdateUser(userID string, isActive bool) error
-	GetActiveUsersByTeam(teamID string) ([]models.User, error)
-	GetUserTeam(userID string) (*models.Team, error)
+	GetUserByID(ctx context.Context, id string) (*models.User, error)
+	UpdateUser(ctx context.Context, userID string, isActive bool) error
+	GetActiveUsersByTeam(ctx context.Context, teamID string) ([]models.User, error)
+	GetUserTeam(ctx context.Context, userID string) (*models.Team, error)
 }
 
 type PRRepository interface {
-	PRExists(id string) (bool, error)
-	CreatePR(pr *models.PullRequest) error
-	GetPRByID(id string) (*models.PullRequest, error)
-	UpdatePR(pr *models.PullRequest) error
-	GetPRsByReviewer(userID string) ([]models.PullRequest, error)
+	PRExists(ctx context.Context, id string) (bool, error)
+	CreatePR(ctx context.Context, pr *models.PullRequest) error
+	GetPRByID(ctx context.Context, id string) (*models.PullRequest, error)
+	GetPRByIDForUpdate(ctx context.Context, id string) (*models.PullRequest, error)
+	UpdatePR(ctx context.Context, pr *models.PullRequest) error
+	GetPRsByReviewer(ctx context.Context, userID string) ([]models.PullRequest, error)
+	RecordReassignment(ctx context.Context, reassignment *models.Reassignment) error
+	RecordNoCandidate(ctx context.Context, event *models.NoCandidateEvent) error
+}
+
+type StatsRepository interface {
+	TeamMemberStats(ctx context.Context, teamID string) ([]models.MemberReviewStats, error)
+	TeamPRStats(ctx context.Context, teamID string) (*models.TeamPRStats, error)
+	ReviewsPerUser(ctx context.Context, filter models.StatsFilter) ([]models.UserReviewCount, error)
+	PRsByReviewerCount(ctx context.Context, filter models.StatsFilter) ([]models.ReviewerCountBucket, error)
+	NoCandidateCount(ctx context.Context, filter models.StatsFilter) (int, error)
+	MergesPerDay(ctx context.Context, filter models.StatsFilter) ([]models.DailyMerges, error)
+}
+
+type IdempotencyRepository interface {
+	Reserve(ctx context.Context, key, requestHash string, expiresAt time.Time) (*models.IdempotencyKey, bool, error)
+	Complete(ctx context.Context, key string, statusCode int, headers string, body []byte) error
+	Release(ctx context.Context, key string) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+type TokenRepository interface {
+	CreateToken(ctx context.Context, token *models.APIToken) error
+	// SaveToken создаёт токен или меняет имя, scope и секрет существующего с тем же ID.
+	// Отзыв токена не снимается: revoked_at существующего токена не меняется.
+	SaveToken(ctx context.Context, token *models.APIToken) error
+	GetTokenByHash(ctx context.Context, hash string) (*models.APIToken, error)
+	// RevokeToken отзывает токен и возвращает его; повторный отзыв не меняет время отзыва
+	RevokeToken(ctx context.Context, id string, at time.Time) (*models.APIToken, error)
+}
+
+type TeamRoleRepository interface {
+	// SetRole создаёт или меняет роль пользователя в команде
+	SetRole(ctx context.Context, role *models.TeamRole) error
+	GetRole(ctx context.Context, teamID, userID string) (*models.TeamRole, error)
+}
+
+type WebhookRepository interface {
+	CreateWebhook(ctx context.Context, webhook *models.Webhook) error
+	GetWebhook(ctx context.Context, id string) (*models.Webhook, error)
+	ListWebhooks(ctx context.Context, teamID string) ([]models.Webhook, error)
+	// DeleteWebhook удаляет вебхук вместе с историей его доставок
+	DeleteWebhook(ctx context.Context, id string) error
+	CreateDeliveries(ctx context.Context, deliveries []models.WebhookDelivery) error
+	// ClaimDueDeliveries выбирает до limit ожидающих доставок, которым пора уйти,
+	// и откладывает их на lease, чтобы их не взял другой экземпляр сервиса
+	ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	// ListDeliveries возвращает последние доставки вебхука, новые первыми
+	ListDeliveries(ctx context.Context, webhookID string, limit int) ([]models.WebhookDelivery, error)
+}
+
+type OutboxRepository interface {
+	Append(ctx context.Context, events []models.OutboxEvent) error
+	// Pending возвращает до limit неопубликованных и не проваленных событий в порядке записи
+	Pending(ctx context.Context, limit int) ([]models.OutboxEvent, error)
+	MarkPublished(ctx context.Context, ids []uint, at time.Time) error
+	// RecordFailure увеличивает счётчик попыток события и запоминает ошибку
+	RecordFailure(ctx context.Context, id uint, errText string) error
+	// MarkFailed засчитывает последнюю попытку и выводит событие из очереди
+	MarkFailed(ctx context.Context, id uint, errText string, at time.Time) error
+	// DeletePublished удаляет события, опубликованные раньше before
+	DeletePublished(ctx context.Context, before time.Time) (int64, error)
+}
+
+type EventLogRepository interface {
+	// Append пропускает события, которые уже есть в журнале
+	Append(ctx context.Context, entries []models.EventLogEntry) error
+	// After возвращает до limit записей с ID больше afterID по возрастанию ID;
+	// пустой teamName — записи всех команд
+	After(ctx context.Context, afterID uint, teamName string, limit int) ([]models.EventLogEntry, error)
+	// LastID возвращает ID последней записи или 0, если журнал пуст
+	LastID(ctx context.Context) (uint, error)
+	// DeleteBefore удаляет записи, созданные раньше before
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+type CodeHostRepository interface {
+	// SetAccount создаёт или перепривязывает логин провайдера к пользователю
+	SetAccount(ctx context.Context, account *models.CodeHostAccount) error
+	GetAccount(ctx context.Context, provider, login string) (*models.CodeHostAccount, error)
+	// ListAccounts возвращает привязки провайдера; пустой provider — всех провайдеров
+	ListAccounts(ctx context.Context, provider string) ([]models.CodeHostAccount, error)
+	DeleteAccount(ctx context.Context, provider, login string) error
+	// LinkPullRequest сохраняет связь PR; повторная связь того же PR ничего не меняет
+	LinkPullRequest(ctx context.Context, link *models.CodeHostPullRequest) error
+	GetPullRequestLink(ctx context.Context, prID string) (*models.CodeHostPullRequest, error)
+	// UnlinkPullRequest удаляет связь PR; отсутствие связи не ошибка
+	UnlinkPullRequest(ctx context.Context, prID string) error
+	// GetAccountByUser возвращает логин пользователя у провайдера; при нескольких
+	// привязках — первый по алфавиту
+	GetAccountByUser(ctx context.Context, provider, userID string) (*models.CodeHostAccount, error)
+	CreateSyncs(ctx context.Context, syncs []models.ReviewerSync) error
+	// ClaimDueSyncs выбирает до limit ожидающих задач синхронизации, которым пора
+	// выполниться, и откладывает их на lease, как ClaimDueDeliveries
+	ClaimDueSyncs(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.ReviewerSync, error)
+	UpdateSync(ctx context.Context, sync *models.ReviewerSync) error
+	GetSync(ctx context.Context, id string) (*models.ReviewerSync, error)
+	// ListSyncs возвращает последние задачи, новые первыми; пустой status — все
+	ListSyncs(ctx context.Context, status string, limit int) ([]models.ReviewerSync, error)
+}
+
+type LeaseRepository interface {
+	// TryAcquire берёт или продлевает аренду name для owner до now+ttl.
+	// Возвращает false, если аренда действует и принадлежит другому владельцу.
+	TryAcquire(ctx context.Context, name, owner string, now time.Time, ttl time.Duration) (bool, error)
+	// Release снимает аренду, если она принадлежит owner
+	Release(ctx context.Context, name, owner string) error
 }
@@ -1,11 +1,28 @@
 package repositories
 
-import "gorm.io/gorm"
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
 
 type Repository struct {
 	Team TeamRepository
 	User UserRepository
 	PR   PRRepository
+
+	Stats       StatsRepository
+	Idempotency IdempotencyRepository
+	Tokens      TokenRepository
+	Roles       TeamRoleRepository
+	Webhooks    WebhookRepository
+	Outbox      OutboxRepository
+	Leases      LeaseRepository
+	EventLog    EventLogRepository
+	CodeHost    CodeHostRepository
+
+	db       *gorm.DB
+	readOnly *Repository
 }
 
 func NewRepository(db *gorm.DB) *Repository {
@@ -13,5 +30,42 @@ func NewRepository(db *gorm.DB) *Repository {
 		Team: NewGormTeamRepository(db),
 		User: NewGormUserRepository(db),
 		PR:   NewGormPRRepository(db),
+
+		Stats:       NewGormStatsRepository(db),
+		Idempotency: NewGormIdempotencyRepository(db),
+		Tokens:      NewGormTokenRepository(db),
+		Roles:       NewGormTeamRoleRepository(db),
+		Webhooks:    NewGormWebhookRepository(db),
+		Outbox:      NewGormOutboxRepository(db),
+		Leases:      NewGormLeaseRepository(db),
+		EventLog:    NewGormEventLogRepository(db),
+		CodeHost:    NewGormCodeHostRepository(db),
+
+		db: db,
+	}
+}
+
+// WithReadReplica возвращает копию репозитория, у которой ReadOnly()
+// читает из реплики. Запись и транзакции по-прежнему идут в основную базу.
+func (r *Repository) WithReadReplica(replica *gorm.DB) *Repository {
+	copied := *r
+	copied.readOnly = NewRepository(replica)
+	return &copied
+}
+
+// ReadOnly возвращает репозиторий для запросов, которым допустимо чуть
+// отстающее чтение. Без реплики это сам r.
+func (r *Repository) ReadOnly() *Repository {
+	if r.readOnly != nil {
+		return r.readOnly
 	}
+	return r
+}
+
+// WithTx выполняет fn в одной транзакции. Все вызовы репозиториев внутри fn
+// должны идти через переданный r: при ошибке изменения откатываются.
+func (r *Repository) WithTx(ctx context.Context, fn func(r *Repository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(NewRepository(tx))
+	})
 }
@@ -0,0 +1,117 @@
+package repotest
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Postgres — тестовый сервер Postgres. Каждый тест получает в нём свою базу.
+type Postgres struct {
+	adminDSN string
+	admin    *gorm.DB
+	seq      atomic.Int64
+}
+
+// StartPostgres подключается к серверу из TEST_POSTGRES_DSN (DSN в формате
+// key=value), а если переменная не задана — поднимает временный сервер из
+// локально установленных initdb и pg_ctl. Если ни то, ни другое недоступно,
+// тест пропускается с предупреждением, а при REQUIRE_POSTGRES=1 падает.
+func StartPostgres(t *testing.T) *Postgres {
+	t.Helper()
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		dsn = startLocalPostgres(t)
+	}
+	admin, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("connect to postgres: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := admin.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+	})
+	return &Postgres{adminDSN: dsn, admin: admin}
+}
+
+// NewDatabase создаёт пустую базу, удаляемую по завершении теста, и возвращает DSN для неё.
+func (p *Postgres) NewDatabase(t *testing.T) string {
+	t.Helper()
+	name := fmt.Sprintf("conformance_%d_%d", os.Getpid(), p.seq.Add(1))
+	if err := p.admin.Exec("CREATE DATABASE " + name).Error; err != nil {
+		t.Fatalf("create database %s: %v", name, err)
+	}
+	// Cleanup выполняется в обратном порядке, поэтому база удаляется
+	// после того, как тест закроет свои подключения
+	t.Cleanup(func() {
+		if err := p.admin.Exec("DROP DATABASE IF EXISTS " + name + " WITH (FORCE)").Error; err != nil {
+			t.Logf("drop database %s: %v", name, err)
+		}
+	})
+	return p.adminDSN + " dbname=" + name
+}
+
+func startLocalPostgres(t *testing.T) string {
+	t.Helper()
+	initdb, err := exec.LookPath("initdb")
+	if err != nil {
+		unavailable(t, "TEST_POSTGRES_DSN is not set and initdb is not in PATH")
+	}
+	pgCtl, err := exec.LookPath("pg_ctl")
+	if err != nil {
+		unavailable(t, "TEST_POSTGRES_DSN is not set and pg_ctl is not in PATH")
+	}
+
+	dir := t.TempDir()
+	dataDir := filepath.Join(dir, "data")
+	port, err := freePort()
+	if err != nil {
+		t.Fatalf("find free port: %v", err)
+	}
+
+	run(t, initdb, "-D", dataDir, "-U", "postgres", "--auth=trust", "--no-sync")
+	run(t, pgCtl, "-D", dataDir, "-l", filepath.Join(dir, "postgres.log"), "-w",
+		"-o", fmt.Sprintf("-p %d -k %s -c listen_addresses=''", port, dir), "start")
+	t.Cleanup(func() {
+		_ = exec.Command(pgCtl, "-D", dataDir, "-m", "immediate", "stop").Run()
+	})
+
+	return fmt.Sprintf("host=%s port=%d user=postgres dbname=postgres sslmode=disable", dir, port)
+}
+
+// unavailable пропускает тест без Postgres. Пропуск легко не заметить в выводе
+// go test, поэтому он дублируется в stderr, а make test-postgres (REQUIRE_POSTGRES=1)
+// в этом случае падает.
+func unavailable(t *testing.T, reason string) {
+	t.Helper()
+	if os.Getenv("REQUIRE_POSTGRES") == "1" {
+		t.Fatalf("postgres is required (REQUIRE_POSTGRES=1): %s", reason)
+	}
+	fmt.Fprintf(os.Stderr, "WARNING: %s: postgres conformance suite skipped (%s)\n", t.Name(), reason)
+	t.Skipf("postgres is unavailable: %s; set TEST_POSTGRES_DSN or install postgres", reason)
+}
+
+func run(t *testing.T, name string, args ...string) {
+	t.Helper()
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("%s: %v\n%s", filepath.Base(name), err, out)
+	}
+}
+
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
@@ -0,0 +1,832 @@
+// Package repotest содержит общий набор тестов, которому должна
+// соответствовать любая реализация репозиториев.
+package repotest
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+	"time"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/errors"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/repositories"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Factory возвращает репозиторий поверх новой пустой базы.
+type Factory func(t *testing.T) *repositories.Repository
+
+// Run прогоняет набор тестов на соответствие против репозиториев из newRepo.
+func Run(t *testing.T, newRepo Factory) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, repo *repositories.Repository)
+	}{
+		{"NotFound", testNotFound},
+		{"TeamExists", testTeamExists},
+		{"CreateTeamRollback", testCreateTeamRollback},
+		{"TeamUsers", testTeamUsers},
+		{"UpdateUser", testUpdateUser},
+		{"PRExists", testPRExists},
+		{"UpdatePR", testUpdatePR},
+		{"UpdatePRConflict", testUpdatePRConflict},
+		{"PRsByReviewer", testPRsByReviewer},
+		{"WithTx", testWithTx},
+		{"CanceledContext", testCanceledContext},
+		{"Idempotency", testIdempotency},
+		{"Tokens", testTokens},
+		{"TeamRoles", testTeamRoles},
+		{"Webhooks", testWebhooks},
+		{"Outbox", testOutbox},
+		{"Leases", testLeases},
+		{"EventLog", testEventLog},
+		{"CodeHost", testCodeHost},
+		{"TeamStats", testTeamStats},
+		{"AssignmentStats", testAssignmentStats},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newRepo(t))
+		})
+	}
+}
+
+func createTeam(t *testing.T, repo *repositories.Repository, id, name string, userIDs ...string) {
+	t.Helper()
+	ctx := context.Background()
+	users := make([]models.User, len(userIDs))
+	for i, userID := range userIDs {
+		users[i] = models.User{ID: userID, Username: "user " + userID, IsActive: true}
+	}
+	require.NoError(t, repo.Team.CreateTeam(ctx, &models.Team{ID: id, Name: name}, users))
+}
+
+func createPR(t *testing.T, repo *repositories.Repository, id, authorID string, reviewers ...string) {
+	t.Helper()
+	ctx := context.Background()
+	require.NoError(t, repo.PR.CreatePR(ctx, &models.PullRequest{
+		ID:        id,
+		Title:     "title " + id,
+		AuthorID:  authorID,
+		Status:    "OPEN",
+		Reviewers: models.StringArray(reviewers),
+	}))
+}
+
+func testNotFound(t *testing.T, repo *repositories.Repository) {
+	ctx := context.Background()
+	_, err := repo.Team.GetTeamByName(ctx, "missing")
+	assert.True(t, errors.IsNotFound(err), "GetTeamByName: %v", err)
+
+	_, err = repo.Team.GetTeamByID(ctx, "missing")
+	assert.True(t, errors.IsNotFound(err), "GetTeamByID: %v", err)
+
+	_, err = repo.User.GetUserByID(ctx, "missing")
+	assert.True(t, errors.IsNotFound(err), "GetUserByID: %v", err)
+
+	_, err = repo.User.GetUserTeam(ctx, "missing")
+	assert.True(t, errors.IsNotFound(err), "GetUserTeam: %v", err)
+
+	_, err = repo.PR.GetPRByID(ctx, "missing")
+	assert.True(t, errors.IsNotFound(err), "GetPRByID: %v", err)
+}
+
+func testTeamExists(t *testing.T, repo *repositories.Repository) {
+	ctx := context.Background()
+	exists, err := repo.Team.TeamExists(ctx, "backend")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	createTeam(t, repo, "t1", "backend", "u1")
+
+	exists, err = repo.Team.TeamExists(ctx, "backend")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	err = repo.Team.CreateTeam(ctx, &models.Team{ID: "t2", Name: "backend"}, nil)
+	assert.True(t, errors.IsTeamExists(err), "duplicate team: %v", err)
+}
+
+func testCreateTeamRollback(t *testing.T, repo *repositories.Repository) {
+	ctx := context.Background()
+	// Второй участник с тем же ID нарушает первичный ключ посреди транзакции
+	users := []models.User{
+		{ID: "u1", Username: "Alice", IsActive: true},
+		{ID: "u1", Username: "Alice again", IsActive: true},
+	}
+	err := repo.Team.CreateTeam(ctx, &models.Team{ID: "t1", Name: "backend"}, users)
+	require.Error(t, err)
+
+	exists, err := repo.Team.TeamExists(ctx, "backend")
+	require.NoError(t, err)
+	assert.False(t, exists, "team must be rolled back")
+
+	_, err = repo.User.GetUserByID(ctx, "u1")
+	assert.True(t, errors.IsNotFound(err), "user must be rolled back: %v", err)
+}
+
+func testTeamUsers(t *testing.T, repo *repositories.Repository) {
+	ctx := context.Background()
+	createTeam(t, repo, "t1", "backend", "u1", "u2")
+	createTeam(t, repo, "t2", "frontend", "u3")
+
+	team, err := repo.Team.GetTeamByName(ctx, "backend")
+	require.NoError(t, err)
+	assert.Equal(t, "t1", team.ID)
+
+	users, err := repo.Team.GetTeamUsers(ctx, team.ID)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"u1", "u2"}, userIDs(users))
+
+	userTeam, err := repo.User.GetUserTeam(ctx, "u3")
+	require.NoError(t, err)
+	assert.Equal(t, "frontend", userTeam.Name)
+}
+
+func testUpdateUser(t *testing.T, repo *repositories.Repository) {
+	ctx := context.Background()
+	createTeam(t, repo, "t1", "backend", "u1", "u2", "u3")
+
+	require.NoError(t, repo.User.UpdateUser(ctx, "u2", false))
+
+	user, err := repo.User.GetUserByID(ctx, "u2")
+	require.NoError(t, err)
+	assert.False(t, user.IsActive)
+
+	active, err := repo.User.GetActiveUsersByTeam(ctx, "t1")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"u1", "u3"}, userIDs(active))
+}
+
+func testPRExists(t *testing.T, repo *repositories.Repository) {
+	ctx := context.Background()
+	createTeam(t, repo, "t1", "backend", "u1", "u2")
+
+	exists, err := repo.PR.PRExists(ctx, "pr-1")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	createPR(t, repo, "pr-1", "u1", "u2")
+
+	exists, err = repo.PR.PRExists(ctx, "pr-1")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	err = repo.PR.CreatePR(ctx, &models.PullRequest{ID: "pr-1", Title: "again", AuthorID: "u1"})
+	assert.True(t, errors.IsPRExists(err), "duplicate PR: %v", err)
+}
+
+func testUpdatePR(t *testing.T, repo *repositories.Repository) {
+	ctx := context.Background()
+	createTeam(t, repo, "t1", "backend", "u1", "u2", "u3")
+	createPR(t, repo, "pr-1", "u1", "u2")
+
+	pr, err := repo.PR.GetPRByID(ctx, "pr-1")
+	require.NoError(t, err)
+	assert.Equal(t, models.StringArray{"u2"}, pr.Reviewers)
+
+	pr.Reviewers = models.StringArray{"u3"}
+	pr.Status = "MERGED"
+	require.NoError(t, repo.PR.UpdatePR(ctx, pr))
+
+	pr, err = repo.PR.GetPRByID(ctx, "pr-1")
+	require.NoError(t, err)
+	assert.Equal(t, models.StringArray{"u3"}, pr.Reviewers)
+	assert.Equal(t, "MERGED", pr.Status)
+	assert.Equal(t, 2, pr.Version)
+}
+
+func testUpdatePRConflict(t *testing.T, repo *repositories.Repository) {
+	ctx := context.Background()
+	createTeam(t, repo, "t1", "backend", "u1", "u2", "u3")
+	createPR(t, repo, "pr-1", "u1", "u2")
+
+	first, err := repo.PR.GetPRByID(ctx, "pr-1")
+	require.NoError(t, err)
+	second, err := repo.PR.GetPRByID(ctx, "pr-1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, first.Version)
+
+	first.Status = "MERGED"
+	require.NoError(t, repo.PR.UpdatePR(ctx, first))
+	assert.Equal(t, 2, first.Version)
+
+	// Вторая копия прочитана до изменения и не должна его затереть
+	second.Reviewers = models.StringArray{"u3"}
+	err = repo.PR.UpdatePR(ctx, second)
+	assert.True(t, errors.IsConflict(err), "stale update: %v", err)
+
+	pr, err := repo.PR.GetPRByID(ctx, "pr-1")
+	require.NoError(t, err)
+	assert.Equal(t, "MERGED", pr.Status)
+	assert.Equal(t, models.StringArray{"u2"}, pr.Reviewers)
+}
+
+func testPRsByReviewer(t *testing.T, repo *repositories.Repository) {
+	ctx := context.Background()
+	createTeam(t, repo, "t1", "backend", "u1", "u2", "u3", "u10")
+	createPR(t, repo, "pr-1", "u1", "u2", "u3")
+	createPR(t, repo, "pr-2", "u1", "u3")
+	createPR(t, repo, "pr-3", "u2", "u10")
+	createPR(t, repo, "pr-4", "u3")
+
+	prs, err := repo.PR.GetPRsByReviewer(ctx, "u3")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"pr-1", "pr-2"}, prIDs(prs))
+
+	prs, err = repo.PR.GetPRsByReviewer(ctx, "u2")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"pr-1"}, prIDs(prs))
+
+	// u1 не должен совпадать с u10 как подстрока
+	prs, err = repo.PR.GetPRsByReviewer(ctx, "u1")
+	require.NoError(t, err)
+	assert.Empty(t, prs)
+}
+
+func testWithTx(t *testing.T, repo *repositories.Repository) {
+	ctx := context.Background()
+	createTeam(t, repo, "t1", "backend", "u1", "u2")
+	createPR(t, repo, "pr-1", "u1", "u2")
+
+	errAbort := stderrors.New("abort")
+	err := repo.WithTx(ctx, func(r *repositories.Repository) error {
+		pr, err := r.PR.GetPRByIDForUpdate(ctx, "pr-1")
+		require.NoError(t, err)
+		pr.Status = "MERGED"
+		require.NoError(t, r.PR.UpdatePR(ctx, pr))
+		require.NoError(t, r.User.UpdateUser(ctx, "u2", false))
+		return errAbort
+	})
+	assert.ErrorIs(t, err, errAbort)
+
+	pr, err := repo.PR.GetPRByID(ctx, "pr-1")
+	require.NoError(t, err)
+	assert.Equal(t, "OPEN", pr.Status, "PR update must be rolled back")
+	user, err := repo.User.GetUserByID(ctx, "u2")
+	require.NoError(t, err)
+	assert.True(t, user.IsActive, "user update must be rolled back")
+
+	err = repo.WithTx(ctx, func(r *repositories.Repository) error {
+		_, err := r.PR.GetPRByIDForUpdate(ctx, "missing")
+		assert.True(t, errors.IsNotFound(err), "GetPRByIDForUpdate: %v", err)
+
+		pr, err := r.PR.GetPRByIDForUpdate(ctx, "pr-1")
+		if err != nil {
+			return err
+		}
+		pr.Status = "MERGED"
+		return r.PR.UpdatePR(ctx, pr)
+	})
+	require.NoError(t, err)
+
+	pr, err = repo.PR.GetPRByID(ctx, "pr-1")
+	require.NoError(t, err)
+	assert.Equal(t, "MERGED", pr.Status)
+}
+
+func testCanceledContext(t *testing.T, repo *repositories.Repository) {
+	createTeam(t, repo, "t1", "backend", "u1")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := repo.User.GetUserByID(ctx, "u1")
+	assert.ErrorIs(t, err, context.Canceled)
+
+	err = repo.WithTx(ctx, func(r *repositories.Repository) error {
+		return r.User.UpdateUser(ctx, "u1", false)
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func testIdempotency(t *testing.T, repo *repositories.Repository) {
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour)
+
+	record, reserved, err := repo.Idempotency.Reserve(ctx, "key-1", "hash-1", expiresAt)
+	require.NoError(t, err)
+	assert.True(t, reserved)
+	assert.Equal(t, 0, record.StatusCode)
+
+	record, reserved, err = repo.Idempotency.Reserve(ctx, "key-1", "hash-2", expiresAt)
+	require.NoError(t, err)
+	assert.False(t, reserved)
+	assert.Equal(t, "hash-1", record.RequestHash)
+
+	require.NoError(t, repo.Idempotency.Complete(ctx, "key-1", 201, `{"ETag":"\"1\""}`, []byte(`{"ok":true}`)))
+	record, reserved, err = repo.Idempotency.Reserve(ctx, "key-1", "hash-1", expiresAt)
+	require.NoError(t, err)
+	assert.False(t, reserved)
+	assert.Equal(t, 201, record.StatusCode)
+	assert.Equal(t, `{"ok":true}`, string(record.Body))
+
+	require.NoError(t, repo.Idempotency.Release(ctx, "key-1"))
+	_, reserved, err = repo.Idempotency.Reserve(ctx, "key-1", "hash-2", expiresAt)
+	require.NoError(t, err)
+	assert.True(t, reserved, "released key must be reusable")
+
+	// Истёкший ключ занимается заново
+	_, _, err = repo.Idempotency.Reserve(ctx, "key-2", "hash-1", time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	_, reserved, err = repo.Idempotency.Reserve(ctx, "key-2", "hash-2", expiresAt)
+	require.NoError(t, err)
+	assert.True(t, reserved, "expired key must be reusable")
+
+	_, _, err = repo.Idempotency.Reserve(ctx, "key-3", "hash-1", time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	deleted, err := repo.Idempotency.DeleteExpired(ctx, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+}
+
+func testTokens(t *testing.T, repo *repositories.Repository) {
+	ctx := context.Background()
+	userID := "u1"
+	token := &models.APIToken{ID: "tok-1", Name: "ci", TokenHash: "hash-1", Scope: "user", UserID: &userID}
+	require.NoError(t, repo.Tokens.CreateToken(ctx, token))
+
+	// Хэш уникален
+	err := repo.Tokens.CreateToken(ctx, &models.APIToken{ID: "tok-2", Name: "dup", TokenHash: "hash-1", Scope: "admin"})
+	assert.Error(t, err)
+
+	found, err := repo.Tokens.GetTokenByHash(ctx, "hash-1")
+	require.NoError(t, err)
+	assert.Equal(t, "tok-1", found.ID)
+	require.NotNil(t, found.UserID)
+	assert.Equal(t, "u1", *found.UserID)
+	assert.Nil(t, found.RevokedAt)
+
+	_, err = repo.Tokens.GetTokenByHash(ctx, "missing")
+	assert.True(t, errors.IsNotFound(err))
+
+	revokedAt := time.Now().UTC().Truncate(time.Second)
+	revoked, err := repo.Tokens.RevokeToken(ctx, "tok-1", revokedAt)
+	require.NoError(t, err)
+	require.NotNil(t, revoked.RevokedAt)
+	assert.True(t, revoked.RevokedAt.Equal(revokedAt))
+
+	// Повторный отзыв не сдвигает время
+	revoked, err = repo.Tokens.RevokeToken(ctx, "tok-1", revokedAt.Add(time.Hour))
+	require.NoError(t, err)
+	assert.True(t, revoked.RevokedAt.Equal(revokedAt))
+
+	_, err = repo.Tokens.RevokeToken(ctx, "missing", revokedAt)
+	assert.True(t, errors.IsNotFound(err))
+
+	// SaveToken меняет секрет токена с тем же ID, но не снимает отзыв
+	token.TokenHash = "hash-2"
+	token.RevokedAt = nil
+	require.NoError(t, repo.Tokens.SaveToken(ctx, token))
+	found, err = repo.Tokens.GetTokenByHash(ctx, "hash-2")
+	require.NoError(t, err)
+	require.NotNil(t, found.RevokedAt)
+	assert.True(t, found.RevokedAt.Equal(revokedAt))
+	_, err = repo.Tokens.GetTokenByHash(ctx, "hash-1")
+	assert.True(t, errors.IsNotFound(err))
+}
+
+func testTeamStats(t *testing.T, repo *repositories.Repository) {
+	ctx := context.Background()
+	createTeam(t, repo, "t1", "backend", "u1", "u2", "u3")
+	createTeam(t, repo, "t2", "frontend", "f1")
+
+	createdAt := time.Date(2025, 10, 1, 10, 0, 0, 0, time.UTC)
+	mergedAt := createdAt.Add(2 * time.Hour)
+	require.NoError(t, repo.PR.CreatePR(ctx, &models.PullRequest{
+		ID: "pr-1", Title: "merged", AuthorID: "u1", Status: "MERGED",
+		Reviewers: models.StringArray{"u2", "u3"}, CreatedAt: createdAt, MergedAt: &mergedAt,
+	}))
+	createPR(t, repo, "pr-2", "u1", "u2")
+	createPR(t, repo, "pr-3", "f1")
+	require.NoError(t, repo.PR.RecordReassignment(ctx, &models.Reassignment{
+		PullRequestID: "pr-2", OldReviewerID: "u3", NewReviewerID: "u2",
+	}))
+
+	members, err := repo.Stats.TeamMemberStats(ctx, "t1")
+	require.NoError(t, err)
+	require.Len(t, members, 3)
+
+	byID := make(map[string]models.MemberReviewStats)
+	for _, member := range members {
+		byID[member.UserID] = member
+	}
+	assert.Equal(t, 0, byID["u1"].AssignedReviews)
+	assert.Nil(t, byID["u1"].AvgTimeToMergeSeconds)
+
+	assert.Equal(t, 2, byID["u2"].AssignedReviews)
+	assert.Equal(t, 1, byID["u2"].OpenReviews)
+	assert.Equal(t, 1, byID["u2"].MergedReviews)
+	assert.Equal(t, 1, byID["u2"].ReassignedTo)
+	require.NotNil(t, byID["u2"].AvgTimeToMergeSeconds)
+	assert.InDelta(t, 7200, *byID["u2"].AvgTimeToMergeSeconds, 1)
+
+	assert.Equal(t, 1, byID["u3"].AssignedReviews)
+	assert.Equal(t, 1, byID["u3"].ReassignedFrom)
+
+	prs, err := repo.Stats.TeamPRStats(ctx, "t1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, prs.Authored)
+	assert.Equal(t, 1, prs.Open)
+	assert.Equal(t, 1, prs.Merged)
+	require.NotNil(t, prs.AvgTimeToMergeSeconds)
+	assert.InDelta(t, 7200, *prs.AvgTimeToMergeSeconds, 1)
+}
+
+func testAssignmentStats(t *testing.T, repo *repositories.Repository) {
+	ctx := context.Background()
+	createTeam(t, repo, "t1", "backend", "u1", "u2", "u3")
+	createTeam(t, repo, "t2", "frontend", "f1", "f2")
+
+	day1 := time.Date(2025, 10, 1, 10, 0, 0, 0, time.UTC)
+	day2 := time.Date(2025, 10, 2, 23, 30, 0, 0, time.UTC)
+	for _, pr := range []models.PullRequest{
+		{ID: "pr-1", AuthorID: "u1", Reviewers: models.StringArray{"u2", "u3"}, CreatedAt: day1, MergedAt: &day1},
+		{ID: "pr-2", AuthorID: "u1", Reviewers: models.StringArray{"u2"}, CreatedAt: day1, MergedAt: &day2},
+		{ID: "pr-3", AuthorID: "u2", Reviewers: models.StringArray{}, CreatedAt: day2, MergedAt: &day2},
+		{ID: "pr-4", AuthorID: "f1", Reviewers: models.StringArray{"f2"}, CreatedAt: day2},
+	} {
+		pr.Title = "title " + pr.ID
+		pr.Status = "OPEN"
+		if pr.MergedAt != nil {
+			pr.Status = "MERGED"
+		}
+		require.NoError(t, repo.PR.CreatePR(ctx, &pr))
+	}
+	require.NoError(t, repo.PR.RecordNoCandidate(ctx, &models.NoCandidateEvent{PullRequestID: "pr-1", ReviewerID: "u3"}))
+	require.NoError(t, repo.PR.RecordNoCandidate(ctx, &models.NoCandidateEvent{PullRequestID: "pr-4", ReviewerID: "f2"}))
+
+	all := models.StatsFilter{}
+	perUser, err := repo.Stats.ReviewsPerUser(ctx, all)
+	require.NoError(t, err)
+	require.Len(t, perUser, 3)
+	assert.Equal(t, models.UserReviewCount{UserID: "u2", Username: "user u2", TeamName: "backend", AssignedReviews: 2}, perUser[0])
+
+	buckets, err := repo.Stats.PRsByReviewerCount(ctx, all)
+	require.NoError(t, err)
+	assert.Equal(t, []models.ReviewerCountBucket{{Reviewers: 0, PRs: 1}, {Reviewers: 1, PRs: 2}, {Reviewers: 2, PRs: 1}}, buckets)
+
+	noCandidate, err := repo.Stats.NoCandidateCount(ctx, all)
+	require.NoError(t, err)
+	assert.Equal(t, 2, noCandidate)
+
+	merges, err := repo.Stats.MergesPerDay(ctx, all)
+	require.NoError(t, err)
+	assert.Equal(t, []models.DailyMerges{{Day: "2025-10-01", Merged: 1}, {Day: "2025-10-02", Merged: 2}}, merges)
+
+	// Фильтр по команде и по времени
+	from := time.Date(2025, 10, 2, 0, 0, 0, 0, time.UTC)
+	filtered := models.StatsFilter{From: &from, TeamID: "t1"}
+
+	buckets, err = repo.Stats.PRsByReviewerCount(ctx, filtered)
+	require.NoError(t, err)
+	assert.Equal(t, []models.ReviewerCountBucket{{Reviewers: 0, PRs: 1}}, buckets)
+
+	merges, err = repo.Stats.MergesPerDay(ctx, filtered)
+	require.NoError(t, err)
+	assert.Equal(t, []models.DailyMerges{{Day: "2025-10-02", Merged: 2}}, merges)
+
+	noCandidate, err = repo.Stats.NoCandidateCount(ctx, models.StatsFilter{TeamID: "t2"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, noCandidate)
+
+	// Верхняя граница не включается: остаются только PR, созданные в первый день
+	to := from
+	perUser, err = repo.Stats.ReviewsPerUser(ctx, models.StatsFilter{To: &to, TeamID: "t1"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"u2", "u3"}, []string{perUser[0].UserID, perUser[1].UserID})
+	assert.Equal(t, []int{2, 1}, []int{perUser[0].AssignedReviews, perUser[1].AssignedReviews})
+
+	buckets, err = repo.Stats.PRsByReviewerCount(ctx, models.StatsFilter{From: &day1, To: &day2})
+	require.NoError(t, err)
+	assert.Equal(t, []models.ReviewerCountBucket{{Reviewers: 1, PRs: 1}, {Reviewers: 2, PRs: 1}}, buckets)
+
+	// День считается в UTC, даже если время сохранено с другим часовым поясом
+	msk := time.FixedZone("MSK", 3*60*60)
+	mergedLocal := time.Date(2025, 10, 3, 1, 30, 0, 0, msk)
+	require.NoError(t, repo.PR.CreatePR(ctx, &models.PullRequest{
+		ID: "pr-5", Title: "title pr-5", AuthorID: "f1", Status: "MERGED",
+		Reviewers: models.StringArray{"f2"}, CreatedAt: day2, MergedAt: &mergedLocal,
+	}))
+	merges, err = repo.Stats.MergesPerDay(ctx, models.StatsFilter{TeamID: "t2"})
+	require.NoError(t, err)
+	assert.Equal(t, []models.DailyMerges{{Day: "2025-10-02", Merged: 1}}, merges)
+}
+
+func userIDs(users []models.User) []string {
+	ids := make([]string, len(users))
+	for i, user := range users {
+		ids[i] = user.ID
+	}
+	return ids
+}
+
+func prIDs(prs []models.PullRequest) []string {
+	ids := make([]string, len(prs))
+	for i, pr := range prs {
+		ids[i] = pr.ID
+	}
+	return ids
+}
+
+func testTeamRoles(t *testing.T, repo *repositories.Repository) {
+	ctx := context.Background()
+	createTeam(t, repo, "t1", "backend", "u1", "u2")
+
+	_, err := repo.Roles.GetRole(ctx, "t1", "u1")
+	assert.True(t, errors.IsNotFound(err))
+
+	require.NoError(t, repo.Roles.SetRole(ctx, &models.TeamRole{TeamID: "t1", UserID: "u1", Role: "member"}))
+	// Повторная запись меняет роль, а не создаёт дубликат
+	require.NoError(t, repo.Roles.SetRole(ctx, &models.TeamRole{TeamID: "t1", UserID: "u1", Role: "lead"}))
+
+	role, err := repo.Roles.GetRole(ctx, "t1", "u1")
+	require.NoError(t, err)
+	assert.Equal(t, "lead", role.Role)
+
+	_, err = repo.Roles.GetRole(ctx, "t1", "u2")
+	assert.True(t, errors.IsNotFound(err))
+}
+
+func testWebhooks(t *testing.T, repo *repositories.Repository) {
+	ctx := context.Background()
+	createTeam(t, repo, "t1", "backend", "u1")
+	hook := &models.Webhook{
+		ID: "wh-1", TeamID: "t1", URL: "https://example.com/hook", Secret: "whsec_1",
+		EventTypes: models.StringArray{"pr.created", "pr.merged"},
+	}
+	require.NoError(t, repo.Webhooks.CreateWebhook(ctx, hook))
+
+	found, err := repo.Webhooks.GetWebhook(ctx, "wh-1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pr.created", "pr.merged"}, []string(found.EventTypes))
+	hooks, err := repo.Webhooks.ListWebhooks(ctx, "t1")
+	require.NoError(t, err)
+	assert.Len(t, hooks, 1)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	require.NoError(t, repo.Webhooks.CreateDeliveries(ctx, []models.WebhookDelivery{
+		{ID: "d-1", WebhookID: "wh-1", EventID: "e-1", EventType: "pr.created", Payload: "{}",
+			Status: models.DeliveryPending, NextAttemptAt: now.Add(-time.Minute)},
+		{ID: "d-2", WebhookID: "wh-1", EventID: "e-2", EventType: "pr.merged", Payload: "{}",
+			Status: models.DeliveryPending, NextAttemptAt: now.Add(time.Hour)},
+		{ID: "d-3", WebhookID: "wh-1", EventID: "e-3", EventType: "pr.merged", Payload: "{}",
+			Status: models.DeliveryDelivered, NextAttemptAt: now.Add(-time.Minute)},
+	}))
+	// Повторная публикация того же события в тот же вебхук не создаёт доставку
+	require.NoError(t, repo.Webhooks.CreateDeliveries(ctx, []models.WebhookDelivery{
+		{ID: "d-4", WebhookID: "wh-1", EventID: "e-1", EventType: "pr.created", Payload: "{}",
+			Status: models.DeliveryPending, NextAttemptAt: now.Add(-time.Minute)},
+	}))
+
+	// Забирается только ожидающая доставка, срок которой подошёл
+	claimed, err := repo.Webhooks.ClaimDueDeliveries(ctx, now, time.Minute, 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	assert.Equal(t, "d-1", claimed[0].ID)
+	// До истечения аренды её не заберут повторно
+	claimed, err = repo.Webhooks.ClaimDueDeliveries(ctx, now, time.Minute, 10)
+	require.NoError(t, err)
+	assert.Empty(t, claimed)
+	claimed, err = repo.Webhooks.ClaimDueDeliveries(ctx, now.Add(2*time.Minute), time.Minute, 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+
+	delivery := claimed[0]
+	delivery.Status = models.DeliveryDelivered
+	delivery.Attempts = 1
+	delivery.LastStatusCode = 204
+	delivery.DeliveredAt = &now
+	require.NoError(t, repo.Webhooks.UpdateDelivery(ctx, &delivery))
+
+	deliveries, err := repo.Webhooks.ListDeliveries(ctx, "wh-1", 2)
+	require.NoError(t, err)
+	assert.Len(t, deliveries, 2)
+	deliveries, err = repo.Webhooks.ListDeliveries(ctx, "wh-1", 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 3)
+	for _, d := range deliveries {
+		if d.ID == "d-1" {
+			assert.Equal(t, models.DeliveryDelivered, d.Status)
+			assert.Equal(t, 204, d.LastStatusCode)
+		}
+	}
+
+	require.NoError(t, repo.Webhooks.DeleteWebhook(ctx, "wh-1"))
+	_, err = repo.Webhooks.GetWebhook(ctx, "wh-1")
+	assert.True(t, errors.IsNotFound(err))
+	deliveries, err = repo.Webhooks.ListDeliveries(ctx, "wh-1", 10)
+	require.NoError(t, err)
+	assert.Empty(t, deliveries)
+	assert.True(t, errors.IsNotFound(repo.Webhooks.DeleteWebhook(ctx, "wh-1")))
+}
+
+func testOutbox(t *testing.T, repo *repositories.Repository) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+	require.NoError(t, repo.Outbox.Append(ctx, []models.OutboxEvent{
+		{EventID: "e-1", EventType: "pr.created", Key: "pr-1", Payload: "{}"},
+		{EventID: "e-2", EventType: "pr.merged", Key: "pr-1", Payload: "{}"},
+		{EventID: "e-3", EventType: "pr.created", Key: "pr-2", Payload: "{}"},
+	}))
+	// Событие с тем же ID уже записано
+	assert.Error(t, repo.Outbox.Append(ctx, []models.OutboxEvent{
+		{EventID: "e-1", EventType: "pr.created", Key: "pr-1", Payload: "{}"},
+	}))
+
+	pending, err := repo.Outbox.Pending(ctx, 2)
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	assert.Equal(t, "e-1", pending[0].EventID)
+	assert.Equal(t, "e-2", pending[1].EventID)
+
+	require.NoError(t, repo.Outbox.RecordFailure(ctx, pending[1].ID, "boom"))
+	require.NoError(t, repo.Outbox.MarkPublished(ctx, []uint{pending[0].ID}, now.Add(-time.Hour)))
+	pending, err = repo.Outbox.Pending(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	assert.Equal(t, "e-2", pending[0].EventID)
+	assert.Equal(t, 1, pending[0].Attempts)
+	assert.Equal(t, "boom", pending[0].LastError)
+
+	// Проваленное событие уходит из очереди и не удаляется вместе с опубликованными
+	require.NoError(t, repo.Outbox.MarkFailed(ctx, pending[0].ID, "poison", now))
+	pending, err = repo.Outbox.Pending(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, "e-3", pending[0].EventID)
+
+	require.NoError(t, repo.Outbox.MarkPublished(ctx, []uint{pending[0].ID}, now))
+	// Удаляются только события, опубликованные до границы
+	deleted, err := repo.Outbox.DeletePublished(ctx, now.Add(-time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+	deleted, err = repo.Outbox.DeletePublished(ctx, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+	pending, err = repo.Outbox.Pending(ctx, 10)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func testLeases(t *testing.T, repo *repositories.Repository) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+	acquired, err := repo.Leases.TryAcquire(ctx, "relay", "a", now, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+	// Владелец продлевает аренду, другой её не получит до истечения
+	acquired, err = repo.Leases.TryAcquire(ctx, "relay", "a", now.Add(30*time.Second), time.Minute)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+	acquired, err = repo.Leases.TryAcquire(ctx, "relay", "b", now.Add(time.Minute), time.Minute)
+	require.NoError(t, err)
+	assert.False(t, acquired)
+	acquired, err = repo.Leases.TryAcquire(ctx, "relay", "b", now.Add(2*time.Minute), time.Minute)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+
+	// Чужая аренда не освобождается
+	require.NoError(t, repo.Leases.Release(ctx, "relay", "a"))
+	acquired, err = repo.Leases.TryAcquire(ctx, "relay", "a", now.Add(2*time.Minute), time.Minute)
+	require.NoError(t, err)
+	assert.False(t, acquired)
+	require.NoError(t, repo.Leases.Release(ctx, "relay", "b"))
+	acquired, err = repo.Leases.TryAcquire(ctx, "relay", "a", now.Add(2*time.Minute), time.Minute)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+}
+
+func testEventLog(t *testing.T, repo *repositories.Repository) {
+	ctx := context.Background()
+	lastID, err := repo.EventLog.LastID(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, lastID)
+
+	require.NoError(t, repo.EventLog.Append(ctx, []models.EventLogEntry{
+		{EventID: "e-1", EventType: "pr.created", TeamName: "backend", UserIDs: models.StringArray{"u1"}, Payload: "{}"},
+		{EventID: "e-2", EventType: "pr.created", TeamName: "frontend", Payload: "{}"},
+		{EventID: "e-3", EventType: "pr.merged", TeamName: "backend", Payload: "{}"},
+	}))
+	// Повторная публикация события не дублирует запись
+	require.NoError(t, repo.EventLog.Append(ctx, []models.EventLogEntry{
+		{EventID: "e-1", EventType: "pr.created", TeamName: "backend", Payload: "{}"},
+	}))
+
+	all, err := repo.EventLog.After(ctx, 0, "", 10)
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	assert.Equal(t, models.StringArray{"u1"}, all[0].UserIDs)
+	lastID, err = repo.EventLog.LastID(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, all[2].ID, lastID)
+
+	backend, err := repo.EventLog.After(ctx, all[0].ID, "backend", 10)
+	require.NoError(t, err)
+	require.Len(t, backend, 1)
+	assert.Equal(t, "e-3", backend[0].EventID)
+	limited, err := repo.EventLog.After(ctx, 0, "", 2)
+	require.NoError(t, err)
+	assert.Len(t, limited, 2)
+
+	deleted, err := repo.EventLog.DeleteBefore(ctx, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Zero(t, deleted)
+	deleted, err = repo.EventLog.DeleteBefore(ctx, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), deleted)
+}
+
+func testCodeHost(t *testing.T, repo *repositories.Repository) {
+	ctx := context.Background()
+	_, err := repo.CodeHost.GetAccount(ctx, "github", "octocat")
+	assert.True(t, errors.IsNotFound(err))
+
+	require.NoError(t, repo.CodeHost.SetAccount(ctx, &models.CodeHostAccount{Provider: "github", Login: "octocat", UserID: "u1"}))
+	require.NoError(t, repo.CodeHost.SetAccount(ctx, &models.CodeHostAccount{Provider: "gitlab", Login: "octocat", UserID: "u2"}))
+	// Повторная привязка логина меняет пользователя
+	require.NoError(t, repo.CodeHost.SetAccount(ctx, &models.CodeHostAccount{Provider: "github", Login: "octocat", UserID: "u3"}))
+	account, err := repo.CodeHost.GetAccount(ctx, "github", "octocat")
+	require.NoError(t, err)
+	assert.Equal(t, "u3", account.UserID)
+
+	all, err := repo.CodeHost.ListAccounts(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	assert.Equal(t, "github", all[0].Provider)
+	github, err := repo.CodeHost.ListAccounts(ctx, "github")
+	require.NoError(t, err)
+	assert.Len(t, github, 1)
+
+	require.NoError(t, repo.CodeHost.DeleteAccount(ctx, "gitlab", "octocat"))
+	assert.True(t, errors.IsNotFound(repo.CodeHost.DeleteAccount(ctx, "gitlab", "octocat")))
+
+	_, err = repo.CodeHost.GetPullRequestLink(ctx, "github:acme/payments#42")
+	assert.True(t, errors.IsNotFound(err))
+	link := &models.CodeHostPullRequest{
+		PullRequestID: "github:acme/payments#42",
+		Provider:      "github",
+		Repository:    "acme/payments",
+		Number:        42,
+		URL:           "https://github.com/acme/payments/pull/42",
+	}
+	require.NoError(t, repo.CodeHost.LinkPullRequest(ctx, link))
+	// Повторная связь того же PR не ошибка
+	require.NoError(t, repo.CodeHost.LinkPullRequest(ctx, &models.CodeHostPullRequest{
+		PullRequestID: link.PullRequestID, Provider: "github", Repository: "acme/payments", Number: 42,
+	}))
+	stored, err := repo.CodeHost.GetPullRequestLink(ctx, link.PullRequestID)
+	require.NoError(t, err)
+	assert.Equal(t, link.URL, stored.URL)
+	assert.Equal(t, 42, stored.Number)
+	require.NoError(t, repo.CodeHost.UnlinkPullRequest(ctx, "github:acme/payments#43"))
+	stored, err = repo.CodeHost.GetPullRequestLink(ctx, link.PullRequestID)
+	require.NoError(t, err)
+
+	require.NoError(t, repo.CodeHost.SetAccount(ctx, &models.CodeHostAccount{Provider: "github", Login: "alice", UserID: "u3"}))
+	// У пользователя два логина — берётся первый по алфавиту
+	account, err = repo.CodeHost.GetAccountByUser(ctx, "github", "u3")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", account.Login)
+	_, err = repo.CodeHost.GetAccountByUser(ctx, "gitlab", "u3")
+	assert.True(t, errors.IsNotFound(err))
+
+	now := time.Now().UTC().Truncate(time.Second)
+	require.NoError(t, repo.CodeHost.CreateSyncs(ctx, []models.ReviewerSync{
+		{ID: "s-1", EventID: "e-1", PullRequestID: link.PullRequestID, Status: models.DeliveryPending, NextAttemptAt: now.Add(-time.Minute)},
+		{ID: "s-2", EventID: "e-2", PullRequestID: link.PullRequestID, Removed: models.StringArray{"u2"},
+			Status: models.DeliveryPending, NextAttemptAt: now.Add(time.Hour)},
+	}))
+	// Повторная публикация события не создаёт задачу
+	require.NoError(t, repo.CodeHost.CreateSyncs(ctx, []models.ReviewerSync{
+		{ID: "s-3", EventID: "e-1", PullRequestID: link.PullRequestID, Status: models.DeliveryPending, NextAttemptAt: now.Add(-time.Minute)},
+	}))
+
+	claimed, err := repo.CodeHost.ClaimDueSyncs(ctx, now, time.Minute, 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	assert.Equal(t, "s-1", claimed[0].ID)
+	claimed, err = repo.CodeHost.ClaimDueSyncs(ctx, now, time.Minute, 10)
+	require.NoError(t, err)
+	assert.Empty(t, claimed)
+
+	sync, err := repo.CodeHost.GetSync(ctx, "s-2")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"u2"}, []string(sync.Removed))
+	sync.Status = models.DeliveryFailed
+	sync.Attempts = 8
+	sync.LastError = "unexpected status 422"
+	require.NoError(t, repo.CodeHost.UpdateSync(ctx, sync))
+	_, err = repo.CodeHost.GetSync(ctx, "s-3")
+	assert.True(t, errors.IsNotFound(err))
+
+	failed, err := repo.CodeHost.ListSyncs(ctx, models.DeliveryFailed, 10)
+	require.NoError(t, err)
+	require.Len(t, failed, 1)
+	assert.Equal(t, "unexpected status 422", failed[0].LastError)
+	syncs, err := repo.CodeHost.ListSyncs(ctx, "", 10)
+	require.NoError(t, err)
+	assert.Len(t, syncs, 2)
+}
@@ -0,0 +1,159 @@
+// Package retryqueue — фоновая очередь задач с повторами, общая для доставки
+// вебхуков и синхронизации ревьюеров.
+//
+// Задачи хранятся в таблице; Queue забирает те, которым пора выполниться, под
+// аренду, выполняет их параллельно и по итогу попытки переводит задачу в
+// delivered, откладывает с экспоненциальной задержкой или после MaxAttempts
+// помечает failed. Что такое попытка и как сохранить её результат, решает владелец
+// очереди.
+package retryqueue
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/health"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
+)
+
+const (
+	// BatchSize — сколько задач выполняется за один проход
+	BatchSize = 20
+	// MaxErrorLength ограничивает текст ошибки, который сохраняется в задаче
+	MaxErrorLength = 500
+)
+
+type Config struct {
+	// PollInterval — как часто проверять очередь, если новых задач нет
+	PollInterval time.Duration
+	// Timeout — таймаут одной попытки
+	Timeout     time.Duration
+	MaxAttempts int
+	// Задержка перед повтором: InitialBackoff, затем вдвое больше, но не больше MaxBackoff
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// Queue выполняет задачи типа T
+type Queue[T any] struct {
+	// name — имя очереди для логов
+	name string
+	cfg  Config
+	// claim забирает до limit задач, которым пора выполниться, и откладывает их на lease
+	claim func(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]T, error)
+	// attempt делает одну попытку и сохраняет её результат. Наружу возвращаются
+	// только ошибки хранилища: ошибка самой попытки записывается в задачу.
+	attempt func(ctx context.Context, item *T) error
+	now     func() time.Time
+	wake    chan struct{}
+}
+
+// New создаёт очередь. now задаёт текущее время, чтобы тесты владельца могли его подменять.
+func New[T any](name string, cfg Config, now func() time.Time,
+	claim func(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]T, error),
+	attempt func(ctx context.Context, item *T) error,
+) *Queue[T] {
+	return &Queue[T]{
+		name:    name,
+		cfg:     cfg,
+		claim:   claim,
+		attempt: attempt,
+		now:     now,
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// Wake будит Run, чтобы новые задачи не ждали следующего PollInterval
+func (q *Queue[T]) Wake() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run выполняет задачи, пока не отменён ctx
+func (q *Queue[T]) Run(ctx context.Context, heartbeat *health.Heartbeat) {
+	ticker := time.NewTicker(q.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		n, err := q.ProcessDue(ctx)
+		if err != nil {
+			slog.Error("failed to process retry queue", "queue", q.name, "error", err.Error())
+			heartbeat.Fail(err)
+		} else {
+			heartbeat.Beat()
+		}
+		// Полная пачка — в очереди могут быть ещё задачи
+		if err == nil && n == BatchSize {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-q.wake:
+		}
+	}
+}
+
+// ProcessDue выполняет задачи, срок которых подошёл, и возвращает их число
+func (q *Queue[T]) ProcessDue(ctx context.Context) (int, error) {
+	// Аренда с запасом на таймаут: если экземпляр упадёт посреди попытки,
+	// задачу после истечения аренды заберёт другой
+	lease := 2*q.cfg.Timeout + time.Minute
+	items, err := q.claim(ctx, q.now().UTC(), lease, BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		lastErr error
+	)
+	for i := range items {
+		wg.Add(1)
+		go func(item *T) {
+			defer wg.Done()
+			if err := q.attempt(ctx, item); err != nil {
+				mu.Lock()
+				lastErr = err
+				mu.Unlock()
+			}
+		}(&items[i])
+	}
+	wg.Wait()
+	return len(items), lastErr
+}
+
+// Settle возвращает состояние задачи после попытки номер attempts с ошибкой err:
+// статус (DeliveryDelivered, DeliveryPending или DeliveryFailed), время
+// следующей попытки для DeliveryPending и текст ошибки для сохранения
+func (q *Queue[T]) Settle(attempts int, err error, now time.Time) (status string, next time.Time, lastError string) {
+	switch {
+	case err == nil:
+		return models.DeliveryDelivered, time.Time{}, ""
+	case attempts >= q.cfg.MaxAttempts:
+		return models.DeliveryFailed, time.Time{}, Truncate(err.Error())
+	default:
+		return models.DeliveryPending, now.Add(q.Backoff(attempts)), Truncate(err.Error())
+	}
+}
+
+// Backoff возвращает задержку после attempt неудачных попыток
+func (q *Queue[T]) Backoff(attempt int) time.Duration {
+	delay := q.cfg.InitialBackoff
+	for i := 1; i < attempt && delay < q.cfg.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, q.cfg.MaxBackoff)
+}
+
+// Truncate обрезает текст ошибки до MaxErrorLength байт
+func Truncate(s string) string {
+	if len(s) <= MaxErrorLength {
+		return s
+	}
+	return s[:MaxErrorLength]
+}
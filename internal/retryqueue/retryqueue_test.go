@@ -0,0 +1,77 @@
+package retryqueue
+
+import (
+	"context"
+	stderrors "errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type task struct {
+	ID       int
+	Attempts int
+}
+
+func newTestQueue(claim func(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]task, error),
+	attempt func(ctx context.Context, item *task) error) *Queue[task] {
+	return New("test", Config{
+		PollInterval:   time.Second,
+		Timeout:        time.Second,
+		MaxAttempts:    3,
+		InitialBackoff: 10 * time.Second,
+		MaxBackoff:     time.Minute,
+	}, time.Now, claim, attempt)
+}
+
+func TestBackoff(t *testing.T) {
+	q := newTestQueue(nil, nil)
+	assert.Equal(t, 10*time.Second, q.Backoff(1))
+	assert.Equal(t, 20*time.Second, q.Backoff(2))
+	assert.Equal(t, 40*time.Second, q.Backoff(3))
+	assert.Equal(t, time.Minute, q.Backoff(4))
+	assert.Equal(t, time.Minute, q.Backoff(30))
+}
+
+func TestSettle(t *testing.T) {
+	q := newTestQueue(nil, nil)
+	now := time.Now()
+	failure := stderrors.New(strings.Repeat("x", MaxErrorLength+10))
+
+	status, _, lastError := q.Settle(1, nil, now)
+	assert.Equal(t, models.DeliveryDelivered, status)
+	assert.Empty(t, lastError)
+
+	status, next, lastError := q.Settle(2, failure, now)
+	assert.Equal(t, models.DeliveryPending, status)
+	assert.Equal(t, now.Add(20*time.Second), next)
+	assert.Len(t, lastError, MaxErrorLength)
+
+	status, _, _ = q.Settle(3, failure, now)
+	assert.Equal(t, models.DeliveryFailed, status)
+}
+
+func TestProcessDue(t *testing.T) {
+	ctx := context.Background()
+	var lease time.Duration
+	q := newTestQueue(func(_ context.Context, _ time.Time, l time.Duration, limit int) ([]task, error) {
+		lease = l
+		assert.Equal(t, BatchSize, limit)
+		return []task{{ID: 1}, {ID: 2}}, nil
+	}, func(_ context.Context, item *task) error {
+		// Ошибка хранилища у одной задачи не мешает остальным
+		if item.ID == 2 {
+			return stderrors.New("db is down")
+		}
+		return nil
+	})
+
+	n, err := q.ProcessDue(ctx)
+	assert.Equal(t, 2, n)
+	require.EqualError(t, err, "db is down")
+	assert.Equal(t, 2*time.Second+time.Minute, lease)
+}
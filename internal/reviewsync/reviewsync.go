@@ -0,0 +1,233 @@
+// Package reviewsync передаёт провайдеру (GitHub, GitLab) ревьюеров, которых
+// сервис назначил PR, пришедшему из интеграции.
+//
+// Publish только ставит задачи в очередь (таблица reviewer_syncs), выполняет их
+// Run: читает текущих ревьюеров PR, переводит их в логины провайдера и вызывает
+// codehost.Client. Неудачные попытки повторяются с экспоненциальной задержкой,
+// после MaxAttempts задача помечается failed и ждёт ручного повтора.
+package reviewsync
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/codehost"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/errors"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/events"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/health"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/metrics"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/repositories"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/retryqueue"
+)
+
+type Config struct {
+	// PollInterval — как часто проверять очередь, если новых событий нет
+	PollInterval time.Duration
+	// Timeout — таймаут одной синхронизации, включая все запросы к API
+	Timeout     time.Duration
+	MaxAttempts int
+	// Задержка перед повтором: InitialBackoff, затем вдвое больше, но не больше MaxBackoff
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// Syncer ставит задачи синхронизации ревьюеров в очередь и выполняет их
+type Syncer struct {
+	repo    *repositories.Repository
+	clients map[string]codehost.Client
+	cfg     Config
+	now     func() time.Time
+	queue   *retryqueue.Queue[models.ReviewerSync]
+}
+
+// NewSyncer создаёт синхронизатор. PR провайдеров без клиента не синхронизируются.
+func NewSyncer(repo *repositories.Repository, clients []codehost.Client, cfg Config) *Syncer {
+	byProvider := make(map[string]codehost.Client, len(clients))
+	for _, client := range clients {
+		byProvider[client.Provider()] = client
+	}
+	s := &Syncer{
+		repo:    repo,
+		clients: byProvider,
+		cfg:     cfg,
+		now:     time.Now,
+	}
+	s.queue = retryqueue.New("reviewer_sync", retryqueue.Config{
+		PollInterval:   cfg.PollInterval,
+		Timeout:        cfg.Timeout,
+		MaxAttempts:    cfg.MaxAttempts,
+		InitialBackoff: cfg.InitialBackoff,
+		MaxBackoff:     cfg.MaxBackoff,
+	}, func() time.Time { return s.now() }, repo.CodeHost.ClaimDueSyncs, s.attempt)
+	return s
+}
+
+// Publish создаёт задачи для pr.created и reviewer.reassigned по PR, связанным
+// с PR провайдера. Остальные события и PR, созданные через API, пропускаются.
+func (s *Syncer) Publish(ctx context.Context, evs ...events.Event) error {
+	var syncs []models.ReviewerSync
+	for _, ev := range evs {
+		if ev.Type != events.TypePRCreated && ev.Type != events.TypeReviewerReassigned {
+			continue
+		}
+		link, err := s.repo.CodeHost.GetPullRequestLink(ctx, ev.Key)
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if _, ok := s.clients[link.Provider]; !ok {
+			continue
+		}
+		rs := models.ReviewerSync{
+			ID:            newSyncID(),
+			EventID:       ev.ID,
+			PullRequestID: link.PullRequestID,
+			Status:        models.DeliveryPending,
+			NextAttemptAt: s.now().UTC(),
+		}
+		if ev.Type == events.TypeReviewerReassigned {
+			removed, err := oldReviewer(ev)
+			if err != nil {
+				return err
+			}
+			rs.Removed = models.StringArray{removed}
+		}
+		syncs = append(syncs, rs)
+	}
+	if len(syncs) == 0 {
+		return nil
+	}
+	if err := s.repo.CodeHost.CreateSyncs(ctx, syncs); err != nil {
+		return err
+	}
+	s.queue.Wake()
+	return nil
+}
+
+// oldReviewer достаёт старого ревьюера из события. Data — ReassignmentData при
+// публикации в процессе или json.RawMessage после outbox, поэтому идёт через JSON.
+func oldReviewer(ev events.Event) (string, error) {
+	raw, err := json.Marshal(ev.Data)
+	if err != nil {
+		return "", err
+	}
+	var data events.ReassignmentData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return "", err
+	}
+	return data.OldReviewerID, nil
+}
+
+// Run выполняет задачи, пока не отменён ctx
+func (s *Syncer) Run(ctx context.Context, heartbeat *health.Heartbeat) {
+	s.queue.Run(ctx, heartbeat)
+}
+
+// SyncDue выполняет задачи, срок которых подошёл, и возвращает их число
+func (s *Syncer) SyncDue(ctx context.Context) (int, error) {
+	return s.queue.ProcessDue(ctx)
+}
+
+// attempt делает одну попытку и сохраняет её результат. Ошибка вызова API
+// уходит в задачу, наружу возвращаются только ошибки базы.
+func (s *Syncer) attempt(ctx context.Context, rs *models.ReviewerSync) error {
+	link, err := s.repo.CodeHost.GetPullRequestLink(ctx, rs.PullRequestID)
+	if err != nil {
+		return err
+	}
+	syncErr := s.sync(ctx, link, rs)
+	now := s.now().UTC()
+	rs.Attempts++
+	var next time.Time
+	rs.Status, next, rs.LastError = s.queue.Settle(rs.Attempts, syncErr, now)
+	switch rs.Status {
+	case models.DeliveryDelivered:
+		rs.SyncedAt = &now
+		metrics.ReviewerSyncAttempt(link.Provider, "synced")
+	case models.DeliveryFailed:
+		metrics.ReviewerSyncAttempt(link.Provider, "failed")
+	default:
+		rs.NextAttemptAt = next
+		metrics.ReviewerSyncAttempt(link.Provider, "retry")
+	}
+	if syncErr != nil {
+		slog.Warn("reviewer sync attempt failed",
+			"sync_id", rs.ID, "pull_request_id", rs.PullRequestID, "attempt", rs.Attempts, "error", syncErr.Error())
+	}
+	return s.repo.CodeHost.UpdateSync(ctx, rs)
+}
+
+// sync передаёт провайдеру текущих ревьюеров PR. Задача не хранит список
+// ревьюеров: повтор после переназначения отправит уже актуальный.
+func (s *Syncer) sync(ctx context.Context, link *models.CodeHostPullRequest, rs *models.ReviewerSync) error {
+	client, ok := s.clients[link.Provider]
+	if !ok {
+		return errors.NewInvalidInput("no client for provider " + link.Provider)
+	}
+	pr, err := s.repo.PR.GetPRByID(ctx, rs.PullRequestID)
+	if err != nil {
+		return err
+	}
+	// У закрытого PR ревью уже не нужно
+	if pr.Status == "MERGED" || pr.Status == "CLOSED" {
+		return nil
+	}
+	reviewers, err := s.logins(ctx, link.Provider, pr.Reviewers)
+	if err != nil {
+		return err
+	}
+	// Ревьюера могли вернуть на PR следующим переназначением
+	var removedIDs []string
+	for _, id := range rs.Removed {
+		if !slices.Contains(pr.Reviewers, id) {
+			removedIDs = append(removedIDs, id)
+		}
+	}
+	removed, err := s.logins(ctx, link.Provider, removedIDs)
+	if err != nil {
+		return err
+	}
+	if len(reviewers) == 0 && len(removed) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	defer cancel()
+	return client.RequestReviewers(ctx, codehost.ReviewRequest{
+		Repository: link.Repository,
+		Number:     link.Number,
+		Reviewers:  reviewers,
+		Removed:    removed,
+	})
+}
+
+// logins переводит пользователей в логины провайдера. Пользователей без
+// привязки провайдер не знает, они пропускаются.
+func (s *Syncer) logins(ctx context.Context, provider string, userIDs []string) ([]string, error) {
+	var logins []string
+	for _, userID := range userIDs {
+		account, err := s.repo.CodeHost.GetAccountByUser(ctx, provider, userID)
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		logins = append(logins, account.Login)
+	}
+	return logins, nil
+}
+
+func newSyncID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return "rsync_" + hex.EncodeToString(b)
+}
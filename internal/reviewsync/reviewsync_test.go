@@ -0,0 +1,206 @@
+package reviewsync
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/codehost"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/db"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/events"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/repositories"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const prID = "github:acme/payments#42"
+
+// fakeClient записывает запросы и отвечает ошибками из errs, пока они не кончатся
+type fakeClient struct {
+	mu       sync.Mutex
+	errs     []error
+	requests []codehost.ReviewRequest
+}
+
+func (*fakeClient) Provider() string {
+	return codehost.ProviderGitHub
+}
+
+func (c *fakeClient) RequestReviewers(_ context.Context, req codehost.ReviewRequest) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests = append(c.requests, req)
+	if len(c.errs) == 0 {
+		return nil
+	}
+	err := c.errs[0]
+	c.errs = c.errs[1:]
+	return err
+}
+
+// setup создаёт команду из u1..u4, PR prID от u1 с ревьюерами u2 и u3, связь
+// с PR GitHub и логины alice, bob и carol для u2, u3 и u4
+func setup(t *testing.T) *repositories.Repository {
+	t.Helper()
+	ctx := context.Background()
+	database, err := db.Open("sqlite://" + filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(database.Close)
+	repo := repositories.NewRepository(database.DB)
+	require.NoError(t, repo.Team.CreateTeam(ctx, &models.Team{ID: "t1", Name: "backend"}, []models.User{
+		{ID: "u1", Username: "author", IsActive: true},
+		{ID: "u2", Username: "alice", IsActive: true},
+		{ID: "u3", Username: "bob", IsActive: true},
+		{ID: "u4", Username: "carol", IsActive: true},
+	}))
+	require.NoError(t, repo.PR.CreatePR(ctx, &models.PullRequest{
+		ID: prID, Title: "Add payments", AuthorID: "u1", Status: "OPEN", Reviewers: models.StringArray{"u2", "u3"},
+	}))
+	require.NoError(t, repo.CodeHost.LinkPullRequest(ctx, &models.CodeHostPullRequest{
+		PullRequestID: prID, Provider: codehost.ProviderGitHub, Repository: "acme/payments", Number: 42,
+	}))
+	for userID, login := range map[string]string{"u2": "alice", "u3": "bob", "u4": "carol"} {
+		require.NoError(t, repo.CodeHost.SetAccount(ctx, &models.CodeHostAccount{
+			Provider: codehost.ProviderGitHub, Login: login, UserID: userID,
+		}))
+	}
+	return repo
+}
+
+func newTestSyncer(repo *repositories.Repository, client codehost.Client, now *time.Time) *Syncer {
+	s := NewSyncer(repo, []codehost.Client{client}, Config{
+		PollInterval:   time.Second,
+		Timeout:        time.Second,
+		MaxAttempts:    3,
+		InitialBackoff: time.Minute,
+		MaxBackoff:     90 * time.Second,
+	})
+	s.now = func() time.Time { return *now }
+	return s
+}
+
+func TestSyncer_SyncsCreatedPR(t *testing.T) {
+	ctx := context.Background()
+	repo := setup(t)
+	client := &fakeClient{}
+	now := time.Now()
+	s := newTestSyncer(repo, client, &now)
+
+	created := events.New(events.TypePRCreated, "backend", prID, events.PullRequestData{})
+	// PR создан через API, а не из GitHub — синхронизировать нечего
+	untracked := events.New(events.TypePRCreated, "backend", "pr-1", events.PullRequestData{})
+	merged := events.New(events.TypePRMerged, "backend", prID, events.PullRequestData{})
+	require.NoError(t, s.Publish(ctx, created, untracked, merged))
+	// Повторная публикация того же события
+	require.NoError(t, s.Publish(ctx, created))
+
+	n, err := s.SyncDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []codehost.ReviewRequest{
+		{Repository: "acme/payments", Number: 42, Reviewers: []string{"alice", "bob"}},
+	}, client.requests)
+
+	syncs, err := repo.CodeHost.ListSyncs(ctx, "", 10)
+	require.NoError(t, err)
+	require.Len(t, syncs, 1)
+	assert.Equal(t, models.DeliveryDelivered, syncs[0].Status)
+	assert.Equal(t, created.ID, syncs[0].EventID)
+	assert.NotNil(t, syncs[0].SyncedAt)
+}
+
+func TestSyncer_ReassignRemovesOldReviewer(t *testing.T) {
+	ctx := context.Background()
+	repo := setup(t)
+	client := &fakeClient{}
+	now := time.Now()
+	s := newTestSyncer(repo, client, &now)
+
+	// u3 заменён на u4
+	pr, err := repo.PR.GetPRByID(ctx, prID)
+	require.NoError(t, err)
+	pr.Reviewers = models.StringArray{"u2", "u4"}
+	require.NoError(t, repo.PR.UpdatePR(ctx, pr))
+	require.NoError(t, s.Publish(ctx, events.New(events.TypeReviewerReassigned, "backend", prID, events.ReassignmentData{
+		PullRequestID: prID, OldReviewerID: "u3", NewReviewerID: "u4",
+	})))
+
+	_, err = s.SyncDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []codehost.ReviewRequest{
+		{Repository: "acme/payments", Number: 42, Reviewers: []string{"alice", "carol"}, Removed: []string{"bob"}},
+	}, client.requests)
+}
+
+func TestSyncer_RetriesThenFails(t *testing.T) {
+	ctx := context.Background()
+	repo := setup(t)
+	client := &fakeClient{errs: []error{
+		fmt.Errorf("github: unexpected status 502"),
+		fmt.Errorf("github: unexpected status 502"),
+		fmt.Errorf("github: unexpected status 422"),
+	}}
+	now := time.Now()
+	s := newTestSyncer(repo, client, &now)
+	require.NoError(t, s.Publish(ctx, events.New(events.TypePRCreated, "backend", prID, events.PullRequestData{})))
+
+	// Первая неудача — повтор через InitialBackoff
+	_, err := s.SyncDue(ctx)
+	require.NoError(t, err)
+	syncs, err := repo.CodeHost.ListSyncs(ctx, models.DeliveryPending, 10)
+	require.NoError(t, err)
+	require.Len(t, syncs, 1)
+	assert.Equal(t, 1, syncs[0].Attempts)
+	assert.Equal(t, "github: unexpected status 502", syncs[0].LastError)
+	assert.WithinDuration(t, now.Add(time.Minute), syncs[0].NextAttemptAt, time.Second)
+
+	// До срока повтора задача не выполняется
+	n, err := s.SyncDue(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	now = now.Add(time.Minute)
+	_, err = s.SyncDue(ctx)
+	require.NoError(t, err)
+	now = now.Add(90 * time.Second)
+	_, err = s.SyncDue(ctx)
+	require.NoError(t, err)
+
+	// После MaxAttempts задача попадает в очередь failed
+	failed, err := repo.CodeHost.ListSyncs(ctx, models.DeliveryFailed, 10)
+	require.NoError(t, err)
+	require.Len(t, failed, 1)
+	assert.Equal(t, 3, failed[0].Attempts)
+	assert.Equal(t, "github: unexpected status 422", failed[0].LastError)
+	assert.Len(t, client.requests, 3)
+
+	now = now.Add(time.Hour)
+	n, err = s.SyncDue(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
+
+func TestSyncer_SkipsClosedPR(t *testing.T) {
+	ctx := context.Background()
+	repo := setup(t)
+	client := &fakeClient{}
+	now := time.Now()
+	s := newTestSyncer(repo, client, &now)
+	require.NoError(t, s.Publish(ctx, events.New(events.TypePRCreated, "backend", prID, events.PullRequestData{})))
+
+	pr, err := repo.PR.GetPRByID(ctx, prID)
+	require.NoError(t, err)
+	pr.Status = "MERGED"
+	require.NoError(t, repo.PR.UpdatePR(ctx, pr))
+
+	_, err = s.SyncDue(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, client.requests)
+	syncs, err := repo.CodeHost.ListSyncs(ctx, models.DeliveryDelivered, 10)
+	require.NoError(t, err)
+	assert.Len(t, syncs, 1)
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/auth"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/errors"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/repositories"
+)
+
+type authService struct {
+	repo *repositories.Repository
+}
+
+func NewAuthService(repo *repositories.Repository) AuthService {
+	return &authService{repo: repo}
+}
+
+func (s *authService) Authenticate(ctx context.Context, token string) (*auth.Principal, error) {
+	if token == "" {
+		return nil, errors.NewUnauthorized()
+	}
+	stored, err := s.repo.Tokens.GetTokenByHash(ctx, auth.HashToken(token))
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, errors.NewUnauthorized()
+		}
+		return nil, err
+	}
+	if stored.RevokedAt != nil || (stored.ExpiresAt != nil && !stored.ExpiresAt.After(time.Now())) {
+		return nil, errors.NewUnauthorized()
+	}
+	principal := &auth.Principal{TokenID: stored.ID, Scope: stored.Scope}
+	if stored.UserID != nil {
+		principal.UserID = *stored.UserID
+	}
+	return principal, nil
+}
+
+func (s *authService) IssueToken(ctx context.Context, name, scope, userID string, ttl time.Duration) (*models.IssueTokenResponse, error) {
+	if name == "" {
+		return nil, errors.NewInvalidInput("name is required")
+	}
+	if !auth.ValidScope(scope) {
+		return nil, errors.NewInvalidInput("scope must be admin or user")
+	}
+	if scope == auth.ScopeUser && userID == "" {
+		return nil, errors.NewInvalidInput("user_id is required for user tokens")
+	}
+	if ttl < 0 {
+		return nil, errors.NewInvalidInput("expires_in must not be negative")
+	}
+
+	token := &models.APIToken{Name: name, Scope: scope}
+	if userID != "" {
+		if _, err := s.repo.User.GetUserByID(ctx, userID); err != nil {
+			return nil, err
+		}
+		token.UserID = &userID
+	}
+	if ttl > 0 {
+		expiresAt := time.Now().Add(ttl).UTC()
+		token.ExpiresAt = &expiresAt
+	}
+
+	secret, err := auth.GenerateToken()
+	if err != nil {
+		return nil, err
+	}
+	if token.ID, err = auth.GenerateID(); err != nil {
+		return nil, err
+	}
+	token.TokenHash = auth.HashToken(secret)
+	if err := s.repo.Tokens.CreateToken(ctx, token); err != nil {
+		return nil, err
+	}
+	return &models.IssueTokenResponse{
+		Token:  convertToken(token),
+		Secret: secret,
+	}, nil
+}
+
+func (s *authService) RevokeToken(ctx context.Context, tokenID string) (*models.TokenResponse, error) {
+	if tokenID == "" {
+		return nil, errors.NewInvalidInput("token_id is required")
+	}
+	token, err := s.repo.Tokens.RevokeToken(ctx, tokenID, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	response := convertToken(token)
+	return &response, nil
+}
+
+func (s *authService) EnsureToken(ctx context.Context, tokenID, name, scope, secret string) error {
+	hash := auth.HashToken(secret)
+	// Токен с этим секретом уже есть — ничего не трогаем, в том числе отзыв
+	if _, err := s.repo.Tokens.GetTokenByHash(ctx, hash); err == nil || !errors.IsNotFound(err) {
+		return err
+	}
+	return s.repo.Tokens.SaveToken(ctx, &models.APIToken{
+		ID:        tokenID,
+		Name:      name,
+		TokenHash: hash,
+		Scope:     scope,
+		CreatedAt: time.Now().UTC(),
+	})
+}
+
+func convertToken(token *models.APIToken) models.TokenResponse {
+	return models.TokenResponse{
+		TokenID:   token.ID,
+		Name:      token.Name,
+		Scope:     token.Scope,
+		UserID:    token.UserID,
+		CreatedAt: token.CreatedAt,
+		ExpiresAt: token.ExpiresAt,
+		RevokedAt: token.RevokedAt,
+	}
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/auth"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/errors"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthService_TokenLifecycle(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	authService := NewAuthService(s.repo)
+
+	_, err := s.CreateTeam(ctx, "backend", []models.TeamMember{{UserId: "u1", Username: "Alice", IsActive: true}})
+	require.NoError(t, err)
+
+	issued, err := authService.IssueToken(ctx, "alice laptop", auth.ScopeUser, "u1", time.Hour)
+	require.NoError(t, err)
+	assert.Contains(t, issued.Secret, "prs_")
+	require.NotNil(t, issued.Token.ExpiresAt)
+
+	principal, err := authService.Authenticate(ctx, issued.Secret)
+	require.NoError(t, err)
+	assert.Equal(t, &auth.Principal{TokenID: issued.Token.TokenID, Scope: auth.ScopeUser, UserID: "u1"}, principal)
+
+	_, err = authService.Authenticate(ctx, issued.Secret+"x")
+	assert.True(t, errors.IsUnauthorized(err))
+
+	revoked, err := authService.RevokeToken(ctx, issued.Token.TokenID)
+	require.NoError(t, err)
+	assert.NotNil(t, revoked.RevokedAt)
+	_, err = authService.Authenticate(ctx, issued.Secret)
+	assert.True(t, errors.IsUnauthorized(err))
+}
+
+func TestAuthService_Validation(t *testing.T) {
+	ctx := context.Background()
+	authService := NewAuthService(newTestService(t).repo)
+
+	_, err := authService.IssueToken(ctx, "", auth.ScopeAdmin, "", 0)
+	assert.True(t, errors.IsInvalidInput(err))
+	_, err = authService.IssueToken(ctx, "ci", "root", "", 0)
+	assert.True(t, errors.IsInvalidInput(err))
+	_, err = authService.IssueToken(ctx, "ci", auth.ScopeUser, "", 0)
+	assert.True(t, errors.IsInvalidInput(err))
+	_, err = authService.IssueToken(ctx, "ci", auth.ScopeUser, "ghost", 0)
+	assert.True(t, errors.IsNotFound(err))
+	_, err = authService.Authenticate(ctx, "")
+	assert.True(t, errors.IsUnauthorized(err))
+}
+
+func TestAuthService_EnsureToken(t *testing.T) {
+	ctx := context.Background()
+	authService := NewAuthService(newTestService(t).repo)
+
+	require.NoError(t, authService.EnsureToken(ctx, "bootstrap", "bootstrap", auth.ScopeAdmin, "first-secret"))
+	// Смена секрета в конфигурации заменяет старый
+	require.NoError(t, authService.EnsureToken(ctx, "bootstrap", "bootstrap", auth.ScopeAdmin, "second-secret"))
+
+	_, err := authService.Authenticate(ctx, "first-secret")
+	assert.True(t, errors.IsUnauthorized(err))
+	principal, err := authService.Authenticate(ctx, "second-secret")
+	require.NoError(t, err)
+	assert.True(t, principal.IsAdmin())
+
+	// Перезапуск с тем же секретом не возвращает отозванный токен
+	_, err = authService.RevokeToken(ctx, "bootstrap")
+	require.NoError(t, err)
+	require.NoError(t, authService.EnsureToken(ctx, "bootstrap", "bootstrap", auth.ScopeAdmin, "second-secret"))
+	_, err = authService.Authenticate(ctx, "second-secret")
+	assert.True(t, errors.IsUnauthorized(err))
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/errors"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/logger"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/metrics"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/repositories"
+)
+
+const (
+	// BatchModeAtomic создаёт все PR пакета в одной транзакции или ни одного
+	BatchModeAtomic = "atomic"
+	// BatchModePartial создаёт каждый PR отдельно и возвращает результат по каждому
+	BatchModePartial = "partial"
+
+	MaxBatchSize = 100
+)
+
+// BatchCreatePRs создаёт пакет PR, распределяя ревьюеров так, чтобы внутри пакета
+// ревью доставались в первую очередь наименее загруженным участникам команды.
+func (s *reviewService) BatchCreatePRs(ctx context.Context, items []models.CreatePRRequest, mode string) (*models.BatchCreateResponse, error) {
+	if mode == "" {
+		mode = BatchModeAtomic
+	}
+	if mode != BatchModeAtomic && mode != BatchModePartial {
+		return nil, errors.NewInvalidInput(fmt.Sprintf("mode must be %q or %q", BatchModeAtomic, BatchModePartial))
+	}
+	if len(items) == 0 {
+		return nil, errors.NewInvalidInput("items must not be empty")
+	}
+	if len(items) > MaxBatchSize {
+		return nil, errors.NewInvalidInput(fmt.Sprintf("batch is limited to %d items", MaxBatchSize))
+	}
+
+	load := make(map[string]int)
+	response := &models.BatchCreateResponse{
+		Mode:    mode,
+		Results: make([]models.BatchCreateItemResult, len(items)),
+	}
+
+	if mode == BatchModeAtomic {
+		err := s.repo.WithTx(ctx, func(r *repositories.Repository) error {
+			for i, item := range items {
+				pr, err := s.createPR(ctx, r, item.PullRequestID, item.PullRequestName, item.AuthorID, load)
+				if err != nil {
+					return batchItemError(i, item.PullRequestID, err)
+				}
+				countLoad(load, pr)
+				response.Results[i] = models.BatchCreateItemResult{
+					PullRequestId: pr.ID,
+					PR:            s.convertPRToShort(pr),
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, result := range response.Results {
+			metrics.PRCreated(len(result.PR.AssignedReviewers))
+		}
+		response.Created = len(items)
+		return response, nil
+	}
+
+	for i, item := range items {
+		var pr *models.PullRequest
+		err := s.repo.WithTx(ctx, func(r *repositories.Repository) error {
+			var err error
+			pr, err = s.createPR(ctx, r, item.PullRequestID, item.PullRequestName, item.AuthorID, load)
+			return err
+		})
+		result := models.BatchCreateItemResult{PullRequestId: item.PullRequestID}
+		if err != nil {
+			appErr, ok := err.(*errors.AppError)
+			if !ok {
+				// Уже созданные PR закоммичены, поэтому сбой инфраструктуры записываем
+				// в результат элемента, а не обрываем ответ
+				logger.FromContext(ctx).Error("failed to create pull request in batch",
+					"pull_request_id", item.PullRequestID, "error", err.Error())
+				appErr = errors.NewInternal()
+				if stderrors.Is(err, context.DeadlineExceeded) {
+					appErr = errors.NewTimeout()
+				}
+			}
+			result.Error = &models.BatchCreateItemError{Code: string(appErr.Code), Message: appErr.Message}
+			response.Failed++
+		} else {
+			countLoad(load, pr)
+			metrics.PRCreated(len(pr.Reviewers))
+			result.PR = s.convertPRToShort(pr)
+			response.Created++
+		}
+		response.Results[i] = result
+	}
+	return response, nil
+}
+
+func countLoad(load map[string]int, pr *models.PullRequest) {
+	for _, reviewer := range pr.Reviewers {
+		load[reviewer]++
+	}
+}
+
+// batchItemError дополняет доменную ошибку номером элемента пакета, сохраняя её код
+func batchItemError(index int, prID string, err error) error {
+	appErr, ok := err.(*errors.AppError)
+	if !ok {
+		return err
+	}
+	return &errors.AppError{
+		Code:    appErr.Code,
+		Message: fmt.Sprintf("item %d (%s): %s", index, prID, appErr.Message),
+	}
+}
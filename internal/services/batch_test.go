@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/db"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/repositories"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newTestService(t *testing.T, opts ...Option) *reviewService {
+	t.Helper()
+	database, err := db.Open("sqlite://" + filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(database.Close)
+	return NewReviewService(repositories.NewRepository(database.DB), opts...).(*reviewService)
+}
+
+func TestBatchCreatePRs_BalancesReviewers(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+
+	_, err := s.CreateTeam(ctx, "backend", []models.TeamMember{
+		{UserId: "author", Username: "Author", IsActive: true},
+		{UserId: "u1", Username: "Alice", IsActive: true},
+		{UserId: "u2", Username: "Bob", IsActive: true},
+		{UserId: "u3", Username: "Carol", IsActive: true},
+		{UserId: "u4", Username: "Dave", IsActive: true},
+	})
+	require.NoError(t, err)
+
+	items := make([]models.CreatePRRequest, 10)
+	for i := range items {
+		items[i] = models.CreatePRRequest{
+			PullRequestID:   fmt.Sprintf("pr-%d", i),
+			PullRequestName: "release",
+			AuthorID:        "author",
+		}
+	}
+
+	result, err := s.BatchCreatePRs(ctx, items, BatchModeAtomic)
+	require.NoError(t, err)
+	assert.Equal(t, 10, result.Created)
+
+	// 10 PR по 2 ревьюера на 4 кандидатов — ровно по 5 ревью каждому
+	load := make(map[string]int)
+	for _, item := range result.Results {
+		require.Len(t, item.PR.AssignedReviewers, 2)
+		for _, reviewer := range item.PR.AssignedReviewers {
+			load[reviewer]++
+		}
+	}
+	assert.Equal(t, map[string]int{"u1": 5, "u2": 5, "u3": 5, "u4": 5}, load)
+}
+
+func TestBatchCreatePRs_Modes(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+
+	_, err := s.CreateTeam(ctx, "backend", []models.TeamMember{
+		{UserId: "u1", Username: "Alice", IsActive: true},
+		{UserId: "u2", Username: "Bob", IsActive: true},
+	})
+	require.NoError(t, err)
+
+	items := []models.CreatePRRequest{
+		{PullRequestID: "pr-1", PullRequestName: "first", AuthorID: "u1"},
+		{PullRequestID: "pr-2", PullRequestName: "unknown author", AuthorID: "missing"},
+	}
+
+	// В атомарном режиме ошибка откатывает весь пакет
+	_, err = s.BatchCreatePRs(ctx, items, BatchModeAtomic)
+	require.Error(t, err)
+	exists, err := s.repo.PR.PRExists(ctx, "pr-1")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	// В частичном режиме успешные PR сохраняются
+	result, err := s.BatchCreatePRs(ctx, items, BatchModePartial)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Created)
+	assert.Equal(t, 1, result.Failed)
+	assert.NotNil(t, result.Results[0].PR)
+	assert.Equal(t, "NOT_FOUND", result.Results[1].Error.Code)
+
+	exists, err = s.repo.PR.PRExists(ctx, "pr-1")
+	require.NoError(t, err)
+	assert.True(t, exists)
+}
+
+func TestBatchCreatePRs_PartialKeepsResultsOnInfraError(t *testing.T) {
+	ctx := context.Background()
+	database, err := db.Open("sqlite://" + filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(database.Close)
+	// Имитируем сбой базы на вставке одного из PR
+	require.NoError(t, database.Callback().Create().Before("gorm:create").Register("test:fail_pr", func(tx *gorm.DB) {
+		if pr, ok := tx.Statement.Dest.(*models.PullRequest); ok && pr.ID == "pr-2" {
+			_ = tx.AddError(stderrors.New("connection reset by peer"))
+		}
+	}))
+	s := NewReviewService(repositories.NewRepository(database.DB)).(*reviewService)
+
+	_, err = s.CreateTeam(ctx, "backend", []models.TeamMember{
+		{UserId: "u1", Username: "Alice", IsActive: true},
+		{UserId: "u2", Username: "Bob", IsActive: true},
+	})
+	require.NoError(t, err)
+
+	items := []models.CreatePRRequest{
+		{PullRequestID: "pr-1", PullRequestName: "first", AuthorID: "u1"},
+		{PullRequestID: "pr-2", PullRequestName: "broken", AuthorID: "u1"},
+		{PullRequestID: "pr-3", PullRequestName: "third", AuthorID: "u1"},
+	}
+
+	result, err := s.BatchCreatePRs(ctx, items, BatchModePartial)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Created)
+	assert.Equal(t, 1, result.Failed)
+	assert.NotNil(t, result.Results[0].PR)
+	require.NotNil(t, result.Results[1].Error)
+	assert.Equal(t, "INTERNAL_ERROR", result.Results[1].Error.Code)
+	// Подробности ошибки инфраструктуры клиенту не отдаются
+	assert.NotContains(t, result.Results[1].Error.Message, "connection reset")
+	assert.NotNil(t, result.Results[2].PR)
+
+	for id, want := range map[string]bool{"pr-1": true, "pr-2": false, "pr-3": true} {
+		exists, err := s.repo.PR.PRExists(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, want, exists, id)
+	}
+}
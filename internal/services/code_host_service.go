@@ -0,0 +1,248 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/codehost"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/errors"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/logger"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/repositories"
+)
+
+type codeHostService struct {
+	// review — полная цепочка сервиса: события провайдера проходят те же
+	// проверки, трассировку и outbox, что и запросы API
+	review ReviewService
+	repo   *repositories.Repository
+}
+
+func NewCodeHostService(review ReviewService, repo *repositories.Repository) CodeHostService {
+	return &codeHostService{review: review, repo: repo}
+}
+
+func (s *codeHostService) Ingest(ctx context.Context, ev codehost.Event) (*models.IngestResult, error) {
+	prID := ev.PullRequestID()
+	result := &models.IngestResult{PullRequestID: prID}
+	var err error
+	switch ev.Action {
+	case codehost.ActionOpened:
+		return s.open(ctx, ev)
+	case codehost.ActionMerged:
+		result.Result = models.IngestMerged
+		_, err = s.review.MergePR(ctx, prID, 0)
+	case codehost.ActionClosed:
+		result.Result = models.IngestClosed
+		_, err = s.review.ClosePR(ctx, prID)
+	case codehost.ActionReopened:
+		result.Result = models.IngestReopened
+		if _, err = s.review.ReopenPR(ctx, prID); err == nil && ev.Draft {
+			_, err = s.review.MarkDraft(ctx, prID)
+		}
+	case codehost.ActionDraft:
+		result.Result = models.IngestDraft
+		_, err = s.review.MarkDraft(ctx, prID)
+	case codehost.ActionReady:
+		result.Result = models.IngestReady
+		_, err = s.review.MarkReady(ctx, prID)
+	default:
+		return ignored(prID, fmt.Sprintf("unsupported action %q", ev.Action)), nil
+	}
+	switch {
+	case errors.IsNotFound(err):
+		// PR открыт до подключения интеграции или его автор не привязан
+		return ignored(prID, "pull request is not tracked"), nil
+	// Провайдер и сервис разошлись в статусе PR; повтор доставки не поможет
+	case errors.IsPRMerged(err):
+		return ignored(prID, "pull request is already merged"), nil
+	case errors.IsPRClosed(err):
+		return ignored(prID, "pull request is closed"), nil
+	case err != nil:
+		return nil, err
+	}
+	return result, nil
+}
+
+// open создаёт PR от имени привязанного пользователя и запоминает связь с PR провайдера
+func (s *codeHostService) open(ctx context.Context, ev codehost.Event) (*models.IngestResult, error) {
+	prID := ev.PullRequestID()
+	account, err := s.repo.CodeHost.GetAccount(ctx, ev.Provider, ev.AuthorLogin)
+	if errors.IsNotFound(err) {
+		return ignored(prID, fmt.Sprintf("%s login %q is not linked to a user", ev.Provider, ev.AuthorLogin)), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Связь сохраняется до создания PR: по ней синхронизация ревьюеров находит
+	// PR провайдера, когда обрабатывает pr.created
+	_, err = s.repo.CodeHost.GetPullRequestLink(ctx, prID)
+	linked := errors.IsNotFound(err)
+	if err != nil && !linked {
+		return nil, err
+	}
+	if linked {
+		err = s.repo.CodeHost.LinkPullRequest(ctx, &models.CodeHostPullRequest{
+			PullRequestID: prID,
+			Provider:      ev.Provider,
+			Repository:    ev.Repository,
+			Number:        ev.Number,
+			URL:           ev.URL,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	result := &models.IngestResult{Result: models.IngestCreated, PullRequestID: prID}
+	if _, err := s.review.CreatePR(ctx, prID, ev.Title, account.UserID); errors.IsPRExists(err) {
+		result.Result = models.IngestExists
+	} else if err != nil {
+		// PR не создан — связь, добавленную этим вызовом, убираем
+		if linked {
+			if unlinkErr := s.repo.CodeHost.UnlinkPullRequest(context.WithoutCancel(ctx), prID); unlinkErr != nil {
+				logger.FromContext(ctx).Error("failed to remove pull request link", "pull_request_id", prID, "error", unlinkErr.Error())
+			}
+		}
+		return nil, err
+	}
+	// Черновик создаётся открытым PR и сразу переводится в DRAFT. При повторной
+	// доставке PR мог уже закрыться или смёржиться — тогда его не трогаем.
+	if ev.Draft {
+		_, err := s.review.MarkDraft(ctx, prID)
+		if err != nil && !errors.IsPRMerged(err) && !errors.IsPRClosed(err) {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func ignored(prID, reason string) *models.IngestResult {
+	return &models.IngestResult{Result: models.IngestIgnored, PullRequestID: prID, Reason: reason}
+}
+
+func validateProvider(provider string) error {
+	if !slices.Contains(codehost.Providers, provider) {
+		return errors.NewInvalidInput(fmt.Sprintf("unknown provider %q", provider))
+	}
+	return nil
+}
+
+func (s *codeHostService) SetAccount(ctx context.Context, provider, login, userID string) (*models.CodeHostAccountResponse, error) {
+	if err := validateProvider(provider); err != nil {
+		return nil, err
+	}
+	if login == "" || userID == "" {
+		return nil, errors.NewInvalidInput("login and user_id are required")
+	}
+	if _, err := s.repo.User.GetUserByID(ctx, userID); err != nil {
+		return nil, err
+	}
+	account := &models.CodeHostAccount{Provider: provider, Login: login, UserID: userID}
+	if err := s.repo.CodeHost.SetAccount(ctx, account); err != nil {
+		return nil, err
+	}
+	return convertCodeHostAccount(account), nil
+}
+
+func (s *codeHostService) ListAccounts(ctx context.Context, provider string) (*models.CodeHostAccountListResponse, error) {
+	if provider != "" {
+		if err := validateProvider(provider); err != nil {
+			return nil, err
+		}
+	}
+	accounts, err := s.repo.CodeHost.ListAccounts(ctx, provider)
+	if err != nil {
+		return nil, err
+	}
+	response := &models.CodeHostAccountListResponse{
+		Accounts: make([]models.CodeHostAccountResponse, len(accounts)),
+	}
+	for i := range accounts {
+		response.Accounts[i] = *convertCodeHostAccount(&accounts[i])
+	}
+	return response, nil
+}
+
+func (s *codeHostService) DeleteAccount(ctx context.Context, provider, login string) error {
+	if err := validateProvider(provider); err != nil {
+		return err
+	}
+	if login == "" {
+		return errors.NewInvalidInput("login is required")
+	}
+	return s.repo.CodeHost.DeleteAccount(ctx, provider, login)
+}
+
+func (s *codeHostService) ListSyncs(ctx context.Context, status string, limit int) (*models.ReviewerSyncListResponse, error) {
+	switch status {
+	case "", models.DeliveryPending, models.DeliveryDelivered, models.DeliveryFailed:
+	default:
+		return nil, errors.NewInvalidInput(fmt.Sprintf("unknown status %q", status))
+	}
+	if limit == 0 {
+		limit = DefaultDeliveriesLimit
+	}
+	if limit < 0 || limit > MaxDeliveriesLimit {
+		return nil, errors.NewInvalidInput(fmt.Sprintf("limit must be in 1..%d", MaxDeliveriesLimit))
+	}
+	syncs, err := s.repo.CodeHost.ListSyncs(ctx, status, limit)
+	if err != nil {
+		return nil, err
+	}
+	response := &models.ReviewerSyncListResponse{
+		Syncs: make([]models.ReviewerSyncResponse, len(syncs)),
+	}
+	for i := range syncs {
+		response.Syncs[i] = *convertReviewerSync(&syncs[i])
+	}
+	return response, nil
+}
+
+func (s *codeHostService) RetrySync(ctx context.Context, syncID string) (*models.ReviewerSyncResponse, error) {
+	if syncID == "" {
+		return nil, errors.NewInvalidInput("sync_id is required")
+	}
+	sync, err := s.repo.CodeHost.GetSync(ctx, syncID)
+	if err != nil {
+		return nil, err
+	}
+	if sync.Status != models.DeliveryFailed {
+		return nil, errors.NewInvalidInput(fmt.Sprintf("sync is %s, only failed syncs can be retried", sync.Status))
+	}
+	// LastError остаётся до следующей попытки: по нему видно, что чинили
+	sync.Status = models.DeliveryPending
+	sync.Attempts = 0
+	sync.NextAttemptAt = time.Now().UTC()
+	if err := s.repo.CodeHost.UpdateSync(ctx, sync); err != nil {
+		return nil, err
+	}
+	return convertReviewerSync(sync), nil
+}
+
+func convertCodeHostAccount(account *models.CodeHostAccount) *models.CodeHostAccountResponse {
+	return &models.CodeHostAccountResponse{
+		Provider: account.Provider,
+		Login:    account.Login,
+		UserID:   account.UserID,
+	}
+}
+
+func convertReviewerSync(sync *models.ReviewerSync) *models.ReviewerSyncResponse {
+	response := &models.ReviewerSyncResponse{
+		SyncID:         sync.ID,
+		PullRequestID:  sync.PullRequestID,
+		EventID:        sync.EventID,
+		RemovedUserIDs: sync.Removed,
+		Status:         sync.Status,
+		Attempts:       sync.Attempts,
+		LastError:      sync.LastError,
+		CreatedAt:      sync.CreatedAt,
+		SyncedAt:       sync.SyncedAt,
+	}
+	if sync.Status == models.DeliveryPending {
+		response.NextAttemptAt = &sync.NextAttemptAt
+	}
+	return response
+}
@@ -0,0 +1,234 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/auth"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/codehost"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/codehost/github"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/codehost/gitlab"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/errors"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// githubEvent разбирает записанный вебхук GitHub из testdata пакета github
+func githubEvent(t *testing.T, fixture string) codehost.Event {
+	t.Helper()
+	return fixtureEvent(t, codehost.ProviderGitHub, fixture, func(body []byte) (*codehost.Event, error) {
+		return github.Parse(github.EventPullRequest, body)
+	})
+}
+
+// gitlabEvent разбирает записанный вебхук GitLab из testdata пакета gitlab
+func gitlabEvent(t *testing.T, fixture string) codehost.Event {
+	t.Helper()
+	return fixtureEvent(t, codehost.ProviderGitLab, fixture, func(body []byte) (*codehost.Event, error) {
+		return gitlab.Parse(gitlab.EventMergeRequest, body)
+	})
+}
+
+func fixtureEvent(t *testing.T, provider, fixture string, parse func([]byte) (*codehost.Event, error)) codehost.Event {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("..", "codehost", provider, "testdata", fixture))
+	require.NoError(t, err)
+	ev, err := parse(body)
+	require.NoError(t, err)
+	require.NotNil(t, ev)
+	return *ev
+}
+
+func TestCodeHostService_IngestGitHub(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	codeHost := NewCodeHostService(s, s.repo)
+
+	_, err := s.CreateTeam(ctx, "backend", []models.TeamMember{
+		{UserId: "author", Username: "Author", IsActive: true},
+		{UserId: "u1", Username: "Alice", IsActive: true},
+	})
+	require.NoError(t, err)
+	opened := githubEvent(t, "pull_request_opened.json")
+	prID := opened.PullRequestID()
+
+	// Автор не привязан — PR не создаётся
+	result, err := codeHost.Ingest(ctx, opened)
+	require.NoError(t, err)
+	assert.Equal(t, models.IngestIgnored, result.Result)
+	_, err = s.repo.PR.GetPRByID(ctx, prID)
+	assert.True(t, errors.IsNotFound(err))
+	result, err = codeHost.Ingest(ctx, githubEvent(t, "pull_request_merged.json"))
+	require.NoError(t, err)
+	assert.Equal(t, models.IngestIgnored, result.Result)
+
+	// PR не создался — связь с PR провайдера не остаётся
+	require.NoError(t, s.repo.CodeHost.SetAccount(ctx, &models.CodeHostAccount{
+		Provider: codehost.ProviderGitHub, Login: "octocat", UserID: "ghost",
+	}))
+	_, err = codeHost.Ingest(ctx, opened)
+	assert.True(t, errors.IsNotFound(err))
+	_, err = s.repo.CodeHost.GetPullRequestLink(ctx, prID)
+	assert.True(t, errors.IsNotFound(err))
+
+	_, err = codeHost.SetAccount(ctx, codehost.ProviderGitHub, "octocat", "author")
+	require.NoError(t, err)
+	result, err = codeHost.Ingest(ctx, opened)
+	require.NoError(t, err)
+	assert.Equal(t, &models.IngestResult{Result: models.IngestCreated, PullRequestID: prID}, result)
+	pr, err := s.repo.PR.GetPRByID(ctx, prID)
+	require.NoError(t, err)
+	assert.Equal(t, "Add refund endpoint", pr.Title)
+	assert.Equal(t, "author", pr.AuthorID)
+	link, err := s.repo.CodeHost.GetPullRequestLink(ctx, prID)
+	require.NoError(t, err)
+	assert.Equal(t, "acme/payments", link.Repository)
+	assert.Equal(t, 42, link.Number)
+
+	// Повторная доставка не создаёт второй PR
+	result, err = codeHost.Ingest(ctx, opened)
+	require.NoError(t, err)
+	assert.Equal(t, models.IngestExists, result.Result)
+
+	for _, step := range []struct {
+		fixture, result, status string
+	}{
+		{"pull_request_closed.json", models.IngestClosed, "CLOSED"},
+		{"pull_request_reopened.json", models.IngestReopened, "OPEN"},
+		{"pull_request_merged.json", models.IngestMerged, "MERGED"},
+	} {
+		result, err = codeHost.Ingest(ctx, githubEvent(t, step.fixture))
+		require.NoError(t, err, step.fixture)
+		assert.Equal(t, step.result, result.Result, step.fixture)
+		pr, err = s.repo.PR.GetPRByID(ctx, prID)
+		require.NoError(t, err)
+		assert.Equal(t, step.status, pr.Status, step.fixture)
+	}
+
+	// Смёрженный PR не открывается снова
+	result, err = codeHost.Ingest(ctx, githubEvent(t, "pull_request_reopened.json"))
+	require.NoError(t, err)
+	assert.Equal(t, models.IngestIgnored, result.Result)
+}
+
+func TestCodeHostService_IngestGitLab(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	codeHost := NewCodeHostService(s, s.repo)
+
+	_, err := s.CreateTeam(ctx, "backend", []models.TeamMember{
+		{UserId: "author", Username: "Author", IsActive: true},
+		{UserId: "u1", Username: "Alice", IsActive: true},
+	})
+	require.NoError(t, err)
+	_, err = codeHost.SetAccount(ctx, codehost.ProviderGitLab, "octocat", "author")
+	require.NoError(t, err)
+	prID := "gitlab:acme/payments#7"
+
+	// Черновик создаётся сразу в DRAFT
+	for _, step := range []struct {
+		fixture, result, status string
+	}{
+		{"merge_request_open_draft.json", models.IngestCreated, "DRAFT"},
+		{"merge_request_update_ready.json", models.IngestReady, "OPEN"},
+		{"merge_request_update_draft.json", models.IngestDraft, "DRAFT"},
+		{"merge_request_close.json", models.IngestClosed, "CLOSED"},
+		{"merge_request_reopen.json", models.IngestReopened, "OPEN"},
+		{"merge_request_merge.json", models.IngestMerged, "MERGED"},
+	} {
+		result, err := codeHost.Ingest(ctx, gitlabEvent(t, step.fixture))
+		require.NoError(t, err, step.fixture)
+		assert.Equal(t, step.result, result.Result, step.fixture)
+		assert.Equal(t, prID, result.PullRequestID, step.fixture)
+		pr, err := s.repo.PR.GetPRByID(ctx, prID)
+		require.NoError(t, err)
+		assert.Equal(t, step.status, pr.Status, step.fixture)
+	}
+	link, err := s.repo.CodeHost.GetPullRequestLink(ctx, prID)
+	require.NoError(t, err)
+	assert.Equal(t, codehost.ProviderGitLab, link.Provider)
+	assert.Equal(t, 7, link.Number)
+
+	// Повторная доставка открытия черновика не трогает смёрженный PR
+	result, err := codeHost.Ingest(ctx, gitlabEvent(t, "merge_request_open_draft.json"))
+	require.NoError(t, err)
+	assert.Equal(t, models.IngestExists, result.Result)
+	result, err = codeHost.Ingest(ctx, gitlabEvent(t, "merge_request_update_draft.json"))
+	require.NoError(t, err)
+	assert.Equal(t, models.IngestIgnored, result.Result)
+}
+
+func TestCodeHostService_Accounts(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	codeHost := NewCodeHostPolicyService(NewCodeHostService(s, s.repo))
+
+	_, err := s.CreateTeam(ctx, "backend", []models.TeamMember{
+		{UserId: "u1", Username: "Alice", IsActive: true},
+		{UserId: "u2", Username: "Bob", IsActive: true},
+	})
+	require.NoError(t, err)
+
+	_, err = codeHost.SetAccount(ctx, "bitbucket", "alice", "u1")
+	assert.True(t, errors.IsInvalidInput(err))
+	_, err = codeHost.SetAccount(ctx, codehost.ProviderGitHub, "alice", "missing")
+	assert.True(t, errors.IsNotFound(err))
+	user := auth.WithPrincipal(ctx, &auth.Principal{TokenID: "tok-u1", Scope: auth.ScopeUser, UserID: "u1"})
+	_, err = codeHost.SetAccount(user, codehost.ProviderGitHub, "alice", "u1")
+	assert.True(t, errors.IsForbidden(err))
+
+	_, err = codeHost.SetAccount(ctx, codehost.ProviderGitHub, "alice", "u2")
+	require.NoError(t, err)
+	// Повторная привязка логина меняет пользователя
+	account, err := codeHost.SetAccount(ctx, codehost.ProviderGitHub, "alice", "u1")
+	require.NoError(t, err)
+	assert.Equal(t, "u1", account.UserID)
+	list, err := codeHost.ListAccounts(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, []models.CodeHostAccountResponse{{Provider: "github", Login: "alice", UserID: "u1"}}, list.Accounts)
+
+	require.NoError(t, codeHost.DeleteAccount(ctx, codehost.ProviderGitHub, "alice"))
+	assert.True(t, errors.IsNotFound(codeHost.DeleteAccount(ctx, codehost.ProviderGitHub, "alice")))
+}
+
+func TestCodeHostService_RetrySync(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	codeHost := NewCodeHostPolicyService(NewCodeHostService(s, s.repo))
+
+	require.NoError(t, s.repo.CodeHost.CreateSyncs(ctx, []models.ReviewerSync{
+		{ID: "s-1", EventID: "e-1", PullRequestID: "github:acme/payments#42", Status: models.DeliveryFailed,
+			Attempts: 8, LastError: "github: unexpected status 422"},
+		{ID: "s-2", EventID: "e-2", PullRequestID: "github:acme/payments#42", Status: models.DeliveryDelivered, Attempts: 1},
+	}))
+
+	_, err := codeHost.ListSyncs(ctx, "lost", 0)
+	assert.True(t, errors.IsInvalidInput(err))
+	user := auth.WithPrincipal(ctx, &auth.Principal{TokenID: "tok-u1", Scope: auth.ScopeUser, UserID: "u1"})
+	_, err = codeHost.ListSyncs(user, "", 0)
+	assert.True(t, errors.IsForbidden(err))
+	failed, err := codeHost.ListSyncs(ctx, models.DeliveryFailed, 0)
+	require.NoError(t, err)
+	require.Len(t, failed.Syncs, 1)
+	assert.Equal(t, "s-1", failed.Syncs[0].SyncID)
+
+	// Выполненную задачу повторять незачем
+	_, err = codeHost.RetrySync(ctx, "s-2")
+	assert.True(t, errors.IsInvalidInput(err))
+	_, err = codeHost.RetrySync(ctx, "missing")
+	assert.True(t, errors.IsNotFound(err))
+	_, err = codeHost.RetrySync(user, "s-1")
+	assert.True(t, errors.IsForbidden(err))
+
+	sync, err := codeHost.RetrySync(ctx, "s-1")
+	require.NoError(t, err)
+	assert.Equal(t, models.DeliveryPending, sync.Status)
+	assert.Zero(t, sync.Attempts)
+	assert.NotNil(t, sync.NextAttemptAt)
+	failed, err = codeHost.ListSyncs(ctx, models.DeliveryFailed, 0)
+	require.NoError(t, err)
+	assert.Empty(t, failed.Syncs)
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"slices"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/errors"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/repositories"
+)
+
+type eventStreamService struct {
+	repo *repositories.Repository
+}
+
+func NewEventStreamService(repo *repositories.Repository) EventStreamService {
+	return &eventStreamService{repo: repo}
+}
+
+func (s *eventStreamService) OpenStream(ctx context.Context, teamName, userID string) (EventFilter, error) {
+	if teamName == "" && userID == "" {
+		return EventFilter{}, errors.NewInvalidInput("team_name or user_id parameter is required")
+	}
+	if userID == "" {
+		team, err := s.repo.Team.GetTeamByName(ctx, teamName)
+		if err != nil {
+			return EventFilter{}, err
+		}
+		return EventFilter{TeamName: team.Name}, nil
+	}
+	// События пользователя — только события его команды
+	team, err := s.repo.User.GetUserTeam(ctx, userID)
+	if err != nil {
+		return EventFilter{}, err
+	}
+	if teamName != "" && team.Name != teamName {
+		return EventFilter{}, errors.NewInvalidInput("user is not a member of team_name")
+	}
+	return EventFilter{TeamName: team.Name, UserID: userID}, nil
+}
+
+func (s *eventStreamService) Events(ctx context.Context, filter EventFilter, afterID uint, limit int) ([]models.EventLogEntry, uint, error) {
+	entries, err := s.repo.EventLog.After(ctx, afterID, filter.TeamName, limit)
+	if err != nil {
+		return nil, afterID, err
+	}
+	if len(entries) == 0 {
+		return nil, afterID, nil
+	}
+	// Курсор сдвигается и за пропущенные события, чтобы не читать их снова
+	next := entries[len(entries)-1].ID
+	if filter.UserID == "" {
+		return entries, next, nil
+	}
+	matched := entries[:0]
+	for _, entry := range entries {
+		if slices.Contains(entry.UserIDs, filter.UserID) {
+			matched = append(matched, entry)
+		}
+	}
+	return matched, next, nil
+}
+
+func (s *eventStreamService) LastEventID(ctx context.Context) (uint, error) {
+	return s.repo.EventLog.LastID(ctx)
+}
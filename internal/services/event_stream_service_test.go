@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/auth"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/errors"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/events"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/outbox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventStreamService(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t, WithMaxReviewers(1))
+	stream := NewEventStreamPolicyService(NewEventStreamService(s.repo), s.repo)
+	relay := outbox.NewRelay(s.repo, outbox.Config{BatchSize: 100, LeaseTTL: time.Minute, Retention: time.Hour},
+		outbox.Sink{Name: "event_log", Publisher: outbox.EventLogSink{Repo: s.repo.EventLog}})
+
+	_, err := s.CreateTeam(ctx, "backend", []models.TeamMember{
+		{UserId: "author", Username: "Author", IsActive: true},
+		{UserId: "u1", Username: "Alice", IsActive: true},
+		{UserId: "u2", Username: "Bob", IsActive: false},
+	})
+	require.NoError(t, err)
+	_, err = s.CreateTeam(ctx, "frontend", []models.TeamMember{
+		{UserId: "f1", Username: "Frank", IsActive: true},
+	})
+	require.NoError(t, err)
+	as := func(userID string) context.Context {
+		return auth.WithPrincipal(ctx, &auth.Principal{TokenID: "tok-" + userID, Scope: auth.ScopeUser, UserID: userID})
+	}
+
+	t.Run("open", func(t *testing.T) {
+		_, err := stream.OpenStream(ctx, "", "")
+		assert.True(t, errors.IsInvalidInput(err))
+		_, err = stream.OpenStream(ctx, "missing", "")
+		assert.True(t, errors.IsNotFound(err))
+		_, err = stream.OpenStream(ctx, "frontend", "u1")
+		assert.True(t, errors.IsInvalidInput(err))
+
+		filter, err := stream.OpenStream(ctx, "", "u1")
+		require.NoError(t, err)
+		assert.Equal(t, EventFilter{TeamName: "backend", UserID: "u1"}, filter)
+
+		// Участник видит события своей команды и свои, но не чужие
+		_, err = stream.OpenStream(as("u1"), "backend", "")
+		assert.NoError(t, err)
+		_, err = stream.OpenStream(as("u1"), "", "u1")
+		assert.NoError(t, err)
+		_, err = stream.OpenStream(as("u1"), "frontend", "")
+		assert.True(t, errors.IsForbidden(err))
+		_, err = stream.OpenStream(as("u1"), "", "author")
+		assert.True(t, errors.IsForbidden(err))
+	})
+
+	start, err := stream.LastEventID(ctx)
+	require.NoError(t, err)
+	_, err = s.CreatePR(ctx, "pr-1", "feature", "author")
+	require.NoError(t, err)
+	_, err = s.CreatePR(ctx, "pr-2", "ui", "f1")
+	require.NoError(t, err)
+	_, err = s.SetUserActive(ctx, "u1", false)
+	require.NoError(t, err)
+	require.NoError(t, relay.Tick(ctx))
+
+	entries, next, err := stream.Events(ctx, EventFilter{TeamName: "backend"}, start, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{events.TypePRCreated, events.TypeReviewerAssigned, events.TypeUserDeactivated}, entryTypes(entries))
+	last, err := stream.LastEventID(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, last, next)
+
+	// Событие frontend не подходит фильтру, но курсор его проходит
+	entries, next, err = stream.Events(ctx, EventFilter{TeamName: "backend", UserID: "author"}, start, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{events.TypePRCreated, events.TypeReviewerAssigned}, entryTypes(entries))
+	assert.Equal(t, last, next)
+
+	entries, next, err = stream.Events(ctx, EventFilter{TeamName: "backend"}, last, 10)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+	assert.Equal(t, last, next)
+}
+
+func entryTypes(entries []models.EventLogEntry) []string {
+	types := make([]string, len(entries))
+	for i, entry := range entries {
+		types[i] = entry.EventType
+	}
+	return types
+}
@@ -1,25 +1,117 @@
 package services
 
-import "github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
+import (
+	"context"
+	"time"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/auth"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/codehost"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
+)
 
 type TeamService interface {
-	CreateTeam(teamName string, members []models.TeamMember) (*models.TeamResponse, error)
-	GetTeam(teamName string) (*models.TeamResponse, error)
+	CreateTeam(ctx context.Context, teamName string, members []models.TeamMember) (*models.TeamResponse, error)
+	GetTeam(ctx context.Context, teamName string) (*models.TeamResponse, error)
+	// SetTeamRole назначает пользователю роль в команде (lead, member, bot)
+	SetTeamRole(ctx context.Context, teamName, userID, role string) (*models.TeamRoleResponse, error)
 }
 
 type UserService interface {
-	SetUserActive(userID string, isActive bool) (*models.UserResponse, error)
-	GetUserReviews(userID string) (*models.UserPRsResponse, error)
+	SetUserActive(ctx context.Context, userID string, isActive bool) (*models.UserResponse, error)
+	GetUserReviews(ctx context.Context, userID string) (*models.UserPRsResponse, error)
 }
 
 type PRService interface {
-	CreatePR(prID, title, authorID string) (*models.PullRequestShort, error)
-	MergePR(prID string) (*models.PullRequestResponse, error)
-	ReassignReviewer(prID, oldReviewerID string) (*models.ReassignResponse, error)
+	CreatePR(ctx context.Context, prID, title, authorID string) (*models.PullRequestShort, error)
+	BatchCreatePRs(ctx context.Context, items []models.CreatePRRequest, mode string) (*models.BatchCreateResponse, error)
+	// GetPR возвращает PR с текущей версией — её клиент присылает в If-Match
+	GetPR(ctx context.Context, prID string) (*models.PullRequestResponse, error)
+	// expectedVersion — версия из If-Match; 0 означает отсутствие условия
+	MergePR(ctx context.Context, prID string, expectedVersion int) (*models.PullRequestResponse, error)
+	ReassignReviewer(ctx context.Context, prID, oldReviewerID string, expectedVersion int) (*models.ReassignResponse, error)
+	// ClosePR закрывает PR без merge, ReopenPR открывает закрытый PR снова.
+	// Обе операции идемпотентны.
+	ClosePR(ctx context.Context, prID string) (*models.PullRequestResponse, error)
+	ReopenPR(ctx context.Context, prID string) (*models.PullRequestResponse, error)
+	// MarkDraft переводит открытый PR в черновик (DRAFT), MarkReady возвращает его
+	// на ревью. Черновик можно смёржить, закрыть и переназначить, как открытый PR.
+	MarkDraft(ctx context.Context, prID string) (*models.PullRequestResponse, error)
+	MarkReady(ctx context.Context, prID string) (*models.PullRequestResponse, error)
+}
+
+type StatsService interface {
+	GetTeamStats(ctx context.Context, teamName string) (*models.TeamStatsResponse, error)
+	// Пустые from/to и teamName не ограничивают выборку
+	GetAssignmentStats(ctx context.Context, from, to *time.Time, teamName string) (*models.AssignmentStatsResponse, error)
+}
+
+// Authenticator проверяет bearer-токен: токен из базы (AuthService) или JWT (auth.JWTVerifier)
+type Authenticator interface {
+	// Authenticate возвращает principal по токену из заголовка Authorization
+	Authenticate(ctx context.Context, token string) (*auth.Principal, error)
+}
+
+// AuthService выпускает, отзывает и проверяет токены доступа к API
+type AuthService interface {
+	Authenticator
+	// IssueToken выпускает токен; ttl == 0 — бессрочный
+	IssueToken(ctx context.Context, name, scope, userID string, ttl time.Duration) (*models.IssueTokenResponse, error)
+	RevokeToken(ctx context.Context, tokenID string) (*models.TokenResponse, error)
+	// EnsureToken создаёт или обновляет токен с заданным ID и секретом (bootstrap-токен из конфигурации).
+	// Отозванный токен остаётся отозванным и после перезапуска.
+	EnsureToken(ctx context.Context, tokenID, name, scope, secret string) error
 }
 
 type ReviewService interface {
 	TeamService
 	UserService
 	PRService
+	StatsService
+}
+
+// WebhookService управляет вебхуками команд и показывает историю доставок
+type WebhookService interface {
+	// CreateWebhook регистрирует вебхук; секрет подписи возвращается только здесь
+	CreateWebhook(ctx context.Context, teamName, url string, eventTypes []string) (*models.CreateWebhookResponse, error)
+	ListWebhooks(ctx context.Context, teamName string) (*models.WebhookListResponse, error)
+	DeleteWebhook(ctx context.Context, webhookID string) error
+	// ListDeliveries возвращает последние limit доставок; limit == 0 — значение по умолчанию
+	ListDeliveries(ctx context.Context, webhookID string, limit int) (*models.WebhookDeliveriesResponse, error)
+}
+
+// EventFilter — какие события попадают в поток: события команды TeamName,
+// а если задан UserID — только те из них, что касаются этого пользователя
+type EventFilter struct {
+	TeamName string
+	UserID   string
+}
+
+// EventStreamService отдаёт опубликованные доменные события для /events/stream
+type EventStreamService interface {
+	// OpenStream проверяет параметры потока и возвращает фильтр событий.
+	// Права проверяются здесь один раз, а не на каждом чтении.
+	OpenStream(ctx context.Context, teamName, userID string) (EventFilter, error)
+	// Events возвращает до limit событий после курсора afterID, подходящих
+	// под filter, и курсор для следующего вызова
+	Events(ctx context.Context, filter EventFilter, afterID uint, limit int) ([]models.EventLogEntry, uint, error)
+	// LastEventID — курсор, после которого идут только новые события
+	LastEventID(ctx context.Context) (uint, error)
+}
+
+// CodeHostService применяет события о PR от провайдеров (GitHub, GitLab),
+// хранит привязку логинов провайдера к пользователям и показывает очередь
+// передачи ревьюеров провайдеру
+type CodeHostService interface {
+	// Ingest создаёт, мёржит, закрывает или открывает PR по событию провайдера.
+	// События о неизвестных PR и авторах пропускаются с причиной в результате.
+	Ingest(ctx context.Context, ev codehost.Event) (*models.IngestResult, error)
+	SetAccount(ctx context.Context, provider, login, userID string) (*models.CodeHostAccountResponse, error)
+	// ListAccounts возвращает привязки провайдера; пустой provider — всех провайдеров
+	ListAccounts(ctx context.Context, provider string) (*models.CodeHostAccountListResponse, error)
+	DeleteAccount(ctx context.Context, provider, login string) error
+	// ListSyncs возвращает задачи передачи ревьюеров провайдеру, новые первыми;
+	// status failed — очередь задач, которые не удалось выполнить
+	ListSyncs(ctx context.Context, status string, limit int) (*models.ReviewerSyncListResponse, error)
+	// RetrySync возвращает задачу из failed в очередь с новым счётчиком попыток
+	RetrySync(ctx context.Context, syncID string) (*models.ReviewerSyncResponse, error)
 }
@@ -0,0 +1,406 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/auth"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/codehost"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/errors"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/repositories"
+)
+
+// Action — действие над ресурсами команды, которое проверяет политика доступа
+type Action string
+
+const (
+	ActionViewTeam Action = "team.view"
+	// ActionManageTeam — активация участников и назначение ролей
+	ActionManageTeam Action = "team.manage"
+	// ActionViewReviews — просмотр чужих ревью в команде
+	ActionViewReviews Action = "team.view_reviews"
+	ActionCreatePR    Action = "pr.create"
+	ActionMergePR     Action = "pr.merge"
+	ActionReassign    Action = "pr.reassign"
+	// ActionReassignSelf — снять с ревью самого себя
+	ActionReassignSelf Action = "pr.reassign_self"
+	// ActionManageWebhooks — вебхуки команды и история их доставок
+	ActionManageWebhooks Action = "webhooks.manage"
+)
+
+// rolePermissions — что разрешено роли в её команде. Админу разрешено всё.
+var rolePermissions = map[string][]Action{
+	auth.RoleLead:   {ActionViewTeam, ActionManageTeam, ActionViewReviews, ActionReassign, ActionReassignSelf, ActionManageWebhooks},
+	auth.RoleMember: {ActionViewTeam, ActionReassignSelf},
+	auth.RoleBot:    {ActionViewTeam, ActionCreatePR, ActionMergePR},
+}
+
+// Allowed сообщает, разрешено ли роли действие
+func Allowed(role string, action Action) bool {
+	return slices.Contains(rolePermissions[role], action)
+}
+
+// policy — общие проверки декораторов policyService и webhookPolicyService.
+// Без principal (аутентификация выключена) и для админа пропускает всё.
+type policy struct {
+	repo *repositories.Repository
+}
+
+// policyService проверяет права principal из контекста перед вызовом next
+type policyService struct {
+	policy
+	next ReviewService
+}
+
+func NewPolicyService(next ReviewService, repo *repositories.Repository) ReviewService {
+	return &policyService{policy: policy{repo: repo}, next: next}
+}
+
+// roleIn возвращает роль principal в команде: из claims JWT, явную из team_roles,
+// member для участника команды без записи и "" для остальных.
+func (p *policy) roleIn(ctx context.Context, team *models.Team, principal *auth.Principal) (string, error) {
+	if role, ok := principal.Roles[team.Name]; ok {
+		return role, nil
+	}
+	userID := principal.UserID
+	if userID == "" {
+		return "", nil
+	}
+	role, err := p.repo.Roles.GetRole(ctx, team.ID, userID)
+	if err == nil {
+		return role.Role, nil
+	}
+	if !errors.IsNotFound(err) {
+		return "", err
+	}
+	user, err := p.repo.User.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	if user.TeamID == team.ID {
+		return auth.RoleMember, nil
+	}
+	return "", nil
+}
+
+// authorize проверяет действие над командой, которую возвращает team.
+// Если ресурса нет, решение оставляем сервису: он ответит NOT_FOUND.
+func (p *policy) authorize(ctx context.Context, action Action, team func() (*models.Team, error)) error {
+	principal := auth.FromContext(ctx)
+	if principal == nil || principal.IsAdmin() {
+		return nil
+	}
+	t, err := team()
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	role, err := p.roleIn(ctx, t, principal)
+	if err != nil {
+		return err
+	}
+	if !Allowed(role, action) {
+		return errors.NewForbidden()
+	}
+	return nil
+}
+
+func requireAdmin(ctx context.Context) error {
+	if principal := auth.FromContext(ctx); principal != nil && !principal.IsAdmin() {
+		return errors.NewForbidden()
+	}
+	return nil
+}
+
+func (p *policy) teamByName(ctx context.Context, name string) func() (*models.Team, error) {
+	return func() (*models.Team, error) {
+		return p.repo.Team.GetTeamByName(ctx, name)
+	}
+}
+
+func (p *policy) teamOfUser(ctx context.Context, userID string) func() (*models.Team, error) {
+	return func() (*models.Team, error) {
+		return p.repo.User.GetUserTeam(ctx, userID)
+	}
+}
+
+// teamOfPR — команда автора PR
+func (p *policy) teamOfPR(ctx context.Context, prID string) func() (*models.Team, error) {
+	return func() (*models.Team, error) {
+		pr, err := p.repo.PR.GetPRByID(ctx, prID)
+		if err != nil {
+			return nil, err
+		}
+		return p.repo.User.GetUserTeam(ctx, pr.AuthorID)
+	}
+}
+
+func (s *policyService) CreateTeam(ctx context.Context, teamName string, members []models.TeamMember) (*models.TeamResponse, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	return s.next.CreateTeam(ctx, teamName, members)
+}
+
+func (s *policyService) GetTeam(ctx context.Context, teamName string) (*models.TeamResponse, error) {
+	if err := s.authorize(ctx, ActionViewTeam, s.teamByName(ctx, teamName)); err != nil {
+		return nil, err
+	}
+	return s.next.GetTeam(ctx, teamName)
+}
+
+func (s *policyService) SetTeamRole(ctx context.Context, teamName, userID, role string) (*models.TeamRoleResponse, error) {
+	if err := s.authorize(ctx, ActionManageTeam, s.teamByName(ctx, teamName)); err != nil {
+		return nil, err
+	}
+	return s.next.SetTeamRole(ctx, teamName, userID, role)
+}
+
+func (s *policyService) SetUserActive(ctx context.Context, userID string, isActive bool) (*models.UserResponse, error) {
+	if err := s.authorize(ctx, ActionManageTeam, s.teamOfUser(ctx, userID)); err != nil {
+		return nil, err
+	}
+	return s.next.SetUserActive(ctx, userID, isActive)
+}
+
+func (s *policyService) GetUserReviews(ctx context.Context, userID string) (*models.UserPRsResponse, error) {
+	// Свои ревью видны всем, чужие — админу и лиду команды
+	if principal := auth.FromContext(ctx); principal == nil || principal.UserID != userID {
+		if err := s.authorize(ctx, ActionViewReviews, s.teamOfUser(ctx, userID)); err != nil {
+			return nil, err
+		}
+	}
+	return s.next.GetUserReviews(ctx, userID)
+}
+
+func (s *policyService) CreatePR(ctx context.Context, prID, title, authorID string) (*models.PullRequestShort, error) {
+	if err := s.authorize(ctx, ActionCreatePR, s.teamOfUser(ctx, authorID)); err != nil {
+		return nil, err
+	}
+	return s.next.CreatePR(ctx, prID, title, authorID)
+}
+
+// BatchCreatePRs требует права на создание PR для каждого автора пачки
+func (s *policyService) BatchCreatePRs(ctx context.Context, items []models.CreatePRRequest, mode string) (*models.BatchCreateResponse, error) {
+	checked := make(map[string]bool)
+	for _, item := range items {
+		if checked[item.AuthorID] {
+			continue
+		}
+		checked[item.AuthorID] = true
+		if err := s.authorize(ctx, ActionCreatePR, s.teamOfUser(ctx, item.AuthorID)); err != nil {
+			return nil, err
+		}
+	}
+	return s.next.BatchCreatePRs(ctx, items, mode)
+}
+
+func (s *policyService) GetPR(ctx context.Context, prID string) (*models.PullRequestResponse, error) {
+	if err := s.authorize(ctx, ActionViewTeam, s.teamOfPR(ctx, prID)); err != nil {
+		return nil, err
+	}
+	return s.next.GetPR(ctx, prID)
+}
+
+func (s *policyService) MergePR(ctx context.Context, prID string, expectedVersion int) (*models.PullRequestResponse, error) {
+	if err := s.authorize(ctx, ActionMergePR, s.teamOfPR(ctx, prID)); err != nil {
+		return nil, err
+	}
+	return s.next.MergePR(ctx, prID, expectedVersion)
+}
+
+func (s *policyService) ClosePR(ctx context.Context, prID string) (*models.PullRequestResponse, error) {
+	if err := s.authorize(ctx, ActionMergePR, s.teamOfPR(ctx, prID)); err != nil {
+		return nil, err
+	}
+	return s.next.ClosePR(ctx, prID)
+}
+
+func (s *policyService) ReopenPR(ctx context.Context, prID string) (*models.PullRequestResponse, error) {
+	if err := s.authorize(ctx, ActionMergePR, s.teamOfPR(ctx, prID)); err != nil {
+		return nil, err
+	}
+	return s.next.ReopenPR(ctx, prID)
+}
+
+func (s *policyService) MarkDraft(ctx context.Context, prID string) (*models.PullRequestResponse, error) {
+	if err := s.authorize(ctx, ActionMergePR, s.teamOfPR(ctx, prID)); err != nil {
+		return nil, err
+	}
+	return s.next.MarkDraft(ctx, prID)
+}
+
+func (s *policyService) MarkReady(ctx context.Context, prID string) (*models.PullRequestResponse, error) {
+	if err := s.authorize(ctx, ActionMergePR, s.teamOfPR(ctx, prID)); err != nil {
+		return nil, err
+	}
+	return s.next.MarkReady(ctx, prID)
+}
+
+func (s *policyService) ReassignReviewer(ctx context.Context, prID, oldReviewerID string, expectedVersion int) (*models.ReassignResponse, error) {
+	action := ActionReassign
+	if principal := auth.FromContext(ctx); principal != nil && principal.UserID == oldReviewerID {
+		action = ActionReassignSelf
+	}
+	if err := s.authorize(ctx, action, s.teamOfPR(ctx, prID)); err != nil {
+		return nil, err
+	}
+	return s.next.ReassignReviewer(ctx, prID, oldReviewerID, expectedVersion)
+}
+
+func (s *policyService) GetTeamStats(ctx context.Context, teamName string) (*models.TeamStatsResponse, error) {
+	if err := s.authorize(ctx, ActionViewTeam, s.teamByName(ctx, teamName)); err != nil {
+		return nil, err
+	}
+	return s.next.GetTeamStats(ctx, teamName)
+}
+
+// GetAssignmentStats по всем командам доступна только админу
+func (s *policyService) GetAssignmentStats(ctx context.Context, from, to *time.Time, teamName string) (*models.AssignmentStatsResponse, error) {
+	if teamName == "" {
+		if err := requireAdmin(ctx); err != nil {
+			return nil, err
+		}
+	} else if err := s.authorize(ctx, ActionViewTeam, s.teamByName(ctx, teamName)); err != nil {
+		return nil, err
+	}
+	return s.next.GetAssignmentStats(ctx, from, to, teamName)
+}
+
+// webhookPolicyService пускает к вебхукам команды админа и её лида
+type webhookPolicyService struct {
+	policy
+	next WebhookService
+}
+
+func NewWebhookPolicyService(next WebhookService, repo *repositories.Repository) WebhookService {
+	return &webhookPolicyService{policy: policy{repo: repo}, next: next}
+}
+
+// teamOfWebhook — команда, которой принадлежит вебхук
+func (p *policy) teamOfWebhook(ctx context.Context, webhookID string) func() (*models.Team, error) {
+	return func() (*models.Team, error) {
+		webhook, err := p.repo.Webhooks.GetWebhook(ctx, webhookID)
+		if err != nil {
+			return nil, err
+		}
+		return p.repo.Team.GetTeamByID(ctx, webhook.TeamID)
+	}
+}
+
+func (s *webhookPolicyService) CreateWebhook(ctx context.Context, teamName, url string, eventTypes []string) (*models.CreateWebhookResponse, error) {
+	if err := s.authorize(ctx, ActionManageWebhooks, s.teamByName(ctx, teamName)); err != nil {
+		return nil, err
+	}
+	return s.next.CreateWebhook(ctx, teamName, url, eventTypes)
+}
+
+func (s *webhookPolicyService) ListWebhooks(ctx context.Context, teamName string) (*models.WebhookListResponse, error) {
+	if err := s.authorize(ctx, ActionManageWebhooks, s.teamByName(ctx, teamName)); err != nil {
+		return nil, err
+	}
+	return s.next.ListWebhooks(ctx, teamName)
+}
+
+func (s *webhookPolicyService) DeleteWebhook(ctx context.Context, webhookID string) error {
+	if err := s.authorize(ctx, ActionManageWebhooks, s.teamOfWebhook(ctx, webhookID)); err != nil {
+		return err
+	}
+	return s.next.DeleteWebhook(ctx, webhookID)
+}
+
+func (s *webhookPolicyService) ListDeliveries(ctx context.Context, webhookID string, limit int) (*models.WebhookDeliveriesResponse, error) {
+	if err := s.authorize(ctx, ActionManageWebhooks, s.teamOfWebhook(ctx, webhookID)); err != nil {
+		return nil, err
+	}
+	return s.next.ListDeliveries(ctx, webhookID, limit)
+}
+
+// eventStreamPolicyService пускает к событиям команды её участников,
+// а к событиям чужого пользователя — тех, кто видит его ревью
+type eventStreamPolicyService struct {
+	policy
+	next EventStreamService
+}
+
+func NewEventStreamPolicyService(next EventStreamService, repo *repositories.Repository) EventStreamService {
+	return &eventStreamPolicyService{policy: policy{repo: repo}, next: next}
+}
+
+func (s *eventStreamPolicyService) OpenStream(ctx context.Context, teamName, userID string) (EventFilter, error) {
+	var err error
+	switch principal := auth.FromContext(ctx); {
+	case userID == "":
+		err = s.authorize(ctx, ActionViewTeam, s.teamByName(ctx, teamName))
+	case principal == nil || principal.UserID != userID:
+		err = s.authorize(ctx, ActionViewReviews, s.teamOfUser(ctx, userID))
+	}
+	if err != nil {
+		return EventFilter{}, err
+	}
+	return s.next.OpenStream(ctx, teamName, userID)
+}
+
+func (s *eventStreamPolicyService) Events(ctx context.Context, filter EventFilter, afterID uint, limit int) ([]models.EventLogEntry, uint, error) {
+	return s.next.Events(ctx, filter, afterID, limit)
+}
+
+func (s *eventStreamPolicyService) LastEventID(ctx context.Context) (uint, error) {
+	return s.next.LastEventID(ctx)
+}
+
+// codeHostPolicyService пускает к привязке логинов только админа.
+// Ingest вызывается без principal — подлинность события проверяет обработчик вебхука.
+type codeHostPolicyService struct {
+	next CodeHostService
+}
+
+func NewCodeHostPolicyService(next CodeHostService) CodeHostService {
+	return &codeHostPolicyService{next: next}
+}
+
+func (s *codeHostPolicyService) Ingest(ctx context.Context, ev codehost.Event) (*models.IngestResult, error) {
+	return s.next.Ingest(ctx, ev)
+}
+
+func (s *codeHostPolicyService) SetAccount(ctx context.Context, provider, login, userID string) (*models.CodeHostAccountResponse, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	return s.next.SetAccount(ctx, provider, login, userID)
+}
+
+func (s *codeHostPolicyService) ListAccounts(ctx context.Context, provider string) (*models.CodeHostAccountListResponse, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	return s.next.ListAccounts(ctx, provider)
+}
+
+func (s *codeHostPolicyService) DeleteAccount(ctx context.Context, provider, login string) error {
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+	return s.next.DeleteAccount(ctx, provider, login)
+}
+
+func (s *codeHostPolicyService) ListSyncs(ctx context.Context, status string, limit int) (*models.ReviewerSyncListResponse, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	return s.next.ListSyncs(ctx, status, limit)
+}
+
+func (s *codeHostPolicyService) RetrySync(ctx context.Context, syncID string) (*models.ReviewerSyncResponse, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	return s.next.RetrySync(ctx, syncID)
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/auth"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/errors"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllowed(t *testing.T) {
+	assert.True(t, Allowed(auth.RoleLead, ActionManageTeam))
+	assert.True(t, Allowed(auth.RoleMember, ActionReassignSelf))
+	assert.False(t, Allowed(auth.RoleMember, ActionReassign))
+	assert.True(t, Allowed(auth.RoleBot, ActionMergePR))
+	assert.False(t, Allowed(auth.RoleBot, ActionManageTeam))
+	assert.False(t, Allowed("", ActionViewTeam))
+}
+
+func TestPolicyService_TeamRoles(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	policy := NewPolicyService(s, s.repo)
+
+	_, err := s.CreateTeam(ctx, "backend", []models.TeamMember{
+		{UserId: "lead", Username: "Lead", IsActive: true},
+		{UserId: "author", Username: "Author", IsActive: true},
+		{UserId: "u1", Username: "Alice", IsActive: true},
+		{UserId: "u2", Username: "Bob", IsActive: true},
+		{UserId: "ci", Username: "CI", IsActive: true},
+	})
+	require.NoError(t, err)
+	// Бота выключаем, чтобы он не попадал в ревьюеры
+	_, err = s.SetUserActive(ctx, "ci", false)
+	require.NoError(t, err)
+	_, err = s.CreateTeam(ctx, "frontend", []models.TeamMember{{UserId: "f1", Username: "Frank", IsActive: true}})
+	require.NoError(t, err)
+
+	admin := auth.WithPrincipal(ctx, &auth.Principal{TokenID: "tok-admin", Scope: auth.ScopeAdmin})
+	as := func(userID string) context.Context {
+		return auth.WithPrincipal(ctx, &auth.Principal{TokenID: "tok-" + userID, Scope: auth.ScopeUser, UserID: userID})
+	}
+	forbidden := func(t *testing.T, err error) {
+		t.Helper()
+		assert.True(t, errors.IsForbidden(err), "expected FORBIDDEN, got %v", err)
+	}
+
+	_, err = policy.SetTeamRole(admin, "backend", "lead", auth.RoleLead)
+	require.NoError(t, err)
+	_, err = policy.SetTeamRole(as("lead"), "backend", "ci", auth.RoleBot)
+	require.NoError(t, err)
+	_, err = policy.SetTeamRole(as("u1"), "backend", "u1", auth.RoleLead)
+	forbidden(t, err)
+	_, err = policy.SetTeamRole(admin, "backend", "u1", "owner")
+	assert.True(t, errors.IsInvalidInput(err))
+	// Роль выдаётся только участнику команды, даже лидом этой команды
+	_, err = policy.SetTeamRole(as("lead"), "backend", "f1", auth.RoleLead)
+	assert.True(t, errors.IsInvalidInput(err))
+	_, err = policy.GetTeam(as("f1"), "backend")
+	forbidden(t, err)
+
+	t.Run("view", func(t *testing.T) {
+		_, err := policy.GetTeam(as("u1"), "backend")
+		assert.NoError(t, err)
+		_, err = policy.GetTeam(as("f1"), "backend")
+		forbidden(t, err)
+		// Несуществующую команду решает сервис
+		_, err = policy.GetTeam(as("u1"), "missing")
+		assert.True(t, errors.IsNotFound(err))
+		_, err = policy.GetAssignmentStats(as("u1"), nil, nil, "")
+		forbidden(t, err)
+		_, err = policy.GetAssignmentStats(as("u1"), nil, nil, "backend")
+		assert.NoError(t, err)
+	})
+
+	t.Run("membership", func(t *testing.T) {
+		_, err := policy.SetUserActive(as("u1"), "u2", false)
+		forbidden(t, err)
+		_, err = policy.SetUserActive(as("lead"), "f1", false)
+		forbidden(t, err)
+		_, err = policy.SetUserActive(as("lead"), "u2", true)
+		assert.NoError(t, err)
+		_, err = policy.CreateTeam(as("lead"), "mobile", nil)
+		forbidden(t, err)
+	})
+
+	t.Run("reviews", func(t *testing.T) {
+		_, err := policy.GetUserReviews(as("u1"), "u1")
+		assert.NoError(t, err)
+		_, err = policy.GetUserReviews(as("u1"), "u2")
+		forbidden(t, err)
+		_, err = policy.GetUserReviews(as("lead"), "u2")
+		assert.NoError(t, err)
+	})
+
+	t.Run("pull requests", func(t *testing.T) {
+		_, err := policy.CreatePR(as("u1"), "pr-1", "feature", "author")
+		forbidden(t, err)
+		_, err = policy.BatchCreatePRs(as("ci"), []models.CreatePRRequest{
+			{PullRequestID: "pr-2", PullRequestName: "feature", AuthorID: "author"},
+			{PullRequestID: "pr-3", PullRequestName: "feature", AuthorID: "f1"},
+		}, BatchModeAtomic)
+		forbidden(t, err)
+
+		pr, err := policy.CreatePR(as("ci"), "pr-1", "feature", "author")
+		require.NoError(t, err)
+		require.NotEmpty(t, pr.AssignedReviewers)
+		reviewer := pr.AssignedReviewers[0]
+
+		// Участник может снять с ревью только себя
+		other := "u1"
+		if reviewer == other {
+			other = "u2"
+		}
+		_, err = policy.ReassignReviewer(as(other), "pr-1", reviewer, 0)
+		forbidden(t, err)
+		_, err = policy.ReassignReviewer(as(reviewer), "pr-1", reviewer, 0)
+		assert.NoError(t, err)
+
+		// PR с версией видят участники команды автора, но не чужие
+		_, err = policy.GetPR(as("u1"), "pr-1")
+		assert.NoError(t, err)
+		_, err = policy.GetPR(as("f1"), "pr-1")
+		forbidden(t, err)
+
+		_, err = policy.MergePR(as("lead"), "pr-1", 0)
+		forbidden(t, err)
+		_, err = policy.MergePR(as("ci"), "pr-1", 0)
+		assert.NoError(t, err)
+	})
+
+	t.Run("roles from jwt", func(t *testing.T) {
+		sso := auth.WithPrincipal(ctx, &auth.Principal{
+			TokenID: "jwt", Scope: auth.ScopeUser, UserID: "f1",
+			Roles: map[string]string{"backend": auth.RoleLead},
+		})
+		_, err := policy.SetUserActive(sso, "u2", true)
+		assert.NoError(t, err)
+		// Роль из claims важнее членства в команде
+		_, err = policy.SetUserActive(auth.WithPrincipal(ctx, &auth.Principal{
+			TokenID: "jwt", Scope: auth.ScopeUser, UserID: "lead",
+			Roles: map[string]string{"backend": auth.RoleMember},
+		}), "u2", true)
+		forbidden(t, err)
+	})
+
+	t.Run("without auth", func(t *testing.T) {
+		_, err := policy.GetAssignmentStats(ctx, nil, nil, "")
+		assert.NoError(t, err)
+	})
+}
@@ -1,33 +1,69 @@
 package services
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 	"slices"
+	"sort"
 	"time"
 
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/auth"
 	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/errors"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/events"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/logger"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/metrics"
 	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/outbox"
 	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/repositories"
-	"github.com/lib/pq"
+)
+
+// DefaultMaxReviewers — сколько ревьюеров назначается на PR по умолчанию
+const DefaultMaxReviewers = 2
+
+// Стратегии выбора ревьюеров
+const (
+	// StrategyRandom выбирает случайных активных участников команды
+	StrategyRandom = "random"
+	// StrategyLeastLoaded выбирает участников с наименьшим числом открытых ревью
+	StrategyLeastLoaded = "least_loaded"
 )
 
 type reviewService struct {
-	repo *repositories.Repository
+	repo         *repositories.Repository
+	maxReviewers int
+	strategy     string
 }
 
-func NewReviewService(repo *repositories.Repository) ReviewService {
-	return &reviewService{repo: repo}
+type Option func(*reviewService)
+
+// WithMaxReviewers задаёт, сколько ревьюеров назначается на PR
+func WithMaxReviewers(n int) Option {
+	return func(s *reviewService) {
+		s.maxReviewers = n
+	}
 }
 
-func (s *reviewService) CreateTeam(teamName string, members []models.TeamMember) (*models.TeamResponse, error) {
-	exists, err := s.repo.Team.TeamExists(teamName)
-	if err != nil {
-		return nil, err
+// WithStrategy задаёт стратегию выбора ревьюеров
+func WithStrategy(strategy string) Option {
+	return func(s *reviewService) {
+		s.strategy = strategy
 	}
-	if exists {
-		return nil, errors.NewTeamExists(teamName)
+}
+
+func NewReviewService(repo *repositories.Repository, opts ...Option) ReviewService {
+	s := &reviewService{
+		repo:         repo,
+		maxReviewers: DefaultMaxReviewers,
+		strategy:     StrategyRandom,
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
+}
+
+func (s *reviewService) CreateTeam(ctx context.Context, teamName string, members []models.TeamMember) (*models.TeamResponse, error) {
 	team := &models.Team{
 		ID:   generateID(),
 		Name: teamName,
@@ -40,7 +76,16 @@ func (s *reviewService) CreateTeam(teamName string, members []models.TeamMember)
 			IsActive: member.IsActive,
 		}
 	}
-	err = s.repo.Team.CreateTeam(team, users)
+	err := s.repo.WithTx(ctx, func(r *repositories.Repository) error {
+		exists, err := r.Team.TeamExists(ctx, teamName)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return errors.NewTeamExists(teamName)
+		}
+		return r.Team.CreateTeam(ctx, team, users)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -50,15 +95,16 @@ func (s *reviewService) CreateTeam(teamName string, members []models.TeamMember)
 	}, nil
 }
 
-func (s *reviewService) GetTeam(teamName string) (*models.TeamResponse, error) {
-	team, err := s.repo.Team.GetTeamByName(teamName)
+func (s *reviewService) GetTeam(ctx context.Context, teamName string) (*models.TeamResponse, error) {
+	repo := s.repo.ReadOnly()
+	team, err := repo.Team.GetTeamByName(ctx, teamName)
 	if err != nil {
 		return nil, err
 	}
 	if team == nil {
 		return nil, errors.NewNotFound()
 	}
-	users, err := s.repo.Team.GetTeamUsers(team.ID)
+	users, err := repo.Team.GetTeamUsers(ctx, team.ID)
 	if err != nil {
 		return nil, err
 	}
@@ -76,23 +122,63 @@ func (s *reviewService) GetTeam(teamName string) (*models.TeamResponse, error) {
 	}, nil
 }
 
-func (s *reviewService) SetUserActive(userID string, isActive bool) (*models.UserResponse, error) {
-	user, err := s.repo.User.GetUserByID(userID)
+func (s *reviewService) SetTeamRole(ctx context.Context, teamName, userID, role string) (*models.TeamRoleResponse, error) {
+	if !auth.ValidRole(role) {
+		return nil, errors.NewInvalidInput("role must be lead, member or bot")
+	}
+	team, err := s.repo.Team.GetTeamByName(ctx, teamName)
 	if err != nil {
 		return nil, err
 	}
-	if user == nil {
-		return nil, errors.NewNotFound()
-	}
-	err = s.repo.User.UpdateUser(userID, isActive)
+	user, err := s.repo.User.GetUserByID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
-	user, err = s.repo.User.GetUserByID(userID)
-	if err != nil {
+	// Явная роль важнее членства, поэтому роль в чужой команде дала бы права на неё
+	if user.TeamID != team.ID {
+		return nil, errors.NewInvalidInput(fmt.Sprintf("user %s is not a member of team %s", userID, team.Name))
+	}
+	if err := s.repo.Roles.SetRole(ctx, &models.TeamRole{TeamID: team.ID, UserID: userID, Role: role}); err != nil {
 		return nil, err
 	}
-	team, err := s.repo.User.GetUserTeam(userID)
+	return &models.TeamRoleResponse{
+		TeamName: team.Name,
+		UserID:   userID,
+		Role:     role,
+	}, nil
+}
+
+func (s *reviewService) SetUserActive(ctx context.Context, userID string, isActive bool) (*models.UserResponse, error) {
+	var (
+		user *models.User
+		team *models.Team
+	)
+	err := s.repo.WithTx(ctx, func(r *repositories.Repository) error {
+		var err error
+		user, err = r.User.GetUserByID(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			return errors.NewNotFound()
+		}
+		deactivated := user.IsActive && !isActive
+		if err = r.User.UpdateUser(ctx, userID, isActive); err != nil {
+			return err
+		}
+		user, err = r.User.GetUserByID(ctx, userID)
+		if err != nil {
+			return err
+		}
+		team, err = r.User.GetUserTeam(ctx, userID)
+		if err != nil || !deactivated {
+			return err
+		}
+		return outbox.Append(ctx, r, events.New(events.TypeUserDeactivated, team.Name, user.ID, events.UserData{
+			UserID:   user.ID,
+			Username: user.Username,
+		}))
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -104,15 +190,35 @@ func (s *reviewService) SetUserActive(userID string, isActive bool) (*models.Use
 	}, nil
 }
 
-func (s *reviewService) CreatePR(prID, title, authorID string) (*models.PullRequestShort, error) {
-	author, err := s.repo.User.GetUserByID(authorID)
+func (s *reviewService) CreatePR(ctx context.Context, prID, title, authorID string) (*models.PullRequestShort, error) {
+	var pr *models.PullRequest
+	err := s.repo.WithTx(ctx, func(r *repositories.Repository) error {
+		var err error
+		pr, err = s.createPR(ctx, r, prID, title, authorID, nil)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	metrics.PRCreated(len(pr.Reviewers))
+	return s.convertPRToShort(pr), nil
+}
+
+// createPR создаёт PR, назначает на него ревьюеров и пишет события об этом в outbox.
+// load — сколько ревью уже назначено каждому пользователю в текущем пакете, nil вне пакета.
+func (s *reviewService) createPR(ctx context.Context, r *repositories.Repository, prID, title, authorID string, load map[string]int) (*models.PullRequest, error) {
+	author, err := r.User.GetUserByID(ctx, authorID)
 	if err != nil {
 		return nil, err
 	}
 	if author == nil {
 		return nil, errors.NewNotFound()
 	}
-	reviewers, err := s.autoAssignReviewers(authorID)
+	team, err := r.User.GetUserTeam(ctx, authorID)
+	if err != nil {
+		return nil, err
+	}
+	reviewers, err := s.autoAssignReviewers(ctx, r, team, authorID, load)
 	if err != nil {
 		return nil, err
 	}
@@ -121,27 +227,34 @@ func (s *reviewService) CreatePR(prID, title, authorID string) (*models.PullRequ
 		Title:     title,
 		AuthorID:  authorID,
 		Status:    "OPEN",
-		Reviewers: pq.StringArray(reviewers),
+		Reviewers: models.StringArray(reviewers),
 	}
-	err = s.repo.PR.CreatePR(pr)
-	if err != nil {
+	if err := r.PR.CreatePR(ctx, pr); err != nil {
 		return nil, err
 	}
-	return &models.PullRequestShort{
-		PullRequestId:     pr.ID,
-		PullRequestName:   pr.Title,
-		AuthorId:          pr.AuthorID,
-		Status:            pr.Status,
-		AssignedReviewers: pr.Reviewers,
-	}, nil
-}
-
-func (s *reviewService) autoAssignReviewers(authorID string) ([]string, error) {
-	team, err := s.repo.User.GetUserTeam(authorID)
-	if err != nil {
+	evs := []events.Event{events.New(events.TypePRCreated, team.Name, pr.ID, events.PullRequestData{
+		PullRequest: s.convertPRToResponse(pr),
+	})}
+	for _, reviewer := range pr.Reviewers {
+		evs = append(evs, reviewerAssigned(team, pr, reviewer))
+	}
+	if err := outbox.Append(ctx, r, evs...); err != nil {
 		return nil, err
 	}
-	activeUsers, err := s.repo.User.GetActiveUsersByTeam(team.ID)
+	return pr, nil
+}
+
+func reviewerAssigned(team *models.Team, pr *models.PullRequest, reviewerID string) events.Event {
+	return events.New(events.TypeReviewerAssigned, team.Name, pr.ID, events.ReviewerData{
+		PullRequestID:   pr.ID,
+		PullRequestName: pr.Title,
+		AuthorID:        pr.AuthorID,
+		ReviewerID:      reviewerID,
+	})
+}
+
+func (s *reviewService) autoAssignReviewers(ctx context.Context, r *repositories.Repository, team *models.Team, authorID string, load map[string]int) ([]string, error) {
+	activeUsers, err := r.User.GetActiveUsersByTeam(ctx, team.ID)
 	if err != nil {
 		return nil, err
 	}
@@ -154,59 +267,212 @@ func (s *reviewService) autoAssignReviewers(authorID string) ([]string, error) {
 	if len(candidates) == 0 {
 		return []string{}, nil
 	}
-	r := rand.New(rand.NewSource(time.Now().UnixNano()))
-	r.Shuffle(len(candidates), func(i, j int) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	rnd.Shuffle(len(candidates), func(i, j int) {
 		candidates[i], candidates[j] = candidates[j], candidates[i]
 	})
-	if len(candidates) > 2 {
-		return candidates[:2], nil
+	// Открытые ревью считаются внутри транзакции, поэтому уже включают PR из текущего пакета
+	if s.strategy == StrategyLeastLoaded {
+		load, err = s.openReviewLoad(ctx, r, team.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// Сначала берём наименее загруженных, при равенстве — в случайном порядке
+	if load != nil {
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return load[candidates[i]] < load[candidates[j]]
+		})
+	}
+	if len(candidates) > s.maxReviewers {
+		return candidates[:s.maxReviewers], nil
 	}
 	return candidates, nil
 }
 
-func (s *reviewService) MergePR(prID string) (*models.PullRequestResponse, error) {
-	pr, err := s.repo.PR.GetPRByID(prID)
+// openReviewLoad возвращает число открытых ревью у каждого участника команды
+func (s *reviewService) openReviewLoad(ctx context.Context, r *repositories.Repository, teamID string) (map[string]int, error) {
+	members, err := r.Stats.TeamMemberStats(ctx, teamID)
 	if err != nil {
 		return nil, err
 	}
-	if pr.Status == "MERGED" {
-		return s.convertPRToResponse(pr), nil
-	}
-	now := time.Now()
-	pr.Status = "MERGED"
-	pr.MergedAt = &now
-	err = s.repo.PR.UpdatePR(pr)
-	if err != nil {
-		return nil, err
+	load := make(map[string]int, len(members))
+	for _, member := range members {
+		load[member.UserID] = member.OpenReviews
 	}
-	return s.convertPRToResponse(pr), nil
+	return load, nil
 }
 
-func (s *reviewService) ReassignReviewer(prID, oldReviewerID string) (*models.ReassignResponse, error) {
-	pr, err := s.repo.PR.GetPRByID(prID)
+func (s *reviewService) MergePR(ctx context.Context, prID string, expectedVersion int) (*models.PullRequestResponse, error) {
+	var (
+		pr     *models.PullRequest
+		merged bool
+	)
+	err := s.repo.WithTx(ctx, func(r *repositories.Repository) error {
+		var err error
+		pr, err = r.PR.GetPRByIDForUpdate(ctx, prID)
+		if err != nil {
+			return err
+		}
+		if expectedVersion != 0 && pr.Version != expectedVersion {
+			return errors.NewConflict()
+		}
+		if pr.Status == "MERGED" {
+			return nil
+		}
+		if pr.Status == "CLOSED" {
+			return errors.NewPRClosed()
+		}
+		team, err := r.User.GetUserTeam(ctx, pr.AuthorID)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		pr.Status = "MERGED"
+		pr.MergedAt = &now
+		merged = true
+		if err := r.PR.UpdatePR(ctx, pr); err != nil {
+			return err
+		}
+		// Событие пишется только при реальном merge: повтор ничего не меняет
+		return outbox.Append(ctx, r, events.New(events.TypePRMerged, team.Name, pr.ID, events.PullRequestData{
+			PullRequest: s.convertPRToResponse(pr),
+		}))
+	})
 	if err != nil {
 		return nil, err
 	}
-	if pr.Status == "MERGED" {
-		return nil, errors.NewPRMerged()
+	if merged {
+		metrics.PRMerged()
 	}
-	if !slices.Contains(pr.Reviewers, oldReviewerID) {
-		return nil, errors.NewNotAssigned()
-	}
-	newReviewerID, err := s.findReplacementReviewer(oldReviewerID, pr.Reviewers, pr.AuthorID)
+	return s.convertPRToResponse(pr), nil
+}
+
+func (s *reviewService) ClosePR(ctx context.Context, prID string) (*models.PullRequestResponse, error) {
+	return s.setPRStatus(ctx, prID, "CLOSED", events.TypePRClosed, "OPEN", "DRAFT")
+}
+
+func (s *reviewService) ReopenPR(ctx context.Context, prID string) (*models.PullRequestResponse, error) {
+	return s.setPRStatus(ctx, prID, "OPEN", events.TypePRReopened, "CLOSED")
+}
+
+func (s *reviewService) MarkDraft(ctx context.Context, prID string) (*models.PullRequestResponse, error) {
+	return s.setPRStatus(ctx, prID, "DRAFT", events.TypePRDrafted, "OPEN")
+}
+
+func (s *reviewService) MarkReady(ctx context.Context, prID string) (*models.PullRequestResponse, error) {
+	return s.setPRStatus(ctx, prID, "OPEN", events.TypePRReadyForReview, "DRAFT")
+}
+
+// setPRStatus переводит PR из одного из статусов from в to и пишет событие eventType.
+// PR уже в статусе to не меняется; смёрженный PR не меняется никогда, закрытый —
+// только открывается снова.
+func (s *reviewService) setPRStatus(ctx context.Context, prID, to, eventType string, from ...string) (*models.PullRequestResponse, error) {
+	var pr *models.PullRequest
+	err := s.repo.WithTx(ctx, func(r *repositories.Repository) error {
+		var err error
+		pr, err = r.PR.GetPRByIDForUpdate(ctx, prID)
+		if err != nil {
+			return err
+		}
+		switch {
+		case pr.Status == to:
+			return nil
+		case pr.Status == "MERGED":
+			return errors.NewPRMerged()
+		case pr.Status == "CLOSED" && !slices.Contains(from, "CLOSED"):
+			return errors.NewPRClosed()
+		case !slices.Contains(from, pr.Status):
+			return errors.NewInvalidInput(fmt.Sprintf("PR is %s", pr.Status))
+		}
+		team, err := r.User.GetUserTeam(ctx, pr.AuthorID)
+		if err != nil {
+			return err
+		}
+		pr.Status = to
+		if err := r.PR.UpdatePR(ctx, pr); err != nil {
+			return err
+		}
+		return outbox.Append(ctx, r, events.New(eventType, team.Name, pr.ID, events.PullRequestData{
+			PullRequest: s.convertPRToResponse(pr),
+		}))
+	})
 	if err != nil {
 		return nil, err
 	}
-	for i, reviewer := range pr.Reviewers {
-		if reviewer == oldReviewerID {
-			pr.Reviewers[i] = newReviewerID
-			break
+	return s.convertPRToResponse(pr), nil
+}
+
+func (s *reviewService) ReassignReviewer(ctx context.Context, prID, oldReviewerID string, expectedVersion int) (*models.ReassignResponse, error) {
+	var (
+		pr            *models.PullRequest
+		newReviewerID string
+	)
+	err := s.repo.WithTx(ctx, func(r *repositories.Repository) error {
+		var err error
+		pr, err = r.PR.GetPRByIDForUpdate(ctx, prID)
+		if err != nil {
+			return err
 		}
-	}
-	err = s.repo.PR.UpdatePR(pr)
+		if expectedVersion != 0 && pr.Version != expectedVersion {
+			return errors.NewConflict()
+		}
+		if pr.Status == "MERGED" {
+			return errors.NewPRMerged()
+		}
+		if pr.Status == "CLOSED" {
+			return errors.NewPRClosed()
+		}
+		if !slices.Contains(pr.Reviewers, oldReviewerID) {
+			return errors.NewNotAssigned()
+		}
+		team, err := r.User.GetUserTeam(ctx, pr.AuthorID)
+		if err != nil {
+			return err
+		}
+		newReviewerID, err = s.findReplacementReviewer(ctx, r, oldReviewerID, pr.Reviewers, pr.AuthorID)
+		if err != nil {
+			return err
+		}
+		for i, reviewer := range pr.Reviewers {
+			if reviewer == oldReviewerID {
+				pr.Reviewers[i] = newReviewerID
+				break
+			}
+		}
+		if err := r.PR.UpdatePR(ctx, pr); err != nil {
+			return err
+		}
+		err = r.PR.RecordReassignment(ctx, &models.Reassignment{
+			PullRequestID: pr.ID,
+			OldReviewerID: oldReviewerID,
+			NewReviewerID: newReviewerID,
+		})
+		if err != nil {
+			return err
+		}
+		return outbox.Append(ctx, r,
+			events.New(events.TypeReviewerReassigned, team.Name, pr.ID, events.ReassignmentData{
+				PullRequestID: pr.ID,
+				OldReviewerID: oldReviewerID,
+				NewReviewerID: newReviewerID,
+			}),
+			reviewerAssigned(team, pr, newReviewerID),
+		)
+	})
 	if err != nil {
+		if errors.IsNoCandidate(err) {
+			metrics.NoCandidate()
+			// Транзакция откатилась, поэтому событие пишем отдельно; его потеря не критична
+			event := &models.NoCandidateEvent{PullRequestID: prID, ReviewerID: oldReviewerID}
+			if recordErr := s.repo.PR.RecordNoCandidate(ctx, event); recordErr != nil {
+				logger.FromContext(ctx).Error("failed to record NO_CANDIDATE",
+					"pull_request_id", prID, "error", recordErr.Error())
+			}
+		}
 		return nil, err
 	}
+	metrics.ReviewerReassigned()
 	response := &models.ReassignResponse{
 		PR:         *s.convertPRToResponse(pr),
 		ReplacedBy: newReviewerID,
@@ -214,12 +480,12 @@ func (s *reviewService) ReassignReviewer(prID, oldReviewerID string) (*models.Re
 	return response, nil
 }
 
-func (s *reviewService) findReplacementReviewer(oldReviewerID string, currentReviewers []string, authorID string) (string, error) {
-	team, err := s.repo.User.GetUserTeam(oldReviewerID)
+func (s *reviewService) findReplacementReviewer(ctx context.Context, r *repositories.Repository, oldReviewerID string, currentReviewers []string, authorID string) (string, error) {
+	team, err := r.User.GetUserTeam(ctx, oldReviewerID)
 	if err != nil {
 		return "", err
 	}
-	activeUsers, err := s.repo.User.GetActiveUsersByTeam(team.ID)
+	activeUsers, err := r.User.GetActiveUsersByTeam(ctx, team.ID)
 	if err != nil {
 		return "", err
 	}
@@ -234,19 +500,20 @@ func (s *reviewService) findReplacementReviewer(oldReviewerID string, currentRev
 	if len(candidates) == 0 {
 		return "", errors.NewNoCandidate()
 	}
-	r := rand.New(rand.NewSource(time.Now().UnixNano()))
-	return candidates[r.Intn(len(candidates))], nil
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	return candidates[rnd.Intn(len(candidates))], nil
 }
 
-func (s *reviewService) GetUserReviews(userID string) (*models.UserPRsResponse, error) {
-	user, err := s.repo.User.GetUserByID(userID)
+func (s *reviewService) GetUserReviews(ctx context.Context, userID string) (*models.UserPRsResponse, error) {
+	repo := s.repo.ReadOnly()
+	user, err := repo.User.GetUserByID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
 	if user == nil {
 		return nil, errors.NewNotFound()
 	}
-	prs, err := s.repo.PR.GetPRsByReviewer(userID)
+	prs, err := repo.PR.GetPRsByReviewer(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -257,6 +524,7 @@ func (s *reviewService) GetUserReviews(userID string) (*models.UserPRsResponse,
 			PullRequestName: pr.Title,
 			AuthorId:        pr.AuthorID,
 			Status:          pr.Status,
+			Version:         pr.Version,
 		}
 	}
 	return &models.UserPRsResponse{
@@ -265,6 +533,27 @@ func (s *reviewService) GetUserReviews(userID string) (*models.UserPRsResponse,
 	}, nil
 }
 
+// GetPR читает PR из основной базы: версия с отстающей реплики дала бы
+// заведомо устаревший If-Match и ещё один 409
+func (s *reviewService) GetPR(ctx context.Context, prID string) (*models.PullRequestResponse, error) {
+	pr, err := s.repo.PR.GetPRByID(ctx, prID)
+	if err != nil {
+		return nil, err
+	}
+	return s.convertPRToResponse(pr), nil
+}
+
+func (s *reviewService) convertPRToShort(pr *models.PullRequest) *models.PullRequestShort {
+	return &models.PullRequestShort{
+		PullRequestId:     pr.ID,
+		PullRequestName:   pr.Title,
+		AuthorId:          pr.AuthorID,
+		Status:            pr.Status,
+		AssignedReviewers: pr.Reviewers,
+		Version:           pr.Version,
+	}
+}
+
 func (s *reviewService) convertPRToResponse(pr *models.PullRequest) *models.PullRequestResponse {
 	return &models.PullRequestResponse{
 		PullRequestId:     pr.ID,
@@ -273,6 +562,7 @@ func (s *reviewService) convertPRToResponse(pr *models.PullRequest) *models.Pull
 		Status:            pr.Status,
 		AssignedReviewers: pr.Reviewers,
 		MergedAt:          pr.MergedAt,
+		Version:           pr.Version,
 	}
 }
 
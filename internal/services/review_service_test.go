@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/errors"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/events"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreatePR_LeastLoadedStrategy(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t, WithStrategy(StrategyLeastLoaded), WithMaxReviewers(1))
+
+	_, err := s.CreateTeam(ctx, "backend", []models.TeamMember{
+		{UserId: "author", Username: "Author", IsActive: true},
+		{UserId: "u1", Username: "Alice", IsActive: true},
+		{UserId: "u2", Username: "Bob", IsActive: true},
+		{UserId: "u3", Username: "Carol", IsActive: true},
+	})
+	require.NoError(t, err)
+
+	// По одному PR за раз: каждый следующий достаётся наименее загруженному
+	load := make(map[string]int)
+	for i := 0; i < 6; i++ {
+		pr, err := s.CreatePR(ctx, fmt.Sprintf("pr-%d", i), "feature", "author")
+		require.NoError(t, err)
+		require.Len(t, pr.AssignedReviewers, 1)
+		load[pr.AssignedReviewers[0]]++
+	}
+	assert.Equal(t, map[string]int{"u1": 2, "u2": 2, "u3": 2}, load)
+
+	// Смёрженные PR не считаются нагрузкой: после мержа ревью Alice следующий PR её
+	for i := 0; i < 6; i++ {
+		pr, err := s.repo.PR.GetPRByID(ctx, fmt.Sprintf("pr-%d", i))
+		require.NoError(t, err)
+		if pr.Reviewers[0] == "u1" {
+			_, err = s.MergePR(ctx, pr.ID, 0)
+			require.NoError(t, err)
+		}
+	}
+	pr, err := s.CreatePR(ctx, "pr-next", "feature", "author")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"u1"}, pr.AssignedReviewers)
+}
+
+func TestClosePR(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+
+	_, err := s.CreateTeam(ctx, "backend", []models.TeamMember{
+		{UserId: "author", Username: "Author", IsActive: true},
+		{UserId: "u1", Username: "Alice", IsActive: true},
+		{UserId: "u2", Username: "Bob", IsActive: true},
+	})
+	require.NoError(t, err)
+	_, err = s.CreatePR(ctx, "pr-1", "feature", "author")
+	require.NoError(t, err)
+	pendingEvents(t, s)
+
+	pr, err := s.ClosePR(ctx, "pr-1")
+	require.NoError(t, err)
+	assert.Equal(t, "CLOSED", pr.Status)
+	// Повторное закрытие ничего не меняет и событий не пишет
+	_, err = s.ClosePR(ctx, "pr-1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{events.TypePRClosed}, eventTypes(pendingEvents(t, s)))
+
+	_, err = s.MergePR(ctx, "pr-1", 0)
+	assert.True(t, errors.IsPRClosed(err))
+	_, err = s.ReassignReviewer(ctx, "pr-1", pr.AssignedReviewers[0], 0)
+	assert.True(t, errors.IsPRClosed(err))
+
+	pr, err = s.ReopenPR(ctx, "pr-1")
+	require.NoError(t, err)
+	assert.Equal(t, "OPEN", pr.Status)
+	_, err = s.MergePR(ctx, "pr-1", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{events.TypePRReopened, events.TypePRMerged}, eventTypes(pendingEvents(t, s)))
+
+	_, err = s.ClosePR(ctx, "pr-1")
+	assert.True(t, errors.IsPRMerged(err))
+	_, err = s.ReopenPR(ctx, "missing")
+	assert.True(t, errors.IsNotFound(err))
+}
+
+func TestMarkDraft(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+
+	_, err := s.CreateTeam(ctx, "backend", []models.TeamMember{
+		{UserId: "author", Username: "Author", IsActive: true},
+		{UserId: "u1", Username: "Alice", IsActive: true},
+		{UserId: "u2", Username: "Bob", IsActive: true},
+	})
+	require.NoError(t, err)
+	_, err = s.CreatePR(ctx, "pr-1", "feature", "author")
+	require.NoError(t, err)
+	pendingEvents(t, s)
+
+	pr, err := s.MarkDraft(ctx, "pr-1")
+	require.NoError(t, err)
+	assert.Equal(t, "DRAFT", pr.Status)
+	_, err = s.MarkDraft(ctx, "pr-1")
+	require.NoError(t, err)
+	_, err = s.ReopenPR(ctx, "pr-1")
+	assert.True(t, errors.IsInvalidInput(err))
+	// Закрытый черновик открывается снова открытым PR
+	_, err = s.ClosePR(ctx, "pr-1")
+	require.NoError(t, err)
+	_, err = s.MarkReady(ctx, "pr-1")
+	assert.True(t, errors.IsPRClosed(err))
+	pr, err = s.ReopenPR(ctx, "pr-1")
+	require.NoError(t, err)
+	assert.Equal(t, "OPEN", pr.Status)
+
+	_, err = s.MarkDraft(ctx, "pr-1")
+	require.NoError(t, err)
+	// Черновик переназначается как открытый PR: свободных кандидатов в команде нет
+	_, err = s.ReassignReviewer(ctx, "pr-1", pr.AssignedReviewers[0], 0)
+	assert.True(t, errors.IsNoCandidate(err))
+	pr, err = s.MarkReady(ctx, "pr-1")
+	require.NoError(t, err)
+	assert.Equal(t, "OPEN", pr.Status)
+	assert.Equal(t, []string{
+		events.TypePRDrafted, events.TypePRClosed, events.TypePRReopened, events.TypePRDrafted, events.TypePRReadyForReview,
+	}, eventTypes(pendingEvents(t, s)))
+}
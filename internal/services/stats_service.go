@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
+)
+
+// Статистика — тяжёлые агрегаты, которым не нужна свежесть до миллисекунды, поэтому читаем из реплики
+func (s *reviewService) GetTeamStats(ctx context.Context, teamName string) (*models.TeamStatsResponse, error) {
+	repo := s.repo.ReadOnly()
+	team, err := repo.Team.GetTeamByName(ctx, teamName)
+	if err != nil {
+		return nil, err
+	}
+	memberStats, err := repo.Stats.TeamMemberStats(ctx, team.ID)
+	if err != nil {
+		return nil, err
+	}
+	prStats, err := repo.Stats.TeamPRStats(ctx, team.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.TeamStatsResponse{
+		TeamName:              team.Name,
+		AuthoredPRs:           prStats.Authored,
+		OpenPRs:               prStats.Open,
+		MergedPRs:             prStats.Merged,
+		AvgTimeToMergeSeconds: prStats.AvgTimeToMergeSeconds,
+		Members:               make([]models.MemberStatsResponse, len(memberStats)),
+	}
+	for _, member := range memberStats {
+		response.Load.OpenReviews += member.OpenReviews
+		// Каждое переназначение в команде учитываем один раз — по снятому ревьюеру
+		response.Reassignments += member.ReassignedFrom
+	}
+	for i, member := range memberStats {
+		var share float64
+		if response.Load.OpenReviews > 0 {
+			share = float64(member.OpenReviews) / float64(response.Load.OpenReviews)
+		}
+		response.Members[i] = models.MemberStatsResponse{
+			UserId:                member.UserID,
+			Username:              member.Username,
+			IsActive:              member.IsActive,
+			AssignedReviews:       member.AssignedReviews,
+			OpenReviews:           member.OpenReviews,
+			MergedReviews:         member.MergedReviews,
+			AvgTimeToMergeSeconds: member.AvgTimeToMergeSeconds,
+			ReassignedFrom:        member.ReassignedFrom,
+			ReassignedTo:          member.ReassignedTo,
+			LoadShare:             share,
+		}
+		if i == 0 || member.OpenReviews < response.Load.Min {
+			response.Load.Min = member.OpenReviews
+		}
+		if member.OpenReviews > response.Load.Max {
+			response.Load.Max = member.OpenReviews
+		}
+	}
+	if len(memberStats) > 0 {
+		response.Load.Mean = float64(response.Load.OpenReviews) / float64(len(memberStats))
+	}
+	return response, nil
+}
+
+func (s *reviewService) GetAssignmentStats(ctx context.Context, from, to *time.Time, teamName string) (*models.AssignmentStatsResponse, error) {
+	repo := s.repo.ReadOnly()
+	filter := models.StatsFilter{From: from, To: to}
+	if teamName != "" {
+		team, err := repo.Team.GetTeamByName(ctx, teamName)
+		if err != nil {
+			return nil, err
+		}
+		filter.TeamID = team.ID
+	}
+
+	perUser, err := repo.Stats.ReviewsPerUser(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	buckets, err := repo.Stats.PRsByReviewerCount(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	noCandidate, err := repo.Stats.NoCandidateCount(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	merges, err := repo.Stats.MergesPerDay(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	byCount := make([]models.ReviewerCountBucket, s.maxReviewers+1)
+	for i := range byCount {
+		byCount[i].Reviewers = i
+	}
+	for _, bucket := range buckets {
+		if bucket.Reviewers < len(byCount) {
+			byCount[bucket.Reviewers].PRs = bucket.PRs
+		} else {
+			byCount = append(byCount, bucket)
+		}
+	}
+	if perUser == nil {
+		perUser = []models.UserReviewCount{}
+	}
+	if merges == nil {
+		merges = []models.DailyMerges{}
+	}
+
+	return &models.AssignmentStatsResponse{
+		From:               from,
+		To:                 to,
+		TeamName:           teamName,
+		ReviewsPerUser:     perUser,
+		PRsByReviewerCount: byCount,
+		NoCandidateCount:   noCandidate,
+		MergesPerDay:       merges,
+	}, nil
+}
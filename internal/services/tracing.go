@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/errors"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracingService оборачивает ReviewService и открывает спан на каждый вызов
+type tracingService struct {
+	next ReviewService
+}
+
+func NewTracingService(next ReviewService) ReviewService {
+	return &tracingService{next: next}
+}
+
+func (s *tracingService) start(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, "ReviewService."+method, trace.WithAttributes(attrs...))
+}
+
+// finish закрывает спан. Доменные ошибки (NOT_FOUND, PR_MERGED и т.п.) —
+// штатный результат, поэтому отмечаем только код; остальные считаем сбоем.
+func finish(span trace.Span, err error) {
+	defer span.End()
+	if err == nil {
+		return
+	}
+	if appErr, ok := err.(*errors.AppError); ok {
+		span.SetAttributes(attribute.String("error.code", string(appErr.Code)))
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+func (s *tracingService) CreateTeam(ctx context.Context, teamName string, members []models.TeamMember) (*models.TeamResponse, error) {
+	ctx, span := s.start(ctx, "CreateTeam",
+		attribute.String("team.name", teamName), attribute.Int("team.members", len(members)))
+	result, err := s.next.CreateTeam(ctx, teamName, members)
+	finish(span, err)
+	return result, err
+}
+
+func (s *tracingService) GetTeam(ctx context.Context, teamName string) (*models.TeamResponse, error) {
+	ctx, span := s.start(ctx, "GetTeam", attribute.String("team.name", teamName))
+	result, err := s.next.GetTeam(ctx, teamName)
+	finish(span, err)
+	return result, err
+}
+
+func (s *tracingService) SetTeamRole(ctx context.Context, teamName, userID, role string) (*models.TeamRoleResponse, error) {
+	ctx, span := s.start(ctx, "SetTeamRole",
+		attribute.String("team.name", teamName), attribute.String("user.id", userID), attribute.String("team.role", role))
+	result, err := s.next.SetTeamRole(ctx, teamName, userID, role)
+	finish(span, err)
+	return result, err
+}
+
+func (s *tracingService) SetUserActive(ctx context.Context, userID string, isActive bool) (*models.UserResponse, error) {
+	ctx, span := s.start(ctx, "SetUserActive",
+		attribute.String("user.id", userID), attribute.Bool("user.is_active", isActive))
+	result, err := s.next.SetUserActive(ctx, userID, isActive)
+	finish(span, err)
+	return result, err
+}
+
+func (s *tracingService) GetUserReviews(ctx context.Context, userID string) (*models.UserPRsResponse, error) {
+	ctx, span := s.start(ctx, "GetUserReviews", attribute.String("user.id", userID))
+	result, err := s.next.GetUserReviews(ctx, userID)
+	finish(span, err)
+	return result, err
+}
+
+func (s *tracingService) CreatePR(ctx context.Context, prID, title, authorID string) (*models.PullRequestShort, error) {
+	ctx, span := s.start(ctx, "CreatePR",
+		attribute.String("pr.id", prID), attribute.String("pr.author_id", authorID))
+	result, err := s.next.CreatePR(ctx, prID, title, authorID)
+	finish(span, err)
+	return result, err
+}
+
+func (s *tracingService) BatchCreatePRs(ctx context.Context, items []models.CreatePRRequest, mode string) (*models.BatchCreateResponse, error) {
+	ctx, span := s.start(ctx, "BatchCreatePRs",
+		attribute.Int("batch.size", len(items)), attribute.String("batch.mode", mode))
+	result, err := s.next.BatchCreatePRs(ctx, items, mode)
+	if result != nil {
+		span.SetAttributes(attribute.Int("batch.failed", result.Failed))
+	}
+	finish(span, err)
+	return result, err
+}
+
+func (s *tracingService) GetPR(ctx context.Context, prID string) (*models.PullRequestResponse, error) {
+	ctx, span := s.start(ctx, "GetPR", attribute.String("pr.id", prID))
+	result, err := s.next.GetPR(ctx, prID)
+	finish(span, err)
+	return result, err
+}
+
+func (s *tracingService) MergePR(ctx context.Context, prID string, expectedVersion int) (*models.PullRequestResponse, error) {
+	ctx, span := s.start(ctx, "MergePR",
+		attribute.String("pr.id", prID), attribute.Int("pr.expected_version", expectedVersion))
+	result, err := s.next.MergePR(ctx, prID, expectedVersion)
+	finish(span, err)
+	return result, err
+}
+
+func (s *tracingService) ClosePR(ctx context.Context, prID string) (*models.PullRequestResponse, error) {
+	ctx, span := s.start(ctx, "ClosePR", attribute.String("pr.id", prID))
+	result, err := s.next.ClosePR(ctx, prID)
+	finish(span, err)
+	return result, err
+}
+
+func (s *tracingService) ReopenPR(ctx context.Context, prID string) (*models.PullRequestResponse, error) {
+	ctx, span := s.start(ctx, "ReopenPR", attribute.String("pr.id", prID))
+	result, err := s.next.ReopenPR(ctx, prID)
+	finish(span, err)
+	return result, err
+}
+
+func (s *tracingService) MarkDraft(ctx context.Context, prID string) (*models.PullRequestResponse, error) {
+	ctx, span := s.start(ctx, "MarkDraft", attribute.String("pr.id", prID))
+	result, err := s.next.MarkDraft(ctx, prID)
+	finish(span, err)
+	return result, err
+}
+
+func (s *tracingService) MarkReady(ctx context.Context, prID string) (*models.PullRequestResponse, error) {
+	ctx, span := s.start(ctx, "MarkReady", attribute.String("pr.id", prID))
+	result, err := s.next.MarkReady(ctx, prID)
+	finish(span, err)
+	return result, err
+}
+
+func (s *tracingService) ReassignReviewer(ctx context.Context, prID, oldReviewerID string, expectedVersion int) (*models.ReassignResponse, error) {
+	ctx, span := s.start(ctx, "ReassignReviewer",
+		attribute.String("pr.id", prID), attribute.String("pr.old_reviewer_id", oldReviewerID),
+		attribute.Int("pr.expected_version", expectedVersion))
+	result, err := s.next.ReassignReviewer(ctx, prID, oldReviewerID, expectedVersion)
+	finish(span, err)
+	return result, err
+}
+
+func (s *tracingService) GetTeamStats(ctx context.Context, teamName string) (*models.TeamStatsResponse, error) {
+	ctx, span := s.start(ctx, "GetTeamStats", attribute.String("team.name", teamName))
+	result, err := s.next.GetTeamStats(ctx, teamName)
+	finish(span, err)
+	return result, err
+}
+
+func (s *tracingService) GetAssignmentStats(ctx context.Context, from, to *time.Time, teamName string) (*models.AssignmentStatsResponse, error) {
+	ctx, span := s.start(ctx, "GetAssignmentStats", attribute.String("team.name", teamName))
+	if from != nil {
+		span.SetAttributes(attribute.String("stats.from", from.Format(time.RFC3339)))
+	}
+	if to != nil {
+		span.SetAttributes(attribute.String("stats.to", to.Format(time.RFC3339)))
+	}
+	result, err := s.next.GetAssignmentStats(ctx, from, to, teamName)
+	finish(span, err)
+	return result, err
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/errors"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/events"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/repositories"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/webhooks"
+)
+
+// Ограничения выдачи /webhooks/deliveries
+const (
+	DefaultDeliveriesLimit = 50
+	MaxDeliveriesLimit     = 200
+)
+
+type webhookService struct {
+	repo *repositories.Repository
+	// allowHTTP разрешает адреса http:// — для локальной разработки
+	allowHTTP bool
+	// allowPrivate разрешает localhost и внутренние адреса — для локальной разработки
+	allowPrivate bool
+}
+
+func NewWebhookService(repo *repositories.Repository, allowHTTP, allowPrivate bool) WebhookService {
+	return &webhookService{repo: repo, allowHTTP: allowHTTP, allowPrivate: allowPrivate}
+}
+
+func (s *webhookService) CreateWebhook(ctx context.Context, teamName, rawURL string, eventTypes []string) (*models.CreateWebhookResponse, error) {
+	if err := s.validateURL(rawURL); err != nil {
+		return nil, err
+	}
+	if len(eventTypes) == 0 {
+		return nil, errors.NewInvalidInput("event_types must not be empty")
+	}
+	var types models.StringArray
+	for _, eventType := range eventTypes {
+		if !events.ValidType(eventType) {
+			return nil, errors.NewInvalidInput(fmt.Sprintf("unknown event type %q", eventType))
+		}
+		if !slices.Contains(types, eventType) {
+			types = append(types, eventType)
+		}
+	}
+	team, err := s.repo.Team.GetTeamByName(ctx, teamName)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := randomString(32, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return nil, err
+	}
+	id, err := randomString(8, hex.EncodeToString)
+	if err != nil {
+		return nil, err
+	}
+	webhook := &models.Webhook{
+		ID:         "wh_" + id,
+		TeamID:     team.ID,
+		URL:        rawURL,
+		Secret:     "whsec_" + secret,
+		EventTypes: types,
+	}
+	if err := s.repo.Webhooks.CreateWebhook(ctx, webhook); err != nil {
+		return nil, err
+	}
+	return &models.CreateWebhookResponse{
+		Webhook: convertWebhook(webhook, team.Name),
+		Secret:  webhook.Secret,
+	}, nil
+}
+
+func (s *webhookService) validateURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return errors.NewInvalidInput("url must be an absolute URL")
+	}
+	if u.Scheme != "https" && (u.Scheme != "http" || !s.allowHTTP) {
+		return errors.NewInvalidInput("url must use https")
+	}
+	if s.allowPrivate {
+		return nil
+	}
+	// Имена проверяются ещё раз при доставке, после разрешения DNS
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return errors.NewInvalidInput("url must not point to localhost")
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if err := webhooks.CheckAddress(addr); err != nil {
+			return errors.NewInvalidInput("url must point to a public address")
+		}
+	}
+	return nil
+}
+
+func (s *webhookService) ListWebhooks(ctx context.Context, teamName string) (*models.WebhookListResponse, error) {
+	team, err := s.repo.Team.GetTeamByName(ctx, teamName)
+	if err != nil {
+		return nil, err
+	}
+	webhooks, err := s.repo.Webhooks.ListWebhooks(ctx, team.ID)
+	if err != nil {
+		return nil, err
+	}
+	response := &models.WebhookListResponse{
+		TeamName: team.Name,
+		Webhooks: make([]models.WebhookResponse, len(webhooks)),
+	}
+	for i := range webhooks {
+		response.Webhooks[i] = convertWebhook(&webhooks[i], team.Name)
+	}
+	return response, nil
+}
+
+func (s *webhookService) DeleteWebhook(ctx context.Context, webhookID string) error {
+	if webhookID == "" {
+		return errors.NewInvalidInput("webhook_id is required")
+	}
+	return s.repo.Webhooks.DeleteWebhook(ctx, webhookID)
+}
+
+func (s *webhookService) ListDeliveries(ctx context.Context, webhookID string, limit int) (*models.WebhookDeliveriesResponse, error) {
+	if limit == 0 {
+		limit = DefaultDeliveriesLimit
+	}
+	if limit < 0 || limit > MaxDeliveriesLimit {
+		return nil, errors.NewInvalidInput(fmt.Sprintf("limit must be in 1..%d", MaxDeliveriesLimit))
+	}
+	if _, err := s.repo.Webhooks.GetWebhook(ctx, webhookID); err != nil {
+		return nil, err
+	}
+	deliveries, err := s.repo.Webhooks.ListDeliveries(ctx, webhookID, limit)
+	if err != nil {
+		return nil, err
+	}
+	response := &models.WebhookDeliveriesResponse{
+		WebhookID:  webhookID,
+		Deliveries: make([]models.WebhookDeliveryResponse, len(deliveries)),
+	}
+	for i, d := range deliveries {
+		item := models.WebhookDeliveryResponse{
+			DeliveryID:     d.ID,
+			EventID:        d.EventID,
+			EventType:      d.EventType,
+			Status:         d.Status,
+			Attempts:       d.Attempts,
+			LastStatusCode: d.LastStatusCode,
+			LastError:      d.LastError,
+			CreatedAt:      d.CreatedAt,
+			DeliveredAt:    d.DeliveredAt,
+			Payload:        json.RawMessage(d.Payload),
+		}
+		if d.Status == models.DeliveryPending {
+			item.NextAttemptAt = &deliveries[i].NextAttemptAt
+		}
+		response.Deliveries[i] = item
+	}
+	return response, nil
+}
+
+func convertWebhook(webhook *models.Webhook, teamName string) models.WebhookResponse {
+	return models.WebhookResponse{
+		WebhookID:  webhook.ID,
+		TeamName:   teamName,
+		URL:        webhook.URL,
+		EventTypes: webhook.EventTypes,
+		CreatedAt:  webhook.CreatedAt,
+	}
+}
+
+// randomString возвращает n случайных байт в кодировке encode
+func randomString(n int, encode func([]byte) string) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return encode(b), nil
+}
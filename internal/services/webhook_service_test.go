@@ -0,0 +1,184 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/auth"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/errors"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/events"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/outbox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pendingEvents читает из outbox ещё не опубликованные события и помечает их
+// опубликованными, чтобы следующий вызов вернул только новые
+func pendingEvents(t *testing.T, s *reviewService) []events.Event {
+	t.Helper()
+	ctx := context.Background()
+	rows, err := s.repo.Outbox.Pending(ctx, 100)
+	require.NoError(t, err)
+	evs := make([]events.Event, len(rows))
+	ids := make([]uint, len(rows))
+	for i, row := range rows {
+		evs[i], err = outbox.Decode(row)
+		require.NoError(t, err)
+		ids[i] = row.ID
+	}
+	require.NoError(t, s.repo.Outbox.MarkPublished(ctx, ids, time.Now()))
+	return evs
+}
+
+func eventTypes(evs []events.Event) []string {
+	types := make([]string, len(evs))
+	for i, ev := range evs {
+		types[i] = ev.Type
+	}
+	return types
+}
+
+func TestReviewService_WritesOutboxEvents(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+
+	_, err := s.CreateTeam(ctx, "backend", []models.TeamMember{
+		{UserId: "author", Username: "Author", IsActive: true},
+		{UserId: "u1", Username: "Alice", IsActive: true},
+		{UserId: "u2", Username: "Bob", IsActive: true},
+		{UserId: "u3", Username: "Carol", IsActive: true},
+	})
+	require.NoError(t, err)
+
+	pr, err := s.CreatePR(ctx, "pr-1", "feature", "author")
+	require.NoError(t, err)
+	evs := pendingEvents(t, s)
+	assert.Equal(t, []string{events.TypePRCreated, events.TypeReviewerAssigned, events.TypeReviewerAssigned}, eventTypes(evs))
+	for _, ev := range evs {
+		assert.Equal(t, "backend", ev.TeamName)
+		assert.Equal(t, "pr-1", ev.Key)
+		assert.NotEmpty(t, ev.ID)
+	}
+	assert.JSONEq(t, `{"pull_request_id":"pr-1","pull_request_name":"feature","author_id":"author","reviewer_id":"`+
+		pr.AssignedReviewers[0]+`"}`, string(evs[1].Data.(json.RawMessage)))
+
+	reassigned, err := s.ReassignReviewer(ctx, "pr-1", pr.AssignedReviewers[0], 0)
+	require.NoError(t, err)
+	evs = pendingEvents(t, s)
+	assert.Equal(t, []string{events.TypeReviewerReassigned, events.TypeReviewerAssigned}, eventTypes(evs))
+	assert.JSONEq(t, `{"pull_request_id":"pr-1","old_reviewer_id":"`+pr.AssignedReviewers[0]+
+		`","new_reviewer_id":"`+reassigned.ReplacedBy+`"}`, string(evs[0].Data.(json.RawMessage)))
+
+	// Откат транзакции откатывает и события
+	_, err = s.CreatePR(ctx, "pr-1", "feature", "author")
+	require.Error(t, err)
+	_, err = s.BatchCreatePRs(ctx, []models.CreatePRRequest{
+		{PullRequestID: "pr-9", PullRequestName: "a", AuthorID: "author"},
+		{PullRequestID: "pr-9", PullRequestName: "b", AuthorID: "author"},
+	}, BatchModeAtomic)
+	require.Error(t, err)
+	assert.Empty(t, pendingEvents(t, s))
+
+	_, err = s.MergePR(ctx, "pr-1", 0)
+	require.NoError(t, err)
+	_, err = s.MergePR(ctx, "pr-1", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{events.TypePRMerged}, eventTypes(pendingEvents(t, s)))
+
+	_, err = s.SetUserActive(ctx, "u3", false)
+	require.NoError(t, err)
+	_, err = s.SetUserActive(ctx, "u3", false)
+	require.NoError(t, err)
+	_, err = s.SetUserActive(ctx, "u3", true)
+	require.NoError(t, err)
+	evs = pendingEvents(t, s)
+	require.Equal(t, []string{events.TypeUserDeactivated}, eventTypes(evs))
+	assert.Equal(t, "u3", evs[0].Key)
+	assert.JSONEq(t, `{"user_id":"u3","username":"Carol"}`, string(evs[0].Data.(json.RawMessage)))
+
+	_, err = s.BatchCreatePRs(ctx, []models.CreatePRRequest{
+		{PullRequestID: "pr-2", PullRequestName: "a", AuthorID: "author"},
+		{PullRequestID: "pr-3", PullRequestName: "b", AuthorID: "author"},
+	}, BatchModeAtomic)
+	require.NoError(t, err)
+	created := 0
+	for _, ev := range pendingEvents(t, s) {
+		if ev.Type == events.TypePRCreated {
+			created++
+		}
+	}
+	assert.Equal(t, 2, created)
+}
+
+func TestWebhookService(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	webhooks := NewWebhookPolicyService(NewWebhookService(s.repo, false, false), s.repo)
+
+	_, err := s.CreateTeam(ctx, "backend", []models.TeamMember{
+		{UserId: "lead", Username: "Lead", IsActive: true},
+		{UserId: "u1", Username: "Alice", IsActive: true},
+	})
+	require.NoError(t, err)
+	_, err = s.SetTeamRole(ctx, "backend", "lead", auth.RoleLead)
+	require.NoError(t, err)
+	as := func(userID string) context.Context {
+		return auth.WithPrincipal(ctx, &auth.Principal{TokenID: "tok-" + userID, Scope: auth.ScopeUser, UserID: userID})
+	}
+
+	t.Run("validation", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			url   string
+			types []string
+		}{
+			{"http", "http://example.com/hook", []string{events.TypePRCreated}},
+			{"relative", "/hook", []string{events.TypePRCreated}},
+			{"localhost", "https://localhost:8443/hook", []string{events.TypePRCreated}},
+			{"loopback", "https://127.0.0.1/hook", []string{events.TypePRCreated}},
+			{"metadata", "https://169.254.169.254/latest/meta-data", []string{events.TypePRCreated}},
+			{"private", "https://10.0.0.5/hook", []string{events.TypePRCreated}},
+			{"ipv6 loopback", "https://[::1]/hook", []string{events.TypePRCreated}},
+			{"mapped ipv4", "https://[::ffff:192.168.1.1]/hook", []string{events.TypePRCreated}},
+			{"unspecified", "https://0.0.0.0/hook", []string{events.TypePRCreated}},
+			{"no types", "https://example.com/hook", nil},
+			{"unknown type", "https://example.com/hook", []string{"pr.deleted"}},
+		}
+		for _, tt := range tests {
+			_, err := webhooks.CreateWebhook(ctx, "backend", tt.url, tt.types)
+			assert.True(t, errors.IsInvalidInput(err), "%s: %v", tt.name, err)
+		}
+		_, err := webhooks.CreateWebhook(ctx, "missing", "https://example.com/hook", []string{events.TypePRCreated})
+		assert.True(t, errors.IsNotFound(err))
+	})
+
+	_, err = webhooks.CreateWebhook(as("u1"), "backend", "https://example.com/hook", []string{events.TypePRCreated})
+	assert.True(t, errors.IsForbidden(err))
+
+	created, err := webhooks.CreateWebhook(as("lead"), "backend", "https://example.com/hook",
+		[]string{events.TypePRCreated, events.TypePRMerged, events.TypePRCreated})
+	require.NoError(t, err)
+	assert.Contains(t, created.Secret, "whsec_")
+	assert.Equal(t, []string{events.TypePRCreated, events.TypePRMerged}, created.Webhook.EventTypes)
+	webhookID := created.Webhook.WebhookID
+
+	list, err := webhooks.ListWebhooks(as("lead"), "backend")
+	require.NoError(t, err)
+	require.Len(t, list.Webhooks, 1)
+	assert.Equal(t, "backend", list.Webhooks[0].TeamName)
+
+	deliveries, err := webhooks.ListDeliveries(as("lead"), webhookID, 0)
+	require.NoError(t, err)
+	assert.Empty(t, deliveries.Deliveries)
+	_, err = webhooks.ListDeliveries(as("u1"), webhookID, 0)
+	assert.True(t, errors.IsForbidden(err))
+	_, err = webhooks.ListDeliveries(ctx, webhookID, MaxDeliveriesLimit+1)
+	assert.True(t, errors.IsInvalidInput(err))
+
+	assert.True(t, errors.IsForbidden(webhooks.DeleteWebhook(as("u1"), webhookID)))
+	require.NoError(t, webhooks.DeleteWebhook(as("lead"), webhookID))
+	assert.True(t, errors.IsNotFound(webhooks.DeleteWebhook(as("lead"), webhookID)))
+}
@@ -0,0 +1,93 @@
+package tracing_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/db"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/handlers"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/repositories"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/services"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/tracing"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracing_CreatePR(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+
+	database, err := db.Open("sqlite://" + filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(database.Close)
+
+	service := services.NewTracingService(services.NewReviewService(repositories.NewRepository(database.DB)))
+	_, err = service.CreateTeam(context.Background(), "backend", []models.TeamMember{
+		{UserId: "u1", Username: "Alice", IsActive: true},
+		{UserId: "u2", Username: "Bob", IsActive: true},
+	})
+	require.NoError(t, err)
+	recorder.Reset()
+
+	router := gin.New()
+	router.Use(tracing.Middleware())
+	router.Use(handlers.ErrorHandler())
+	router.POST("/pullRequest/create", handlers.NewHandler(service).CreatePR)
+
+	// Execute
+	body, _ := json.Marshal(models.CreatePRRequest{PullRequestID: "pr-1", PullRequestName: "Add search", AuthorID: "u1"})
+	req, _ := http.NewRequest(http.MethodPost, "/pullRequest/create", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	// Трейс продолжается из traceparent клиента
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	spans := recorder.Ended()
+	byName := make(map[string]sdktrace.ReadOnlySpan)
+	var queries []sdktrace.ReadOnlySpan
+	for _, span := range spans {
+		if strings.HasPrefix(span.Name(), "gorm.") {
+			queries = append(queries, span)
+			continue
+		}
+		byName[span.Name()] = span
+	}
+
+	server, ok := byName["POST /pullRequest/create"]
+	require.True(t, ok)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext().TraceID().String())
+
+	serviceSpan, ok := byName["ReviewService.CreatePR"]
+	require.True(t, ok)
+	assert.Equal(t, server.SpanContext().SpanID(), serviceSpan.Parent().SpanID())
+
+	require.NotEmpty(t, queries)
+	for _, query := range queries {
+		assert.Equal(t, serviceSpan.SpanContext().SpanID(), query.Parent().SpanID(), query.Name())
+	}
+}
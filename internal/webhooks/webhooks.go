@@ -0,0 +1,295 @@
+// Package webhooks доставляет события на HTTPS-адреса, которые зарегистрировали команды.
+//
+// Publish только ставит доставки в очередь (таблица webhook_deliveries), отправляет
+// их Run: подписывает тело HMAC, повторяет неудачные попытки с экспоненциальной
+// задержкой и после MaxAttempts помечает доставку failed.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/errors"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/events"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/health"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/metrics"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/repositories"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/retryqueue"
+)
+
+// Заголовки доставки
+const (
+	HeaderID        = "X-Webhook-Id"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Sign возвращает подпись доставки: HMAC-SHA256 от "<timestamp>.<body>" в hex
+// с префиксом sha256=. Метка времени в подписи не даёт переотправить старый запрос.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify проверяет подпись так, как это должен делать получатель
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+type Config struct {
+	// PollInterval — как часто проверять очередь, если новых событий нет
+	PollInterval time.Duration
+	// Timeout — таймаут одного запроса к получателю
+	Timeout     time.Duration
+	MaxAttempts int
+	// Задержка перед повтором: InitialBackoff, затем вдвое больше, но не больше MaxBackoff
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// AllowPrivate разрешает доставку на внутренние адреса — только для локальной разработки
+	AllowPrivate bool
+}
+
+// Dispatcher ставит события в очередь доставки и отправляет их
+type Dispatcher struct {
+	repo   *repositories.Repository
+	client *http.Client
+	cfg    Config
+	now    func() time.Time
+	queue  *retryqueue.Queue[models.WebhookDelivery]
+}
+
+// reservedPrefixes — непубличные диапазоны, которые не покрывают методы netip.Addr
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),      // «эта сеть»
+	netip.MustParsePrefix("100.64.0.0/10"),  // CGNAT
+	netip.MustParsePrefix("192.0.0.0/24"),   // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"),  // сети для бенчмарков
+	netip.MustParsePrefix("240.0.0.0/4"),    // зарезервировано, включая broadcast
+	netip.MustParsePrefix("::/96"),          // устаревшие IPv4-compatible адреса
+	netip.MustParsePrefix("64:ff9b:1::/48"), // NAT64 для локального использования
+}
+
+// nat64Prefix — well-known префикс NAT64: в младших 32 битах лежит IPv4-адрес
+var nat64Prefix = netip.MustParsePrefix("64:ff9b::/96")
+
+// CheckAddress отклоняет адреса, на которые нельзя слать вебхуки: loopback,
+// частные сети, CGNAT, link-local (в том числе метаданные облака), multicast,
+// зарезервированные диапазоны, а также IPv4-mapped и NAT64-формы таких адресов
+func CheckAddress(addr netip.Addr) error {
+	addr = addr.Unmap()
+	if nat64Prefix.Contains(addr) {
+		v6 := addr.As16()
+		addr = netip.AddrFrom4([4]byte(v6[12:]))
+	}
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() || addr.IsUnspecified() {
+		return fmt.Errorf("address %s is not public", addr)
+	}
+	for _, prefix := range reservedPrefixes {
+		if prefix.Contains(addr) {
+			return fmt.Errorf("address %s is not public", addr)
+		}
+	}
+	return nil
+}
+
+// dialControl проверяет адрес уже после разрешения имени, поэтому подмена
+// DNS между регистрацией вебхука и доставкой не помогает
+func dialControl(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	return CheckAddress(addrPort.Addr())
+}
+
+// NewDispatcher создаёт диспетчер. Если client nil, используется клиент,
+// который не следует редиректам и не ходит на внутренние адреса (если не AllowPrivate).
+func NewDispatcher(repo *repositories.Repository, client *http.Client, cfg Config) *Dispatcher {
+	if client == nil {
+		dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+		if !cfg.AllowPrivate {
+			dialer.Control = dialControl
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		// Прокси из окружения обошёл бы проверку адреса
+		transport.Proxy = nil
+		transport.DialContext = dialer.DialContext
+		client = &http.Client{
+			Transport: transport,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+	}
+	d := &Dispatcher{
+		repo:   repo,
+		client: client,
+		cfg:    cfg,
+		now:    time.Now,
+	}
+	d.queue = retryqueue.New("webhook_delivery", retryqueue.Config{
+		PollInterval:   cfg.PollInterval,
+		Timeout:        cfg.Timeout,
+		MaxAttempts:    cfg.MaxAttempts,
+		InitialBackoff: cfg.InitialBackoff,
+		MaxBackoff:     cfg.MaxBackoff,
+	}, func() time.Time { return d.now() }, repo.Webhooks.ClaimDueDeliveries, d.deliver)
+	return d
+}
+
+// Publish создаёт доставки для вебхуков команды события, подписанных на его тип
+func (d *Dispatcher) Publish(ctx context.Context, evs ...events.Event) error {
+	hooksByTeam := make(map[string][]models.Webhook)
+	var deliveries []models.WebhookDelivery
+	for _, ev := range evs {
+		hooks, ok := hooksByTeam[ev.TeamName]
+		if !ok {
+			var err error
+			if hooks, err = d.teamWebhooks(ctx, ev.TeamName); err != nil {
+				return err
+			}
+			hooksByTeam[ev.TeamName] = hooks
+		}
+		var payload []byte
+		for _, hook := range hooks {
+			if !slices.Contains(hook.EventTypes, ev.Type) {
+				continue
+			}
+			if payload == nil {
+				var err error
+				if payload, err = json.Marshal(ev); err != nil {
+					return err
+				}
+			}
+			deliveries = append(deliveries, models.WebhookDelivery{
+				ID:            newDeliveryID(),
+				WebhookID:     hook.ID,
+				EventID:       ev.ID,
+				EventType:     ev.Type,
+				Payload:       string(payload),
+				Status:        models.DeliveryPending,
+				NextAttemptAt: d.now().UTC(),
+			})
+		}
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+	if err := d.repo.Webhooks.CreateDeliveries(ctx, deliveries); err != nil {
+		return err
+	}
+	d.queue.Wake()
+	return nil
+}
+
+func (d *Dispatcher) teamWebhooks(ctx context.Context, teamName string) ([]models.Webhook, error) {
+	team, err := d.repo.Team.GetTeamByName(ctx, teamName)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return d.repo.Webhooks.ListWebhooks(ctx, team.ID)
+}
+
+// Run отправляет доставки, пока не отменён ctx
+func (d *Dispatcher) Run(ctx context.Context, heartbeat *health.Heartbeat) {
+	d.queue.Run(ctx, heartbeat)
+}
+
+// DeliverDue отправляет доставки, срок которых подошёл, и возвращает их число
+func (d *Dispatcher) DeliverDue(ctx context.Context) (int, error) {
+	return d.queue.ProcessDue(ctx)
+}
+
+// deliver делает одну попытку и сохраняет её результат
+func (d *Dispatcher) deliver(ctx context.Context, delivery *models.WebhookDelivery) error {
+	hook, err := d.repo.Webhooks.GetWebhook(ctx, delivery.WebhookID)
+	if err != nil {
+		// Вебхук удалён вместе с доставками, пока доставка была в работе
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	statusCode, sendErr := d.send(ctx, hook, delivery)
+	now := d.now().UTC()
+	delivery.Attempts++
+	delivery.LastStatusCode = statusCode
+	var next time.Time
+	delivery.Status, next, delivery.LastError = d.queue.Settle(delivery.Attempts, sendErr, now)
+	switch delivery.Status {
+	case models.DeliveryDelivered:
+		delivery.DeliveredAt = &now
+		metrics.WebhookAttempt("delivered")
+	case models.DeliveryFailed:
+		metrics.WebhookAttempt("failed")
+	default:
+		delivery.NextAttemptAt = next
+		metrics.WebhookAttempt("retry")
+	}
+	if sendErr != nil {
+		slog.Warn("webhook delivery attempt failed",
+			"webhook_id", hook.ID, "delivery_id", delivery.ID, "attempt", delivery.Attempts, "error", sendErr.Error())
+	}
+	return d.repo.Webhooks.UpdateDelivery(ctx, delivery)
+}
+
+func (d *Dispatcher) send(ctx context.Context, hook *models.Webhook, delivery *models.WebhookDelivery) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.cfg.Timeout)
+	defer cancel()
+
+	body := []byte(delivery.Payload)
+	timestamp := d.now().Unix()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderID, delivery.ID)
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(hook.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// Дочитываем тело, чтобы соединение вернулось в пул
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+func newDeliveryID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return "dlv_" + hex.EncodeToString(b)
+}
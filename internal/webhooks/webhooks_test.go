@@ -0,0 +1,260 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/db"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/events"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/models"
+	"github.com/SANEKNAYMCHIK/Avito-backend-project-autumn-2025/internal/repositories"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "whsec_test"
+
+func TestSignVerify(t *testing.T) {
+	body := []byte(`{"type":"pr.created"}`)
+	signature := Sign(testSecret, 1700000000, body)
+	assert.Regexp(t, `^sha256=[0-9a-f]{64}$`, signature)
+	assert.True(t, Verify(testSecret, 1700000000, body, signature))
+	assert.False(t, Verify(testSecret, 1700000001, body, signature))
+	assert.False(t, Verify("other", 1700000000, body, signature))
+	assert.False(t, Verify(testSecret, 1700000000, []byte(`{}`), signature))
+}
+
+// setup создаёт базу с командой backend и вебхуком на адрес url
+func setup(t *testing.T, url string, eventTypes ...string) *repositories.Repository {
+	t.Helper()
+	ctx := context.Background()
+	database, err := db.Open("sqlite://" + filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(database.Close)
+	repo := repositories.NewRepository(database.DB)
+	require.NoError(t, repo.Team.CreateTeam(ctx, &models.Team{ID: "t1", Name: "backend"}, nil))
+	require.NoError(t, repo.Webhooks.CreateWebhook(ctx, &models.Webhook{
+		ID: "wh-1", TeamID: "t1", URL: url, Secret: testSecret, EventTypes: eventTypes,
+	}))
+	return repo
+}
+
+func newTestDispatcher(repo *repositories.Repository, client *http.Client, now *time.Time) *Dispatcher {
+	d := NewDispatcher(repo, client, Config{
+		PollInterval:   time.Second,
+		Timeout:        time.Second,
+		MaxAttempts:    3,
+		InitialBackoff: time.Minute,
+		MaxBackoff:     90 * time.Second,
+	})
+	d.now = func() time.Time { return *now }
+	return d
+}
+
+func TestDispatcher_DeliversSigned(t *testing.T) {
+	ctx := context.Background()
+	received := make(chan *http.Request, 1)
+	var body []byte
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		received <- r
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	repo := setup(t, server.URL, events.TypePRCreated)
+	now := time.Now()
+	d := newTestDispatcher(repo, server.Client(), &now)
+
+	ev := events.New(events.TypePRCreated, "backend", "pr-1", events.PullRequestData{
+		PullRequest: &models.PullRequestResponse{PullRequestId: "pr-1", Status: "OPEN"},
+	})
+	// На pr.merged вебхук не подписан, события чужих команд его не касаются
+	other := events.New(events.TypePRMerged, "backend", "pr-1", nil)
+	foreign := events.New(events.TypePRCreated, "frontend", "pr-2", nil)
+	require.NoError(t, d.Publish(ctx, ev, other, foreign))
+
+	n, err := d.DeliverDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	req := <-received
+	assert.Equal(t, events.TypePRCreated, req.Header.Get(HeaderEvent))
+	timestamp, err := strconv.ParseInt(req.Header.Get(HeaderTimestamp), 10, 64)
+	require.NoError(t, err)
+	assert.True(t, Verify(testSecret, timestamp, body, req.Header.Get(HeaderSignature)))
+	var payload map[string]any
+	require.NoError(t, json.Unmarshal(body, &payload))
+	assert.Equal(t, ev.ID, payload["id"])
+	assert.Equal(t, "backend", payload["team_name"])
+
+	deliveries, err := repo.Webhooks.ListDeliveries(ctx, "wh-1", 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, req.Header.Get(HeaderID), deliveries[0].ID)
+	assert.Equal(t, models.DeliveryDelivered, deliveries[0].Status)
+	assert.Equal(t, 1, deliveries[0].Attempts)
+	assert.Equal(t, http.StatusNoContent, deliveries[0].LastStatusCode)
+	assert.NotNil(t, deliveries[0].DeliveredAt)
+}
+
+func TestDispatcher_RetriesWithBackoff(t *testing.T) {
+	ctx := context.Background()
+	var calls atomic.Int32
+	var failures atomic.Int32
+	failures.Store(1)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if failures.Add(-1) >= 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	repo := setup(t, server.URL, events.TypePRMerged)
+	now := time.Now()
+	d := newTestDispatcher(repo, server.Client(), &now)
+	require.NoError(t, d.Publish(ctx, events.New(events.TypePRMerged, "backend", "pr-1", nil)))
+
+	_, err := d.DeliverDue(ctx)
+	require.NoError(t, err)
+	deliveries, err := repo.Webhooks.ListDeliveries(ctx, "wh-1", 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, models.DeliveryPending, deliveries[0].Status)
+	assert.Equal(t, http.StatusServiceUnavailable, deliveries[0].LastStatusCode)
+	assert.Contains(t, deliveries[0].LastError, "503")
+
+	// До окончания задержки повтора нет
+	now = now.Add(30 * time.Second)
+	n, err := d.DeliverDue(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	now = now.Add(time.Minute)
+	n, err = d.DeliverDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, int32(2), calls.Load())
+
+	deliveries, err = repo.Webhooks.ListDeliveries(ctx, "wh-1", 10)
+	require.NoError(t, err)
+	assert.Equal(t, models.DeliveryDelivered, deliveries[0].Status)
+	assert.Equal(t, 2, deliveries[0].Attempts)
+	assert.Empty(t, deliveries[0].LastError)
+}
+
+func TestDispatcher_FailsAfterMaxAttempts(t *testing.T) {
+	ctx := context.Background()
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	repo := setup(t, server.URL, events.TypeUserDeactivated)
+	now := time.Now()
+	d := newTestDispatcher(repo, server.Client(), &now)
+	require.NoError(t, d.Publish(ctx, events.New(events.TypeUserDeactivated, "backend", "u1", nil)))
+
+	for range 3 {
+		_, err := d.DeliverDue(ctx)
+		require.NoError(t, err)
+		now = now.Add(time.Hour)
+	}
+	n, err := d.DeliverDue(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	deliveries, err := repo.Webhooks.ListDeliveries(ctx, "wh-1", 10)
+	require.NoError(t, err)
+	assert.Equal(t, models.DeliveryFailed, deliveries[0].Status)
+	assert.Equal(t, 3, deliveries[0].Attempts)
+}
+
+func TestDispatcher_RefusesPrivateAddresses(t *testing.T) {
+	ctx := context.Background()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}))
+	defer server.Close()
+
+	// Имя localhost разрешается в 127.0.0.1 — проверка срабатывает при соединении
+	url := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+	repo := setup(t, url, events.TypeUserDeactivated)
+	now := time.Now()
+	d := newTestDispatcher(repo, nil, &now)
+	require.NoError(t, d.Publish(ctx, events.New(events.TypeUserDeactivated, "backend", "u1", nil)))
+	_, err := d.DeliverDue(ctx)
+	require.NoError(t, err)
+
+	assert.Zero(t, calls.Load())
+	deliveries, err := repo.Webhooks.ListDeliveries(ctx, "wh-1", 10)
+	require.NoError(t, err)
+	assert.Equal(t, models.DeliveryPending, deliveries[0].Status)
+	assert.Contains(t, deliveries[0].LastError, "is not public")
+}
+
+func TestCheckAddress(t *testing.T) {
+	tests := []struct {
+		addr    string
+		blocked bool
+	}{
+		{"127.0.0.1", true},
+		{"10.1.2.3", true},
+		{"172.16.0.1", true},
+		{"192.168.0.1", true},
+		{"169.254.169.254", true},
+		{"0.0.0.0", true},
+		{"0.1.2.3", true},
+		{"100.64.0.1", true},
+		{"100.127.255.254", true},
+		{"192.0.0.170", true},
+		{"198.18.0.1", true},
+		{"198.19.255.255", true},
+		{"240.0.0.1", true},
+		{"255.255.255.255", true},
+		{"224.0.0.1", true},
+		{"::", true},
+		{"::1", true},
+		{"fe80::1", true},
+		{"fc00::1", true},
+		{"::127.0.0.1", true},
+		{"::ffff:127.0.0.1", true},
+		{"::ffff:169.254.169.254", true},
+		{"::ffff:100.64.0.1", true},
+		{"64:ff9b::7f00:1", true},
+		{"64:ff9b::a9fe:a9fe", true},
+		{"64:ff9b::6440:1", true},
+		{"64:ff9b:1::808:808", true},
+		{"8.8.8.8", false},
+		{"100.63.255.255", false},
+		{"100.128.0.1", false},
+		{"198.20.0.1", false},
+		{"::ffff:8.8.8.8", false},
+		{"64:ff9b::808:808", false},
+		{"2a00:1450:4010::8a", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			err := CheckAddress(netip.MustParseAddr(tt.addr))
+			if tt.blocked {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}